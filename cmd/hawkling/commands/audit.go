@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/catalog"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/formatter"
+)
+
+// AuditOptions contains options for the audit commands
+type AuditOptions struct {
+	Days      int
+	Inventory string
+	Output    string
+
+	// Catalog is a local service role catalog adding to the built-in one
+	Catalog string
+	// OnlyOrphaned reports only service roles no resource uses any more
+	OnlyOrphaned bool
+	// ScanRegions limits the regions checked for resources that use the
+	// roles, instead of every region enabled in the account
+	ScanRegions []string
+}
+
+// AuditOIDCCommand represents the audit oidc command
+type AuditOIDCCommand struct {
+	profile string
+	region  string
+	options AuditOptions
+}
+
+// NewAuditOIDCCommand creates a new audit oidc command
+func NewAuditOIDCCommand(profile, region string, options AuditOptions) *AuditOIDCCommand {
+	return &AuditOIDCCommand{
+		profile: profile,
+		region:  region,
+		options: options,
+	}
+}
+
+// Execute runs the audit oidc command
+func (c *AuditOIDCCommand) Execute(ctx context.Context) error {
+	client, err := aws.NewAWSClient(ctx, c.profile, c.region)
+	if err != nil {
+		return errors.Wrap(err, "failed to create AWS client")
+	}
+
+	roles, err := client.ListRoles(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list roles")
+	}
+
+	providers, err := client.ListOpenIDConnectProviders(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list OIDC providers")
+	}
+
+	groups := audit.AuditOIDCTrust(roles, providers)
+
+	format := formatter.Format(strings.ToLower(c.options.Output))
+	if err := formatter.FormatOIDCGroups(groups, format); err != nil {
+		return errors.Wrap(err, "failed to format output")
+	}
+
+	return nil
+}
+
+// AuditPrivescCommand represents the audit privesc command
+type AuditPrivescCommand struct {
+	profile string
+	region  string
+	options AuditOptions
+}
+
+// NewAuditPrivescCommand creates a new audit privesc command
+func NewAuditPrivescCommand(profile, region string, options AuditOptions) *AuditPrivescCommand {
+	return &AuditPrivescCommand{
+		profile: profile,
+		region:  region,
+		options: options,
+	}
+}
+
+// Execute runs the audit privesc command
+func (c *AuditPrivescCommand) Execute(ctx context.Context) error {
+	inventory, err := loadInventory(ctx, c.profile, c.region, c.options.Inventory)
+	if err != nil {
+		return err
+	}
+
+	findings, skipped := audit.AuditPrivilegeEscalation(inventory, c.options.Days)
+
+	for _, role := range skipped {
+		fmt.Fprintf(os.Stderr, "Warning: Skipping role %s: %s\n", role.RoleName, role.Reason)
+	}
+
+	format := formatter.Format(strings.ToLower(c.options.Output))
+	if err := formatter.FormatEscalationFindings(findings, format); err != nil {
+		return errors.Wrap(err, "failed to format output")
+	}
+
+	return nil
+}
+
+// AuditServiceRolesCommand represents the audit service-roles command
+type AuditServiceRolesCommand struct {
+	profile string
+	region  string
+	options AuditOptions
+}
+
+// NewAuditServiceRolesCommand creates a new audit service-roles command
+func NewAuditServiceRolesCommand(profile, region string, options AuditOptions) *AuditServiceRolesCommand {
+	return &AuditServiceRolesCommand{
+		profile: profile,
+		region:  region,
+		options: options,
+	}
+}
+
+// Execute runs the audit service-roles command
+func (c *AuditServiceRolesCommand) Execute(ctx context.Context) error {
+	patterns, err := catalog.LoadServiceRoles(c.options.Catalog)
+	if err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+
+	inventory, err := loadInventory(ctx, c.profile, c.region, c.options.Inventory)
+	if err != nil {
+		return err
+	}
+
+	findings := audit.FindServiceRoles(inventory.Roles, patterns)
+	if len(findings) > 0 {
+		scanners, err := aws.NewReferenceScanners(ctx, c.profile, c.region, c.options.ScanRegions)
+		if err != nil {
+			return errors.Wrap(err, "failed to create reference scanners")
+		}
+
+		roleArns := make([]string, 0, len(findings))
+		for _, finding := range findings {
+			roleArns = append(roleArns, finding.RoleArn)
+		}
+		scanned := make([]string, 0, len(scanners))
+		for _, scanner := range scanners {
+			scanned = append(scanned, scanner.Service())
+		}
+
+		references, failures := aws.ScanReferences(ctx, scanners, roleArns)
+		if len(failures) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: Could not check whether roles are still in use: %s\n", aws.JoinScanFailures(failures))
+		}
+		audit.ClassifyServiceRoles(findings, scanned, c.options.ScanRegions, references, failures)
+	}
+
+	if c.options.OnlyOrphaned {
+		orphaned := findings[:0]
+		for _, finding := range findings {
+			if finding.Status == audit.ServiceRoleOrphaned {
+				orphaned = append(orphaned, finding)
+			}
+		}
+		findings = orphaned
+	}
+
+	format := formatter.Format(strings.ToLower(c.options.Output))
+	if err := formatter.FormatServiceRoleFindings(findings, format); err != nil {
+		return errors.Wrap(err, "failed to format output")
+	}
+
+	return nil
+}
+
+// AuditServiceRolesCatalogCommand represents the audit service-roles
+// catalog command
+type AuditServiceRolesCatalogCommand struct {
+	catalogPath string
+}
+
+// NewAuditServiceRolesCatalogCommand creates a new audit service-roles
+// catalog command
+func NewAuditServiceRolesCatalogCommand(catalogPath string) *AuditServiceRolesCatalogCommand {
+	return &AuditServiceRolesCatalogCommand{catalogPath: catalogPath}
+}
+
+// Execute prints the catalog as JSON, to be saved and edited as a local catalog
+func (c *AuditServiceRolesCatalogCommand) Execute(ctx context.Context) error {
+	patterns, err := catalog.LoadServiceRoles(c.catalogPath)
+	if err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(patterns)
+}
@@ -0,0 +1,286 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/backup"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/formatter"
+	"hawkling/pkg/lint"
+)
+
+// BoundaryOptions contains options for the boundary commands
+type BoundaryOptions struct {
+	FilterOptions
+	Config    string
+	Inventory string
+	Output    string
+	DryRun    bool
+	Force     bool
+	BackupDir string
+}
+
+// BoundaryBackup records the permissions boundary of a role before it was
+// changed, so the change can be rolled back
+type BoundaryBackup struct {
+	RoleName            string
+	PermissionsBoundary string
+}
+
+// BoundaryCheckCommand represents the boundary check command
+type BoundaryCheckCommand struct {
+	profile string
+	region  string
+	options BoundaryOptions
+}
+
+// NewBoundaryCheckCommand creates a new boundary check command
+func NewBoundaryCheckCommand(profile, region string, options BoundaryOptions) *BoundaryCheckCommand {
+	return &BoundaryCheckCommand{
+		profile: profile,
+		region:  region,
+		options: options,
+	}
+}
+
+// Execute runs the boundary check command
+func (c *BoundaryCheckCommand) Execute(ctx context.Context) error {
+	requirements, err := loadBoundaryRequirements(c.options)
+	if err != nil {
+		return err
+	}
+
+	inventory, err := loadInventory(ctx, c.profile, c.region, c.options.Inventory)
+	if err != nil {
+		return err
+	}
+
+	roles := aws.FilterRoles(inventory.Roles, c.options.FilterOptions.toAWS())
+	violations := audit.CheckBoundaryCompliance(roles, requirements)
+
+	format := formatter.Format(strings.ToLower(c.options.Output))
+	if err := formatter.FormatBoundaryViolations(violations, format); err != nil {
+		return errors.Wrap(err, "failed to format output")
+	}
+
+	return nil
+}
+
+// BoundaryApplyCommand represents the boundary apply command
+type BoundaryApplyCommand struct {
+	profile string
+	region  string
+	options BoundaryOptions
+}
+
+// NewBoundaryApplyCommand creates a new boundary apply command
+func NewBoundaryApplyCommand(profile, region string, options BoundaryOptions) *BoundaryApplyCommand {
+	return &BoundaryApplyCommand{
+		profile: profile,
+		region:  region,
+		options: options,
+	}
+}
+
+// Execute runs the boundary apply command
+func (c *BoundaryApplyCommand) Execute(ctx context.Context) error {
+	requirements, err := loadBoundaryRequirements(c.options)
+	if err != nil {
+		return err
+	}
+
+	client, err := aws.NewAWSClient(ctx, c.profile, c.region)
+	if err != nil {
+		return errors.Wrap(err, "failed to create AWS client")
+	}
+
+	// The boundary of a role is only returned with its authorization details
+	inventory, err := client.GetInventory(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get inventory")
+	}
+
+	roles := aws.FilterRoles(inventory.Roles, c.options.FilterOptions.toAWS())
+	violations := audit.CheckBoundaryCompliance(roles, requirements)
+	if len(violations) == 0 {
+		fmt.Println("All roles use their required permissions boundary")
+		return nil
+	}
+
+	fmt.Printf("Found %d IAM roles without their required permissions boundary:\n", len(violations))
+	for i, violation := range violations {
+		current := violation.Current
+		if current == "" {
+			current = "none"
+		}
+		fmt.Printf("%d. %s (%s -> %s)\n", i+1, violation.RoleName, current, violation.Required)
+	}
+
+	// If dry run, stop here
+	if c.options.DryRun {
+		fmt.Println("\nDRY RUN: No permissions boundaries were changed")
+		return nil
+	}
+
+	// Confirm the change if force flag is not set
+	if !c.options.Force {
+		prompt := fmt.Sprintf("\nAre you sure you want to set the permissions boundary of %d roles? [y/N]: ", len(violations))
+		confirmed, err := ConfirmAction(prompt)
+		if err != nil {
+			return errors.Wrap(err, "failed to read confirmation")
+		}
+
+		if !confirmed {
+			fmt.Println("Update cancelled")
+			return nil
+		}
+	}
+
+	var failedRoles []string
+	for _, violation := range violations {
+		// Back up the current boundary before replacing it
+		record := BoundaryBackup{RoleName: violation.RoleName, PermissionsBoundary: violation.Current}
+		backupPath, err := backup.SaveJSON(c.options.BackupDir, "permissions-boundary", violation.RoleName, record)
+		if err != nil {
+			failedRoles = append(failedRoles, violation.RoleName)
+			fmt.Printf("Failed to back up permissions boundary of role %s: %v\n", violation.RoleName, err)
+			continue
+		}
+
+		if err := client.PutRolePermissionsBoundary(ctx, violation.RoleName, violation.Required); err != nil {
+			failedRoles = append(failedRoles, violation.RoleName)
+			fmt.Printf("Failed to set permissions boundary of role %s: %v\n", violation.RoleName, err)
+			continue
+		}
+
+		fmt.Printf("Set permissions boundary of role %s (backup: %s)\n", violation.RoleName, backupPath)
+	}
+
+	if len(failedRoles) > 0 {
+		fmt.Printf("\nFailed to update %d roles: %s\n", len(failedRoles), strings.Join(failedRoles, ", "))
+		return errors.Errorf("failed to update %d roles", len(failedRoles))
+	}
+
+	fmt.Printf("\nSuccessfully set the permissions boundary of %d IAM roles\n", len(violations))
+	return nil
+}
+
+// BoundaryRestoreCommand represents the boundary restore command
+type BoundaryRestoreCommand struct {
+	profile     string
+	region      string
+	backupPaths []string
+	options     DeleteOptions
+}
+
+// NewBoundaryRestoreCommand creates a new boundary restore command
+func NewBoundaryRestoreCommand(profile, region string, backupPaths []string, options DeleteOptions) *BoundaryRestoreCommand {
+	return &BoundaryRestoreCommand{
+		profile:     profile,
+		region:      region,
+		backupPaths: backupPaths,
+		options:     options,
+	}
+}
+
+// Execute runs the boundary restore command
+func (c *BoundaryRestoreCommand) Execute(ctx context.Context) error {
+	records := make([]BoundaryBackup, 0, len(c.backupPaths))
+	for _, path := range c.backupPaths {
+		var record BoundaryBackup
+		if err := backup.LoadJSON(path, &record); err != nil {
+			return err
+		}
+		if record.RoleName == "" {
+			return errors.NewValidationError(fmt.Sprintf("%s is not a permissions boundary backup", path))
+		}
+		records = append(records, record)
+	}
+
+	fmt.Printf("Restoring the permissions boundary of %d IAM roles:\n", len(records))
+	for i, record := range records {
+		boundary := record.PermissionsBoundary
+		if boundary == "" {
+			boundary = "none"
+		}
+		fmt.Printf("%d. %s (-> %s)\n", i+1, record.RoleName, boundary)
+	}
+
+	// If dry run, stop here
+	if c.options.DryRun {
+		fmt.Println("\nDRY RUN: No permissions boundaries were changed")
+		return nil
+	}
+
+	// Confirm the restore if force flag is not set
+	if !c.options.Force {
+		prompt := fmt.Sprintf("\nAre you sure you want to restore the permissions boundary of %d roles? [y/N]: ", len(records))
+		confirmed, err := ConfirmAction(prompt)
+		if err != nil {
+			return errors.Wrap(err, "failed to read confirmation")
+		}
+
+		if !confirmed {
+			fmt.Println("Restore cancelled")
+			return nil
+		}
+	}
+
+	client, err := aws.NewAWSClient(ctx, c.profile, c.region)
+	if err != nil {
+		return errors.Wrap(err, "failed to create AWS client")
+	}
+
+	var failedRoles []string
+	for _, record := range records {
+		var err error
+		if record.PermissionsBoundary == "" {
+			err = client.DeleteRolePermissionsBoundary(ctx, record.RoleName)
+		} else {
+			err = client.PutRolePermissionsBoundary(ctx, record.RoleName, record.PermissionsBoundary)
+		}
+		if err != nil {
+			failedRoles = append(failedRoles, record.RoleName)
+			fmt.Printf("Failed to restore permissions boundary of role %s: %v\n", record.RoleName, err)
+			continue
+		}
+		fmt.Printf("Restored permissions boundary of role: %s\n", record.RoleName)
+	}
+
+	if len(failedRoles) > 0 {
+		return errors.Errorf("failed to restore %d roles", len(failedRoles))
+	}
+
+	fmt.Printf("\nSuccessfully restored the permissions boundary of %d IAM roles\n", len(records))
+	return nil
+}
+
+// loadBoundaryRequirements reads the required boundaries from the config file
+func loadBoundaryRequirements(options BoundaryOptions) ([]audit.BoundaryRequirement, error) {
+	if err := options.FilterOptions.validate(); err != nil {
+		return nil, err
+	}
+	if options.Config == "" {
+		return nil, errors.NewValidationError("--config is required")
+	}
+
+	config, err := lint.LoadConfig(options.Config)
+	if err != nil {
+		return nil, errors.NewValidationError(err.Error())
+	}
+	if len(config.RequiredBoundaries) == 0 {
+		return nil, errors.NewValidationError(fmt.Sprintf("%s defines no RequiredBoundaries", options.Config))
+	}
+
+	for _, requirement := range config.RequiredBoundaries {
+		if requirement.PathPrefix == "" || !strings.HasPrefix(requirement.Boundary, "arn:") {
+			return nil, errors.NewValidationError(fmt.Sprintf("invalid boundary requirement %+v: PathPrefix and a Boundary ARN are required", requirement))
+		}
+	}
+
+	return config.RequiredBoundaries, nil
+}
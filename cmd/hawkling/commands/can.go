@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"hawkling/pkg/errors"
+	"hawkling/pkg/formatter"
+	"hawkling/pkg/policy"
+)
+
+// CanOptions contains options for the can command
+type CanOptions struct {
+	Inventory string
+	Context   []string
+	Output    string
+}
+
+// CanCommand represents the can command
+type CanCommand struct {
+	profile  string
+	region   string
+	roleName string
+	action   string
+	resource string
+	options  CanOptions
+}
+
+// NewCanCommand creates a new can command
+func NewCanCommand(profile, region, roleName, action, resource string, options CanOptions) *CanCommand {
+	return &CanCommand{
+		profile:  profile,
+		region:   region,
+		roleName: roleName,
+		action:   action,
+		resource: resource,
+		options:  options,
+	}
+}
+
+// Execute runs the can command
+func (c *CanCommand) Execute(ctx context.Context) error {
+	requestContext, err := parseRequestContext(c.options.Context)
+	if err != nil {
+		return err
+	}
+
+	inventory, err := loadInventory(ctx, c.profile, c.region, c.options.Inventory)
+	if err != nil {
+		return err
+	}
+
+	role := inventory.FindRole(c.roleName)
+	if role == nil {
+		return errors.Errorf("role '%s' not found", c.roleName)
+	}
+
+	policySet, err := inventory.PolicySet(*role)
+	if err != nil {
+		return errors.Wrap(err, "failed to load role policies")
+	}
+
+	request := policy.Request{
+		Action:   c.action,
+		Resource: c.resource,
+		Context:  requestContext,
+	}
+	result := policySet.Evaluate(request)
+
+	format := formatter.Format(strings.ToLower(c.options.Output))
+	if err := formatter.FormatEvaluation(role.Name, request, result, format); err != nil {
+		return errors.Wrap(err, "failed to format output")
+	}
+
+	return nil
+}
+
+// parseRequestContext parses key=value condition context entries. A key
+// given more than once has multiple values.
+func parseRequestContext(entries []string) (map[string][]string, error) {
+	requestContext := make(map[string][]string, len(entries))
+	for _, entry := range entries {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			return nil, errors.NewValidationError(fmt.Sprintf("invalid context entry %q, expected key=value", entry))
+		}
+		requestContext[key] = append(requestContext[key], value)
+	}
+	return requestContext, nil
+}
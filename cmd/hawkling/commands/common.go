@@ -2,22 +2,123 @@ package commands
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
+	"path"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/backup"
 	"hawkling/pkg/errors"
 )
 
 // FilterOptions contains common filtering options used across commands
 type FilterOptions struct {
-	Days       int
-	OnlyUsed   bool
-	OnlyUnused bool
+	Days        int
+	OnlyUsed    bool
+	OnlyUnused  bool
+	PathPrefix  string
+	NamePattern string
+
+	// RequireAnalyzerAgreement selects unused roles only if Access Analyzer
+	// also reports them unused
+	RequireAnalyzerAgreement bool
+}
+
+// toAWS converts the options to the filter options used by the aws package
+func (o FilterOptions) toAWS() aws.FilterOptions {
+	return aws.FilterOptions{
+		Days:        o.Days,
+		OnlyUsed:    o.OnlyUsed,
+		OnlyUnused:  o.OnlyUnused,
+		PathPrefix:  o.PathPrefix,
+		NamePattern: o.NamePattern,
+
+		RequireAnalyzerAgreement: o.RequireAnalyzerAgreement,
+	}
+}
+
+// validate checks the options for invalid values
+func (o FilterOptions) validate() error {
+	if o.NamePattern != "" {
+		if _, err := path.Match(o.NamePattern, ""); err != nil {
+			return errors.NewValidationError(fmt.Sprintf("invalid name pattern %q: %v", o.NamePattern, err))
+		}
+	}
+	return nil
 }
 
+// scoreRoles sets the risk score of each role from the account's
+// authorization details, and returns them for further checks
+func scoreRoles(ctx context.Context, client aws.IAMClient, roles []aws.Role) (*aws.Inventory, error) {
+	inventory, err := client.GetInventory(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get inventory")
+	}
+
+	for _, role := range audit.ScoreRoles(inventory, roles, time.Now()) {
+		fmt.Fprintf(os.Stderr, "Warning: Could not score role %s: %s\n", role.RoleName, role.Reason)
+	}
+	return inventory, nil
+}
+
+// warnChainedRoles warns about roles that other roles chain through, since
+// deleting them breaks every assume-role chain that passes through
+func warnChainedRoles(inventory *aws.Inventory, roles []aws.Role) {
+	graph, _ := audit.BuildRoleGraph(inventory, 0)
+	for _, chain := range graph.Chains(roles) {
+		fmt.Fprintf(os.Stderr, "Warning: Role %s is part of assume-role chains: %s -> %s -> %s\n",
+			chain.RoleName, strings.Join(chain.From, ", "), chain.RoleName, strings.Join(chain.To, ", "))
+	}
+}
+
+// readUnusedAccess reads the findings of the account's unused-access
+// analyzer into the roles when requested, and warns about roles whose
+// last-used date disagrees with them. Requiring agreement between the two
+// signals fails if the account has no such analyzer.
+func readUnusedAccess(ctx context.Context, profile, region string, roles []aws.Role, options FilterOptions, analyzer bool) error {
+	if !analyzer && !options.RequireAnalyzerAgreement {
+		return nil
+	}
+
+	client, err := aws.NewAnalyzerClient(ctx, profile, region, "")
+	if err != nil {
+		return errors.Wrap(err, "failed to create Access Analyzer client")
+	}
+
+	analyzerArn, err := client.FindUnusedAccessAnalyzer(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to find unused access analyzer")
+	}
+	if analyzerArn == "" {
+		if options.RequireAnalyzerAgreement {
+			return errors.NewValidationError("--require-analyzer-agreement needs an active unused access analyzer")
+		}
+		fmt.Fprintln(os.Stderr, "Warning: No active unused access analyzer found")
+		return nil
+	}
+
+	findings, err := client.ListUnusedAccessFindings(ctx, analyzerArn)
+	if err != nil {
+		return errors.Wrap(err, "failed to list unused access findings")
+	}
+
+	aws.AttachUnusedAccess(roles, findings)
+	for _, disagreement := range audit.FindUsageDisagreements(roles, options.Days) {
+		fmt.Fprintf(os.Stderr, "Warning: Usage signals disagree for role %s: %s\n", disagreement.RoleName, disagreement.Reason)
+	}
+	return nil
+}
+
+// maxRoleConcurrency limits the number of parallel IAM calls made per role
+const maxRoleConcurrency = 10
+
 // ConfirmAction prompts the user for confirmation and returns their response
 func ConfirmAction(prompt string) (bool, error) {
 	fmt.Print(prompt)
@@ -63,3 +164,88 @@ func AddPruneFlags(cmd *cobra.Command, days *int, dryRun *bool, force *bool) {
 	cmd.Flags().BoolVar(dryRun, "dry-run", true, "Show what would be deleted without actually deleting")
 	cmd.Flags().BoolVarP(force, "force", "f", false, "Skip confirmation prompts")
 }
+
+// AddReferenceFlags adds the flags to delete roles that resources still use
+// and to choose the regions checked for them
+func AddReferenceFlags(cmd *cobra.Command, ignoreReferences *bool, scanRegions *[]string) {
+	cmd.Flags().BoolVar(ignoreReferences, "ignore-references", false, "Delete roles even if Lambda, ECS, EC2, Step Functions, CodeBuild, EventBridge or Glue resources still use them")
+	AddScanRegionsFlag(cmd, scanRegions)
+}
+
+// AddScanRegionsFlag adds the flag to choose the regions checked for
+// resources that use roles
+func AddScanRegionsFlag(cmd *cobra.Command, scanRegions *[]string) {
+	cmd.Flags().StringSliceVar(scanRegions, "scan-regions", nil, "Only check these regions for resources that use the roles (default: every region enabled in the account)")
+}
+
+// AddSelectionFlags adds flags that select roles by path and name
+func AddSelectionFlags(cmd *cobra.Command, pathPrefix *string, namePattern *string) {
+	cmd.Flags().StringVar(pathPrefix, "path-prefix", "", "Only include roles whose path starts with this prefix")
+	cmd.Flags().StringVar(namePattern, "name", "", "Only include roles whose name matches this glob pattern")
+}
+
+// AddAnalyzerFlags adds flags to use Access Analyzer unused-access findings
+func AddAnalyzerFlags(cmd *cobra.Command, analyzer *bool, requireAgreement *bool) {
+	cmd.Flags().BoolVar(analyzer, "analyzer", false, "Read Access Analyzer unused-access findings and report roles whose usage signals disagree")
+	cmd.Flags().BoolVar(requireAgreement, "require-analyzer-agreement", false, "Select unused roles only if Access Analyzer also reports them unused (implies --analyzer)")
+}
+
+// AddInventoryFlag adds a flag to read roles from a saved inventory
+func AddInventoryFlag(cmd *cobra.Command, inventory *string) {
+	cmd.Flags().StringVar(inventory, "inventory", "", "Read roles from a saved inventory file instead of AWS")
+}
+
+// AddTerraformFlag adds a flag to read Terraform state
+func AddTerraformFlag(cmd *cobra.Command, tfstate *[]string) {
+	cmd.Flags().StringArrayVar(tfstate, "tfstate", nil, "Read a local Terraform state file, or the *.tfstate files of a directory, to mark roles Terraform manages (repeatable)")
+}
+
+// AddCreatorFlags adds flags to look up who created each role
+func AddCreatorFlags(cmd *cobra.Command, options *CreatorOptions) {
+	cmd.Flags().BoolVar(&options.WithCreator, "with-creator", false, "Show who created each role and how, from its CloudTrail CreateRole event in the last 90 days")
+	cmd.Flags().StringVar(&options.CloudTrailDir, "cloudtrail-dir", "", "Read CreateRole events from CloudTrail log files in this directory instead of the event history (implies --with-creator)")
+}
+
+// AddModifyFlags adds flags for commands that modify roles
+func AddModifyFlags(cmd *cobra.Command, dryRun *bool, force *bool, backupDir *string) {
+	cmd.Flags().BoolVar(dryRun, "dry-run", true, "Show what would be changed without making changes")
+	cmd.Flags().BoolVarP(force, "force", "f", false, "Skip confirmation prompts")
+	AddBackupDirFlag(cmd, backupDir)
+}
+
+// AddRestoreFlags adds flags for commands that restore roles from a backup
+func AddRestoreFlags(cmd *cobra.Command, dryRun *bool, force *bool) {
+	cmd.Flags().BoolVar(dryRun, "dry-run", true, "Show what would be restored without making changes")
+	cmd.Flags().BoolVarP(force, "force", "f", false, "Skip confirmation prompts")
+}
+
+// AddBackupDirFlag adds the flag for the directory backups are written to
+func AddBackupDirFlag(cmd *cobra.Command, backupDir *string) {
+	cmd.Flags().StringVar(backupDir, "backup-dir", backup.DefaultDir, "Directory to write backups to before making changes")
+}
+
+// forEachRole calls fn for each role in parallel and returns the errors by role name
+func forEachRole(roleNames []string, fn func(roleName string) error) map[string]error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	failures := make(map[string]error)
+	semaphore := make(chan struct{}, maxRoleConcurrency)
+
+	for _, name := range roleNames {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(roleName string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			if err := fn(roleName); err != nil {
+				mu.Lock()
+				failures[roleName] = err
+				mu.Unlock()
+			}
+		}(name)
+	}
+
+	wg.Wait()
+	return failures
+}
@@ -0,0 +1,254 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/diff"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/policy"
+)
+
+// CopyRoleOptions contains options for the copy-role command
+type CopyRoleOptions struct {
+	Inventory string
+	ToProfile string
+	ToRegion  string
+
+	// AccountMap rewrites account IDs, as source=destination pairs. The
+	// role's own account maps to the destination account unless given.
+	AccountMap []string
+
+	DryRun bool
+	Force  bool
+}
+
+// CopyRoleCommand represents the copy-role command
+type CopyRoleCommand struct {
+	profile  string
+	region   string
+	roleName string
+	options  CopyRoleOptions
+}
+
+// NewCopyRoleCommand creates a new copy-role command
+func NewCopyRoleCommand(profile, region, roleName string, options CopyRoleOptions) *CopyRoleCommand {
+	return &CopyRoleCommand{
+		profile:  profile,
+		region:   region,
+		roleName: roleName,
+		options:  options,
+	}
+}
+
+// Execute runs the copy-role command
+func (c *CopyRoleCommand) Execute(ctx context.Context) error {
+	if c.options.ToProfile == "" {
+		return errors.NewValidationError("--to-profile is required")
+	}
+	mapping, err := parseAccountMap(c.options.AccountMap)
+	if err != nil {
+		return err
+	}
+
+	inventory, err := loadInventory(ctx, c.profile, c.region, c.options.Inventory)
+	if err != nil {
+		return err
+	}
+	role := inventory.FindRole(c.roleName)
+	if role == nil {
+		return errors.Errorf("role '%s' not found", c.roleName)
+	}
+
+	toRegion := c.options.ToRegion
+	if toRegion == "" {
+		toRegion = c.region
+	}
+	client, err := aws.NewAWSClient(ctx, c.options.ToProfile, toRegion)
+	if err != nil {
+		return errors.Wrap(err, "failed to create AWS client for the destination")
+	}
+	account, err := client.GetAccountID(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the destination account")
+	}
+	if source := policy.AccountFromARN(role.Arn); source != "" && mapping[source] == "" {
+		mapping[source] = account
+	}
+
+	plan := audit.PlanRoleCopy(inventory, *role, mapping)
+	if role.PermissionsBoundary != "" && plan.Role.PermissionsBoundary == "" {
+		printUnmapped(plan.Unmapped)
+		return errors.Errorf("cannot copy role '%s' without its permissions boundary %s", c.roleName, role.PermissionsBoundary)
+	}
+
+	// Reuse policies the destination already has, unless they differ
+	var create []audit.PolicyCopy
+	for _, managed := range plan.Policies {
+		existing, err := client.GetPolicy(ctx, managed.Arn)
+		if err != nil {
+			return errors.Wrap(err, "failed to check policies in the destination")
+		}
+		switch {
+		case existing == nil:
+			create = append(create, managed)
+		case !audit.SameDocument(existing.Document, managed.Document):
+			if managed.Arn == plan.Role.PermissionsBoundary {
+				return errors.Errorf("permissions boundary %s exists in the destination with a different document", managed.Arn)
+			}
+			plan.Unmapped = append(plan.Unmapped, fmt.Sprintf("policy %s exists in the destination with a different document and was not attached", managed.Arn))
+			plan.Role.AttachedPolicies = withoutPolicy(plan.Role.AttachedPolicies, managed.Arn)
+		}
+	}
+
+	c.printPlan(role, plan, create, account)
+
+	if c.options.DryRun {
+		fmt.Println("\nDRY RUN: No changes were made")
+		return nil
+	}
+
+	// Confirm the copy if force flag is not set
+	if !c.options.Force {
+		prompt := fmt.Sprintf("\nAre you sure you want to create role '%s' in account %s? [y/N]: ", c.roleName, account)
+		confirmed, err := ConfirmAction(prompt)
+		if err != nil {
+			return errors.Wrap(err, "failed to read confirmation")
+		}
+
+		if !confirmed {
+			fmt.Println("Copy cancelled")
+			return nil
+		}
+	}
+
+	for _, managed := range create {
+		if _, err := client.CreatePolicy(ctx, managed.Name, managed.Path, managed.Document); err != nil {
+			return errors.Wrap(err, "failed to create policy")
+		}
+		fmt.Printf("Created policy %s\n", managed.Arn)
+	}
+
+	if err := client.CreateRole(ctx, plan.Role); err != nil {
+		return errors.Wrap(err, "failed to create role")
+	}
+	fmt.Printf("Created role %s\n", plan.Role.Name)
+
+	// The role exists from here on, so report how far the copy got
+	fail := func(err error, message string) error {
+		return errors.Wrap(err, fmt.Sprintf("%s; role '%s' was created but is incomplete", message, c.roleName))
+	}
+	for _, inline := range plan.Role.InlinePolicies {
+		if err := client.PutRolePolicy(ctx, plan.Role.Name, inline.Name, inline.Document); err != nil {
+			return fail(err, "failed to put inline policy")
+		}
+	}
+	for _, attached := range plan.Role.AttachedPolicies {
+		if err := client.AttachRolePolicy(ctx, plan.Role.Name, attached.Arn); err != nil {
+			return fail(err, "failed to attach policy")
+		}
+	}
+	for _, profile := range plan.Role.InstanceProfiles {
+		if err := client.CreateInstanceProfile(ctx, profile.Name, profile.Path); err != nil {
+			return fail(err, "failed to create instance profile")
+		}
+		if err := client.AddRoleToInstanceProfile(ctx, profile.Name, plan.Role.Name); err != nil {
+			return fail(err, "failed to add role to instance profile")
+		}
+	}
+
+	fmt.Printf("\nSuccessfully copied role %s to account %s\n", c.roleName, account)
+	return nil
+}
+
+// printPlan shows what the copy creates and what could not be mapped
+func (c *CopyRoleCommand) printPlan(role *aws.Role, plan *audit.RoleCopy, create []audit.PolicyCopy, account string) {
+	fmt.Printf("Copying role %s to account %s as %s\n", role.Name, account, plan.Role.Arn)
+
+	if changes := diff.Unified(role.Name+" (source)", role.Name+" (copy)", role.TrustPolicy, plan.Role.TrustPolicy); changes != "" {
+		fmt.Println("\nTrust policy:")
+		fmt.Print(changes)
+	}
+
+	creating := make(map[string]bool, len(create))
+	for _, managed := range create {
+		creating[managed.Arn] = true
+		fmt.Printf("  Create policy %s\n", managed.Arn)
+	}
+	for _, managed := range plan.Policies {
+		if !creating[managed.Arn] && managed.Arn != plan.Role.PermissionsBoundary && containsPolicy(plan.Role.AttachedPolicies, managed.Arn) {
+			fmt.Printf("  Reuse policy %s\n", managed.Arn)
+		}
+	}
+	if plan.Role.PermissionsBoundary != "" {
+		fmt.Printf("  Set permissions boundary %s\n", plan.Role.PermissionsBoundary)
+	}
+	for _, attached := range plan.Role.AttachedPolicies {
+		fmt.Printf("  Attach policy %s\n", attached.Arn)
+	}
+	for _, inline := range plan.Role.InlinePolicies {
+		fmt.Printf("  Put inline policy %s\n", inline.Name)
+	}
+	keys := make([]string, 0, len(plan.Role.Tags))
+	for key := range plan.Role.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("  Tag %s=%s\n", key, plan.Role.Tags[key])
+	}
+	for _, profile := range plan.Role.InstanceProfiles {
+		fmt.Printf("  Create instance profile %s\n", profile.Name)
+	}
+
+	printUnmapped(plan.Unmapped)
+}
+
+// printUnmapped lists what a copy could not carry over
+func printUnmapped(unmapped []string) {
+	if len(unmapped) == 0 {
+		return
+	}
+	fmt.Printf("\nCould not map %d items:\n", len(unmapped))
+	for _, item := range unmapped {
+		fmt.Printf("  - %s\n", item)
+	}
+}
+
+// parseAccountMap parses source=destination account ID pairs
+func parseAccountMap(pairs []string) (map[string]string, error) {
+	mapping := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		source, target, ok := strings.Cut(pair, "=")
+		if !ok || !policy.IsAccountID(source) || !policy.IsAccountID(target) {
+			return nil, errors.NewValidationError(fmt.Sprintf("invalid account mapping %q, use source=destination account IDs", pair))
+		}
+		mapping[source] = target
+	}
+	return mapping, nil
+}
+
+// containsPolicy reports whether the policies include the ARN
+func containsPolicy(policies []aws.Policy, arn string) bool {
+	for _, p := range policies {
+		if p.Arn == arn {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutPolicy returns the policies without the ARN
+func withoutPolicy(policies []aws.Policy, arn string) []aws.Policy {
+	kept := make([]aws.Policy, 0, len(policies))
+	for _, p := range policies {
+		if p.Arn != arn {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
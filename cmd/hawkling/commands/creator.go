@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/errors"
+)
+
+// CreatorOptions selects where to look up who created roles
+type CreatorOptions struct {
+	// WithCreator looks up the CreateRole event of each role
+	WithCreator bool
+
+	// CloudTrailDir reads CloudTrail log files instead of the event
+	// history, and implies WithCreator
+	CloudTrailDir string
+}
+
+// readCreators sets the creator of each role from its CreateRole event,
+// read from the CloudTrail log directory if given or else from the last
+// 90 days of event history
+func readCreators(ctx context.Context, profile string, options CreatorOptions, roles []aws.Role) error {
+	if !options.WithCreator && options.CloudTrailDir == "" {
+		return nil
+	}
+
+	if options.CloudTrailDir != "" {
+		events, err := aws.LoadCreateRoleEvents(options.CloudTrailDir)
+		if err != nil {
+			return errors.Wrap(err, "failed to read CloudTrail logs")
+		}
+		aws.AttachCreators(roles, events, time.Time{})
+		return nil
+	}
+
+	client, err := aws.NewTrailClient(ctx, profile)
+	if err != nil {
+		return errors.Wrap(err, "failed to create CloudTrail client")
+	}
+
+	since := time.Now().AddDate(0, 0, -aws.EventHistoryDays)
+	events, err := client.LookupCreateRoleEvents(ctx, since)
+	if err != nil {
+		return errors.Wrap(err, "failed to look up who created roles")
+	}
+	aws.AttachCreators(roles, events, since)
+	return nil
+}
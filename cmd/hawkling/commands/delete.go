@@ -3,6 +3,7 @@ package commands
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"hawkling/pkg/aws"
 	"hawkling/pkg/errors"
@@ -12,6 +13,13 @@ import (
 type DeleteOptions struct {
 	DryRun bool
 	Force  bool
+
+	// IgnoreReferences deletes roles that resources still use
+	IgnoreReferences bool
+
+	// ScanRegions limits the regions checked for resources that use the
+	// roles, instead of every region enabled in the account
+	ScanRegions []string
 }
 
 // DeleteCommand represents the delete command
@@ -57,6 +65,32 @@ func (c *DeleteCommand) Execute(ctx context.Context) error {
 		return errors.Errorf("role '%s' not found", c.roleName)
 	}
 
+	// Warn before breaking assume-role chains through the role
+	inventory, err := client.GetInventory(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not check assume-role chains: %v\n", err)
+	} else {
+		warnChainedRoles(inventory, []aws.Role{*targetRole})
+	}
+
+	// Refuse to delete a role that resources still use
+	references, err := scanReferences(ctx, c.profile, c.region, []aws.Role{*targetRole}, c.options.IgnoreReferences, c.options.ScanRegions)
+	if err != nil {
+		return err
+	}
+	if used := references[targetRole.Arn]; len(used) > 0 {
+		printReferences(c.roleName, used)
+		if !c.options.IgnoreReferences {
+			return errors.Errorf("role '%s' is still in use, use --ignore-references to delete it anyway", c.roleName)
+		}
+	}
+
+	// Show the resource policies whose grants the deletion orphans
+	grants := scanResourcePolicies(ctx, c.profile, c.region, []aws.Role{*targetRole}, c.options.ScanRegions)[targetRole.Arn]
+	if len(grants) > 0 {
+		printResourcePolicies(c.roleName, grants)
+	}
+
 	// If dry run, just show what would be deleted
 	if c.options.DryRun {
 		fmt.Printf("DRY RUN: Would delete IAM role: %s\n", c.roleName)
@@ -66,6 +100,9 @@ func (c *DeleteCommand) Execute(ctx context.Context) error {
 	// Confirm deletion if force flag is not set
 	if !c.options.Force {
 		prompt := fmt.Sprintf("Are you sure you want to delete role '%s'? This cannot be undone. [y/N]: ", c.roleName)
+		if len(grants) > 0 {
+			prompt = fmt.Sprintf("Are you sure you want to delete role '%s'? This cannot be undone and orphans %d resource policy grants. [y/N]: ", c.roleName, len(grants))
+		}
 		confirmed, err := ConfirmAction(prompt)
 		if err != nil {
 			return errors.Wrap(err, "failed to read confirmation")
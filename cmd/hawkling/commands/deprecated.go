@@ -0,0 +1,313 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/backup"
+	"hawkling/pkg/catalog"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/formatter"
+)
+
+// DeprecatedPoliciesOptions contains options for the deprecated-policies command
+type DeprecatedPoliciesOptions struct {
+	FilterOptions
+	Catalog   string
+	Inventory string
+	Output    string
+	Replace   bool
+	DryRun    bool
+	Force     bool
+	BackupDir string
+}
+
+// PolicyReplacementBackup records the deprecated policy attachments a
+// replacement changed, so the change can be rolled back
+type PolicyReplacementBackup struct {
+	Replacements []PolicyReplacement
+}
+
+// PolicyReplacement is a deprecated policy replaced on a role
+type PolicyReplacement struct {
+	RoleName    string
+	PolicyArn   string
+	Replacement string
+	// AttachedReplacement is set when the replacement was attached by
+	// hawkling rather than already attached to the role
+	AttachedReplacement bool
+}
+
+// DeprecatedPoliciesCommand represents the deprecated-policies command
+type DeprecatedPoliciesCommand struct {
+	profile string
+	region  string
+	options DeprecatedPoliciesOptions
+}
+
+// NewDeprecatedPoliciesCommand creates a new deprecated-policies command
+func NewDeprecatedPoliciesCommand(profile, region string, options DeprecatedPoliciesOptions) *DeprecatedPoliciesCommand {
+	return &DeprecatedPoliciesCommand{
+		profile: profile,
+		region:  region,
+		options: options,
+	}
+}
+
+// Execute runs the deprecated-policies command
+func (c *DeprecatedPoliciesCommand) Execute(ctx context.Context) error {
+	if err := c.options.FilterOptions.validate(); err != nil {
+		return err
+	}
+	if c.options.Replace && c.options.Inventory != "" {
+		return errors.NewValidationError("--replace cannot be used with --inventory")
+	}
+
+	deprecated, err := catalog.Load(c.options.Catalog)
+	if err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+
+	inventory, err := loadInventory(ctx, c.profile, c.region, c.options.Inventory)
+	if err != nil {
+		return err
+	}
+
+	// Days only marks roles as unused; it must not hide any attachment
+	filter := c.options.FilterOptions.toAWS()
+	filter.Days = 0
+	roles := aws.FilterRoles(inventory.Roles, filter)
+	attachments := audit.FindDeprecatedPolicies(roles, deprecated, c.options.Days)
+
+	if !c.options.Replace {
+		format := formatter.Format(strings.ToLower(c.options.Output))
+		if err := formatter.FormatDeprecatedAttachments(attachments, format); err != nil {
+			return errors.Wrap(err, "failed to format output")
+		}
+		return nil
+	}
+
+	return c.replace(ctx, roles, attachments)
+}
+
+// replace attaches the successor of each deprecated policy and then detaches
+// the deprecated policy, so the role keeps its permissions throughout
+func (c *DeprecatedPoliciesCommand) replace(ctx context.Context, roles []aws.Role, attachments []audit.DeprecatedAttachment) error {
+	replaceable := make([]audit.DeprecatedAttachment, 0, len(attachments))
+	for _, attachment := range attachments {
+		if attachment.Replacement == "" {
+			fmt.Fprintf(os.Stderr, "Warning: Skipping %s on role %s: no replacement (%s)\n", attachment.PolicyName, attachment.RoleName, attachment.Note)
+			continue
+		}
+		replaceable = append(replaceable, attachment)
+	}
+
+	if len(replaceable) == 0 {
+		fmt.Println("No deprecated policies to replace")
+		return nil
+	}
+
+	fmt.Printf("Found %d deprecated policy attachments to replace:\n", len(replaceable))
+	for i, attachment := range replaceable {
+		fmt.Printf("%d. %s: %s -> %s\n", i+1, attachment.RoleName, attachment.PolicyArn, attachment.Replacement)
+	}
+
+	// If dry run, stop here
+	if c.options.DryRun {
+		fmt.Println("\nDRY RUN: No policies were replaced")
+		return nil
+	}
+
+	// Confirm the replacement if force flag is not set
+	if !c.options.Force {
+		prompt := fmt.Sprintf("\nAre you sure you want to replace %d policy attachments? [y/N]: ", len(replaceable))
+		confirmed, err := ConfirmAction(prompt)
+		if err != nil {
+			return errors.Wrap(err, "failed to read confirmation")
+		}
+
+		if !confirmed {
+			fmt.Println("Replacement cancelled")
+			return nil
+		}
+	}
+
+	client, err := aws.NewAWSClient(ctx, c.profile, c.region)
+	if err != nil {
+		return errors.Wrap(err, "failed to create AWS client")
+	}
+
+	attached := make(map[string]bool)
+	for _, role := range roles {
+		for _, policy := range role.AttachedPolicies {
+			attached[role.Name+"|"+policy.Arn] = true
+		}
+	}
+
+	// Back up the attachments before changing them
+	var record PolicyReplacementBackup
+	planned := make(map[string]bool)
+	for _, attachment := range replaceable {
+		key := attachment.RoleName + "|" + attachment.Replacement
+		record.Replacements = append(record.Replacements, PolicyReplacement{
+			RoleName:            attachment.RoleName,
+			PolicyArn:           attachment.PolicyArn,
+			Replacement:         attachment.Replacement,
+			AttachedReplacement: !attached[key] && !planned[key],
+		})
+		planned[key] = true
+	}
+	backupPath, err := backup.SaveJSON(c.options.BackupDir, "deprecated-policy", "replacement", record)
+	if err != nil {
+		return errors.Wrap(err, "failed to back up policy attachments")
+	}
+	fmt.Printf("Backed up the policy attachments to %s\n", backupPath)
+
+	failed := 0
+	for _, attachment := range replaceable {
+		key := attachment.RoleName + "|" + attachment.Replacement
+		if !attached[key] {
+			if err := client.AttachRolePolicy(ctx, attachment.RoleName, attachment.Replacement); err != nil {
+				failed++
+				fmt.Printf("Failed to replace %s on role %s: %v\n", attachment.PolicyName, attachment.RoleName, err)
+				continue
+			}
+			attached[key] = true
+		}
+
+		if err := client.DetachRolePolicy(ctx, attachment.RoleName, attachment.PolicyArn); err != nil {
+			failed++
+			fmt.Printf("Attached %s to role %s but failed to detach %s: %v\n", attachment.Replacement, attachment.RoleName, attachment.PolicyName, err)
+			continue
+		}
+
+		fmt.Printf("Replaced %s on role %s\n", attachment.PolicyName, attachment.RoleName)
+	}
+
+	if failed > 0 {
+		return errors.Errorf("failed to replace %d policy attachments", failed)
+	}
+
+	fmt.Printf("\nSuccessfully replaced %d policy attachments\n", len(replaceable))
+	fmt.Printf("Roll back with: hawkling deprecated-policies restore %s\n", backupPath)
+	return nil
+}
+
+// DeprecatedRestoreCommand represents the deprecated-policies restore command
+type DeprecatedRestoreCommand struct {
+	profile     string
+	region      string
+	backupPaths []string
+	options     DeleteOptions
+}
+
+// NewDeprecatedRestoreCommand creates a new deprecated-policies restore command
+func NewDeprecatedRestoreCommand(profile, region string, backupPaths []string, options DeleteOptions) *DeprecatedRestoreCommand {
+	return &DeprecatedRestoreCommand{
+		profile:     profile,
+		region:      region,
+		backupPaths: backupPaths,
+		options:     options,
+	}
+}
+
+// Execute runs the deprecated-policies restore command. It attaches the
+// deprecated policies again, then detaches the replacements hawkling
+// attached.
+func (c *DeprecatedRestoreCommand) Execute(ctx context.Context) error {
+	var replacements []PolicyReplacement
+	for _, path := range c.backupPaths {
+		var record PolicyReplacementBackup
+		if err := backup.LoadJSON(path, &record); err != nil {
+			return err
+		}
+		if len(record.Replacements) == 0 {
+			return errors.NewValidationError(fmt.Sprintf("%s is not a deprecated policy replacement backup", path))
+		}
+		replacements = append(replacements, record.Replacements...)
+	}
+
+	fmt.Printf("Restoring %d deprecated policy attachments:\n", len(replacements))
+	for i, replacement := range replacements {
+		fmt.Printf("%d. %s: %s -> %s\n", i+1, replacement.RoleName, replacement.Replacement, replacement.PolicyArn)
+	}
+
+	// If dry run, stop here
+	if c.options.DryRun {
+		fmt.Println("\nDRY RUN: No policies were restored")
+		return nil
+	}
+
+	// Confirm the restore if force flag is not set
+	if !c.options.Force {
+		prompt := fmt.Sprintf("\nAre you sure you want to restore %d policy attachments? [y/N]: ", len(replacements))
+		confirmed, err := ConfirmAction(prompt)
+		if err != nil {
+			return errors.Wrap(err, "failed to read confirmation")
+		}
+
+		if !confirmed {
+			fmt.Println("Restore cancelled")
+			return nil
+		}
+	}
+
+	client, err := aws.NewAWSClient(ctx, c.profile, c.region)
+	if err != nil {
+		return errors.Wrap(err, "failed to create AWS client")
+	}
+
+	failed := 0
+	for _, replacement := range replacements {
+		// Attach the deprecated policy first so the role keeps its permissions
+		if err := client.AttachRolePolicy(ctx, replacement.RoleName, replacement.PolicyArn); err != nil {
+			failed++
+			fmt.Printf("Failed to attach %s to role %s: %v\n", replacement.PolicyArn, replacement.RoleName, err)
+			continue
+		}
+
+		if replacement.AttachedReplacement {
+			if err := client.DetachRolePolicy(ctx, replacement.RoleName, replacement.Replacement); err != nil {
+				failed++
+				fmt.Printf("Attached %s to role %s but failed to detach %s: %v\n", replacement.PolicyArn, replacement.RoleName, replacement.Replacement, err)
+				continue
+			}
+		}
+
+		fmt.Printf("Restored %s on role %s\n", replacement.PolicyArn, replacement.RoleName)
+	}
+
+	if failed > 0 {
+		return errors.Errorf("failed to restore %d policy attachments", failed)
+	}
+
+	fmt.Printf("\nSuccessfully restored %d policy attachments\n", len(replacements))
+	return nil
+}
+
+// DeprecatedCatalogCommand represents the deprecated-policies catalog command
+type DeprecatedCatalogCommand struct {
+	catalogPath string
+}
+
+// NewDeprecatedCatalogCommand creates a new deprecated-policies catalog command
+func NewDeprecatedCatalogCommand(catalogPath string) *DeprecatedCatalogCommand {
+	return &DeprecatedCatalogCommand{catalogPath: catalogPath}
+}
+
+// Execute prints the catalog as JSON, to be saved and edited as a local catalog
+func (c *DeprecatedCatalogCommand) Execute(ctx context.Context) error {
+	deprecated, err := catalog.Load(c.catalogPath)
+	if err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(deprecated)
+}
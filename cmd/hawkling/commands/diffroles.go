@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"context"
+	"strings"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/formatter"
+)
+
+// DiffRolesOptions contains options for the diff-roles command. The second
+// role is read with the Other settings, which default to those of the
+// first role, so roles in different accounts can be compared.
+type DiffRolesOptions struct {
+	Inventory      string
+	OtherProfile   string
+	OtherRegion    string
+	OtherInventory string
+	Output         string
+}
+
+// DiffRolesCommand represents the diff-roles command
+type DiffRolesCommand struct {
+	profile string
+	region  string
+	from    string
+	to      string
+	options DiffRolesOptions
+}
+
+// NewDiffRolesCommand creates a new diff-roles command comparing the roles
+// from and to, given by name or ARN
+func NewDiffRolesCommand(profile, region, from, to string, options DiffRolesOptions) *DiffRolesCommand {
+	return &DiffRolesCommand{
+		profile: profile,
+		region:  region,
+		from:    from,
+		to:      to,
+		options: options,
+	}
+}
+
+// Execute runs the diff-roles command
+func (c *DiffRolesCommand) Execute(ctx context.Context) error {
+	inventory, err := loadInventory(ctx, c.profile, c.region, c.options.Inventory)
+	if err != nil {
+		return err
+	}
+
+	otherInventory := inventory
+	if c.options.OtherProfile != "" || c.options.OtherRegion != "" || c.options.OtherInventory != "" {
+		otherProfile, otherRegion := c.options.OtherProfile, c.options.OtherRegion
+		if otherProfile == "" {
+			otherProfile = c.profile
+		}
+		if otherRegion == "" {
+			otherRegion = c.region
+		}
+		otherInventory, err = loadInventory(ctx, otherProfile, otherRegion, c.options.OtherInventory)
+		if err != nil {
+			return err
+		}
+	}
+
+	from := inventory.FindRole(c.from)
+	if from == nil {
+		return errors.Errorf("role '%s' not found", c.from)
+	}
+	to := otherInventory.FindRole(c.to)
+	if to == nil {
+		return errors.Errorf("role '%s' not found", c.to)
+	}
+
+	roleDiff, err := audit.DiffRoles(c.label(from.Arn, c.from), c.label(to.Arn, c.to), *from, *to)
+	if err != nil {
+		return errors.Wrap(err, "failed to compare roles")
+	}
+
+	format := formatter.Format(strings.ToLower(c.options.Output))
+	if err := formatter.FormatRoleDiff(roleDiff, format); err != nil {
+		return errors.Wrap(err, "failed to format output")
+	}
+
+	return nil
+}
+
+// label names a role in the diff: by ARN when comparing across accounts,
+// so that two roles with the same name can be told apart
+func (c *DiffRolesCommand) label(arn, name string) string {
+	if arn != "" && (c.options.OtherProfile != "" || c.options.OtherInventory != "") {
+		return arn
+	}
+	return name
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/formatter"
+)
+
+// DuplicatesOptions contains options for the duplicates command. Days only
+// marks roles as unused, it does not select them.
+type DuplicatesOptions struct {
+	FilterOptions
+	Inventory string
+	Output    string
+}
+
+// DuplicatesCommand represents the duplicates command
+type DuplicatesCommand struct {
+	profile string
+	region  string
+	options DuplicatesOptions
+}
+
+// NewDuplicatesCommand creates a new duplicates command
+func NewDuplicatesCommand(profile, region string, options DuplicatesOptions) *DuplicatesCommand {
+	return &DuplicatesCommand{
+		profile: profile,
+		region:  region,
+		options: options,
+	}
+}
+
+// Execute runs the duplicates command
+func (c *DuplicatesCommand) Execute(ctx context.Context) error {
+	if err := c.options.validate(); err != nil {
+		return err
+	}
+
+	inventory, err := loadInventory(ctx, c.profile, c.region, c.options.Inventory)
+	if err != nil {
+		return err
+	}
+
+	selection := c.options.FilterOptions
+	selection.Days = 0
+	roles := aws.FilterRoles(inventory.Roles, selection.toAWS())
+
+	groups, skipped := audit.FindDuplicates(roles, c.options.Days)
+
+	for _, role := range skipped {
+		fmt.Fprintf(os.Stderr, "Warning: Skipping role %s: %s\n", role.RoleName, role.Reason)
+	}
+
+	format := formatter.Format(strings.ToLower(c.options.Output))
+	if err := formatter.FormatDuplicateGroups(groups, format); err != nil {
+		return errors.Wrap(err, "failed to format output")
+	}
+
+	return nil
+}
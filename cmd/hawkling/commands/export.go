@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/formatter"
+)
+
+// ExportOptions contains options for the export command
+type ExportOptions struct {
+	FilterOptions
+	Inventory string
+	Format    string
+
+	// Unmanaged exports only roles that neither a CloudFormation stack nor,
+	// with TerraformState, Terraform state declares
+	Unmanaged      bool
+	TerraformState []string
+}
+
+// ExportCommand represents the export command
+type ExportCommand struct {
+	profile string
+	region  string
+	options ExportOptions
+}
+
+// NewExportCommand creates a new export command
+func NewExportCommand(profile, region string, options ExportOptions) *ExportCommand {
+	return &ExportCommand{
+		profile: profile,
+		region:  region,
+		options: options,
+	}
+}
+
+// Execute runs the export command
+func (c *ExportCommand) Execute(ctx context.Context) error {
+	if err := c.options.validate(); err != nil {
+		return err
+	}
+
+	format := formatter.Format(strings.ToLower(c.options.Format))
+	if format != formatter.TerraformFormat && format != formatter.CloudFormationFormat {
+		return errors.NewValidationError(fmt.Sprintf("unsupported export format %q, use terraform or cloudformation", c.options.Format))
+	}
+
+	inventory, err := loadInventory(ctx, c.profile, c.region, c.options.Inventory)
+	if err != nil {
+		return err
+	}
+
+	if err := readTerraformState(c.options.TerraformState, inventory.Roles); err != nil {
+		return err
+	}
+
+	roles := aws.FilterRoles(inventory.Roles, c.options.toAWS())
+	if c.options.Unmanaged {
+		unmanaged := make([]aws.Role, 0, len(roles))
+		for _, role := range roles {
+			if role.Tags[aws.StackNameTag] == "" && role.TerraformAddress == "" {
+				unmanaged = append(unmanaged, role)
+			}
+		}
+		roles = unmanaged
+	}
+
+	if len(roles) == 0 {
+		fmt.Fprintln(os.Stderr, "No IAM roles found matching criteria")
+		return nil
+	}
+
+	if err := formatter.FormatRoleExport(roles, format); err != nil {
+		return errors.Wrap(err, "failed to format output")
+	}
+
+	return nil
+}
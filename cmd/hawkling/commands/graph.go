@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/formatter"
+)
+
+// GraphOptions contains options for the graph command
+type GraphOptions struct {
+	Days      int
+	Inventory string
+	Output    string
+}
+
+// GraphCommand represents the graph command
+type GraphCommand struct {
+	profile string
+	region  string
+	options GraphOptions
+}
+
+// NewGraphCommand creates a new graph command
+func NewGraphCommand(profile, region string, options GraphOptions) *GraphCommand {
+	return &GraphCommand{
+		profile: profile,
+		region:  region,
+		options: options,
+	}
+}
+
+// Execute runs the graph command
+func (c *GraphCommand) Execute(ctx context.Context) error {
+	inventory, err := loadInventory(ctx, c.profile, c.region, c.options.Inventory)
+	if err != nil {
+		return err
+	}
+
+	graph, skipped := audit.BuildRoleGraph(inventory, c.options.Days)
+
+	for _, role := range skipped {
+		fmt.Fprintf(os.Stderr, "Warning: Skipping role %s: %s\n", role.RoleName, role.Reason)
+	}
+
+	format := formatter.Format(strings.ToLower(c.options.Output))
+	if err := formatter.FormatRoleGraph(graph, format); err != nil {
+		return errors.Wrap(err, "failed to format output")
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/errors"
+)
+
+// InventoryOptions contains options for the inventory command
+type InventoryOptions struct {
+	File string
+}
+
+// InventoryCommand represents the inventory command
+type InventoryCommand struct {
+	profile string
+	region  string
+	options InventoryOptions
+}
+
+// NewInventoryCommand creates a new inventory command
+func NewInventoryCommand(profile, region string, options InventoryOptions) *InventoryCommand {
+	return &InventoryCommand{
+		profile: profile,
+		region:  region,
+		options: options,
+	}
+}
+
+// Execute runs the inventory command
+func (c *InventoryCommand) Execute(ctx context.Context) error {
+	if c.options.File == "" {
+		return errors.NewValidationError("an output file is required")
+	}
+
+	inventory, err := loadInventory(ctx, c.profile, c.region, "")
+	if err != nil {
+		return err
+	}
+
+	if err := aws.SaveInventory(c.options.File, inventory); err != nil {
+		return errors.Wrap(err, "failed to save inventory")
+	}
+
+	fmt.Printf("Saved %d IAM roles and %d managed policies to %s\n", len(inventory.Roles), len(inventory.Policies), c.options.File)
+	return nil
+}
+
+// loadInventory reads a saved inventory from path, or fetches it from AWS
+// when path is empty
+func loadInventory(ctx context.Context, profile, region, path string) (*aws.Inventory, error) {
+	if path != "" {
+		inventory, err := aws.LoadInventory(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load inventory")
+		}
+		return inventory, nil
+	}
+
+	client, err := aws.NewAWSClient(ctx, profile, region)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create AWS client")
+	}
+
+	inventory, err := client.GetInventory(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get inventory")
+	}
+	return inventory, nil
+}
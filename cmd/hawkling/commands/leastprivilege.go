@@ -0,0 +1,353 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/backup"
+	"hawkling/pkg/diff"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/formatter"
+	"hawkling/pkg/policy"
+)
+
+// File formats for generated policies
+const (
+	PolicyFileJSON      = "json"
+	PolicyFileTerraform = "terraform"
+)
+
+// DefaultLeastPrivilegePolicyName names the generated inline policy
+const DefaultLeastPrivilegePolicyName = "hawkling-least-privilege"
+
+// maxInlinePolicySize is the largest total size of the inline policies of a
+// role, in characters excluding whitespace
+const maxInlinePolicySize = 10240
+
+// LeastPrivilegeOptions contains options for the least-privilege command
+type LeastPrivilegeOptions struct {
+	Days       int
+	PolicyName string
+	File       string
+	FileFormat string
+	Apply      bool
+	DryRun     bool
+	Force      bool
+	BackupDir  string
+
+	// PollInterval and PollTimeout control how the report job is polled;
+	// zero values use the defaults
+	PollInterval time.Duration
+	PollTimeout  time.Duration
+}
+
+// LeastPrivilegeBackup records the policies of a role before they were
+// replaced by a generated policy, so the change can be rolled back
+type LeastPrivilegeBackup struct {
+	RoleName         string
+	PolicyName       string
+	InlinePolicies   map[string]string
+	AttachedPolicies []string
+}
+
+// LeastPrivilegeCommand represents the least-privilege command
+type LeastPrivilegeCommand struct {
+	profile  string
+	region   string
+	roleName string
+	options  LeastPrivilegeOptions
+}
+
+// NewLeastPrivilegeCommand creates a new least-privilege command
+func NewLeastPrivilegeCommand(profile, region, roleName string, options LeastPrivilegeOptions) *LeastPrivilegeCommand {
+	if options.PolicyName == "" {
+		options.PolicyName = DefaultLeastPrivilegePolicyName
+	}
+	if options.FileFormat == "" {
+		options.FileFormat = policyFileFormat(options.File)
+	}
+	return &LeastPrivilegeCommand{
+		profile:  profile,
+		region:   region,
+		roleName: roleName,
+		options:  options,
+	}
+}
+
+// Execute runs the least-privilege command
+func (c *LeastPrivilegeCommand) Execute(ctx context.Context) error {
+	if c.options.Days <= 0 {
+		return errors.NewValidationError("--days must be greater than zero")
+	}
+	if c.options.FileFormat != PolicyFileJSON && c.options.FileFormat != PolicyFileTerraform {
+		return errors.NewValidationError(fmt.Sprintf("unsupported file format %q, expected json or terraform", c.options.FileFormat))
+	}
+
+	client, err := aws.NewAWSClient(ctx, c.profile, c.region)
+	if err != nil {
+		return errors.Wrap(err, "failed to create AWS client")
+	}
+
+	inventory, err := client.GetInventory(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get inventory")
+	}
+
+	role := inventory.FindRole(c.roleName)
+	if role == nil {
+		return errors.Errorf("role '%s' not found", c.roleName)
+	}
+
+	policySet, err := inventory.PolicySet(*role)
+	if err != nil {
+		return errors.Wrap(err, "failed to read role policies")
+	}
+
+	services, err := serviceLastAccessed(ctx, client, role.Arn, c.options.PollInterval, c.options.PollTimeout)
+	if err != nil {
+		return errors.Wrap(err, "failed to get service last accessed details")
+	}
+
+	generated := audit.GenerateLeastPrivilege(*role, policySet, services, c.options.Days, time.Now())
+	for _, note := range generated.Notes {
+		fmt.Fprintf(os.Stderr, "Note: %s\n", note)
+	}
+
+	current, err := generated.Current.JSON()
+	if err != nil {
+		return err
+	}
+	proposed, err := generated.Proposed.JSON()
+	if err != nil {
+		return err
+	}
+
+	if changes := diff.Unified(role.Name+" (current)", role.Name+" (proposed)", current, proposed); changes != "" {
+		fmt.Print(changes)
+	} else {
+		fmt.Println("No changes proposed: the role uses every service it is granted")
+	}
+
+	if c.options.File != "" {
+		content := proposed
+		if c.options.FileFormat == PolicyFileTerraform {
+			content = formatter.TerraformRolePolicy(role.Name, c.options.PolicyName, proposed)
+		}
+		if err := os.WriteFile(c.options.File, []byte(content), 0o644); err != nil {
+			return errors.Wrap(err, "failed to write policy file")
+		}
+		fmt.Printf("\nWrote proposed policy to %s\n", c.options.File)
+	}
+
+	if !c.options.Apply {
+		return nil
+	}
+
+	return c.apply(ctx, client, *role, generated.Proposed)
+}
+
+// apply puts the proposed policy as an inline policy of the role, then
+// detaches its managed policies and deletes its other inline policies
+func (c *LeastPrivilegeCommand) apply(ctx context.Context, client aws.IAMClient, role aws.Role, proposed *policy.Document) error {
+	document, err := json.Marshal(proposed)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode proposed policy")
+	}
+	if len(document) > maxInlinePolicySize {
+		return errors.Errorf("proposed policy is %d characters, above the %d character inline policy limit", len(document), maxInlinePolicySize)
+	}
+
+	record := LeastPrivilegeBackup{
+		RoleName:       role.Name,
+		PolicyName:     c.options.PolicyName,
+		InlinePolicies: make(map[string]string, len(role.InlinePolicies)),
+	}
+	for _, inline := range role.InlinePolicies {
+		record.InlinePolicies[inline.Name] = inline.Document
+	}
+	for _, attached := range role.AttachedPolicies {
+		record.AttachedPolicies = append(record.AttachedPolicies, attached.Arn)
+	}
+
+	fmt.Printf("\nApplying the proposed policy to role %s:\n", role.Name)
+	fmt.Printf("- put inline policy %s\n", c.options.PolicyName)
+	for _, arn := range record.AttachedPolicies {
+		fmt.Printf("- detach managed policy %s\n", arn)
+	}
+	for _, inline := range role.InlinePolicies {
+		if inline.Name != c.options.PolicyName {
+			fmt.Printf("- delete inline policy %s\n", inline.Name)
+		}
+	}
+
+	// If dry run, stop here
+	if c.options.DryRun {
+		fmt.Println("\nDRY RUN: No policies were changed")
+		return nil
+	}
+
+	// Confirm the change if force flag is not set
+	if !c.options.Force {
+		prompt := fmt.Sprintf("\nAre you sure you want to replace the policies of role '%s'? [y/N]: ", role.Name)
+		confirmed, err := ConfirmAction(prompt)
+		if err != nil {
+			return errors.Wrap(err, "failed to read confirmation")
+		}
+
+		if !confirmed {
+			fmt.Println("Update cancelled")
+			return nil
+		}
+	}
+
+	backupPath, err := backup.SaveJSON(c.options.BackupDir, "least-privilege", role.Name, record)
+	if err != nil {
+		return errors.Wrap(err, "failed to back up role policies")
+	}
+	fmt.Printf("Backed up the policies of role %s to %s\n", role.Name, backupPath)
+
+	// Put the new policy first so the role is never left without permissions
+	if err := client.PutRolePolicy(ctx, role.Name, c.options.PolicyName, string(document)); err != nil {
+		return errors.Wrap(err, "failed to put proposed policy")
+	}
+
+	failed := 0
+	for _, arn := range record.AttachedPolicies {
+		if err := client.DetachRolePolicy(ctx, role.Name, arn); err != nil {
+			failed++
+			fmt.Printf("Failed to detach %s: %v\n", arn, err)
+		}
+	}
+	for name := range record.InlinePolicies {
+		if name == c.options.PolicyName {
+			continue
+		}
+		if err := client.DeleteRolePolicy(ctx, role.Name, name); err != nil {
+			failed++
+			fmt.Printf("Failed to delete inline policy %s: %v\n", name, err)
+		}
+	}
+
+	if failed > 0 {
+		return errors.Errorf("failed to remove %d policies of role %s, restore with %s", failed, role.Name, backupPath)
+	}
+
+	fmt.Printf("\nSuccessfully applied the proposed policy to role %s\n", role.Name)
+	return nil
+}
+
+// LeastPrivilegeRestoreCommand represents the least-privilege restore command
+type LeastPrivilegeRestoreCommand struct {
+	profile     string
+	region      string
+	backupPaths []string
+	options     DeleteOptions
+}
+
+// NewLeastPrivilegeRestoreCommand creates a new least-privilege restore command
+func NewLeastPrivilegeRestoreCommand(profile, region string, backupPaths []string, options DeleteOptions) *LeastPrivilegeRestoreCommand {
+	return &LeastPrivilegeRestoreCommand{
+		profile:     profile,
+		region:      region,
+		backupPaths: backupPaths,
+		options:     options,
+	}
+}
+
+// Execute runs the least-privilege restore command
+func (c *LeastPrivilegeRestoreCommand) Execute(ctx context.Context) error {
+	records := make([]LeastPrivilegeBackup, 0, len(c.backupPaths))
+	for _, path := range c.backupPaths {
+		var record LeastPrivilegeBackup
+		if err := backup.LoadJSON(path, &record); err != nil {
+			return err
+		}
+		if record.RoleName == "" || record.PolicyName == "" {
+			return errors.NewValidationError(fmt.Sprintf("%s is not a least-privilege backup", path))
+		}
+		records = append(records, record)
+	}
+
+	fmt.Printf("Restoring the policies of %d IAM roles:\n", len(records))
+	for i, record := range records {
+		fmt.Printf("%d. %s (%d inline, %d managed policies)\n", i+1, record.RoleName, len(record.InlinePolicies), len(record.AttachedPolicies))
+	}
+
+	// If dry run, stop here
+	if c.options.DryRun {
+		fmt.Println("\nDRY RUN: No policies were changed")
+		return nil
+	}
+
+	// Confirm the restore if force flag is not set
+	if !c.options.Force {
+		prompt := fmt.Sprintf("\nAre you sure you want to restore the policies of %d roles? [y/N]: ", len(records))
+		confirmed, err := ConfirmAction(prompt)
+		if err != nil {
+			return errors.Wrap(err, "failed to read confirmation")
+		}
+
+		if !confirmed {
+			fmt.Println("Restore cancelled")
+			return nil
+		}
+	}
+
+	client, err := aws.NewAWSClient(ctx, c.profile, c.region)
+	if err != nil {
+		return errors.Wrap(err, "failed to create AWS client")
+	}
+
+	var failedRoles []string
+	for _, record := range records {
+		if err := restoreLeastPrivilege(ctx, client, record); err != nil {
+			failedRoles = append(failedRoles, record.RoleName)
+			fmt.Printf("Failed to restore policies of role %s: %v\n", record.RoleName, err)
+			continue
+		}
+		fmt.Printf("Restored policies of role: %s\n", record.RoleName)
+	}
+
+	if len(failedRoles) > 0 {
+		return errors.Errorf("failed to restore %d roles", len(failedRoles))
+	}
+
+	fmt.Printf("\nSuccessfully restored the policies of %d IAM roles\n", len(records))
+	return nil
+}
+
+// restoreLeastPrivilege puts back the backed up policies of a role and then
+// removes the generated policy, unless it replaced a policy of the same name
+func restoreLeastPrivilege(ctx context.Context, client aws.IAMClient, record LeastPrivilegeBackup) error {
+	for name, document := range record.InlinePolicies {
+		if err := client.PutRolePolicy(ctx, record.RoleName, name, document); err != nil {
+			return err
+		}
+	}
+	for _, arn := range record.AttachedPolicies {
+		if err := client.AttachRolePolicy(ctx, record.RoleName, arn); err != nil {
+			return err
+		}
+	}
+	if _, ok := record.InlinePolicies[record.PolicyName]; !ok {
+		if err := client.DeleteRolePolicy(ctx, record.RoleName, record.PolicyName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// policyFileFormat picks the file format of a generated policy from the
+// file extension
+func policyFileFormat(path string) string {
+	if strings.HasSuffix(path, ".tf") {
+		return PolicyFileTerraform
+	}
+	return PolicyFileJSON
+}
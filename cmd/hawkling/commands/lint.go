@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"context"
+	"strings"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/formatter"
+	"hawkling/pkg/lint"
+)
+
+// LintOptions contains options for the lint command
+type LintOptions struct {
+	FilterOptions
+	Config    string
+	Inventory string
+	Output    string
+	FailOn    string
+}
+
+// LintCommand represents the lint command
+type LintCommand struct {
+	profile string
+	region  string
+	options LintOptions
+}
+
+// NewLintCommand creates a new lint command
+func NewLintCommand(profile, region string, options LintOptions) *LintCommand {
+	return &LintCommand{
+		profile: profile,
+		region:  region,
+		options: options,
+	}
+}
+
+// Execute runs the lint command. It returns an error when a finding is at
+// least as severe as the FailOn severity.
+func (c *LintCommand) Execute(ctx context.Context) error {
+	if err := c.options.validate(); err != nil {
+		return err
+	}
+
+	var failOn lint.Severity
+	if c.options.FailOn != "" {
+		severity, err := lint.ParseSeverity(c.options.FailOn)
+		if err != nil {
+			return errors.NewValidationError(err.Error())
+		}
+		failOn = severity
+	}
+
+	config := lint.DefaultConfig()
+	if c.options.Config != "" {
+		loaded, err := lint.LoadConfig(c.options.Config)
+		if err != nil {
+			return errors.NewValidationError(err.Error())
+		}
+		config = loaded
+	}
+
+	linter := lint.NewLinter(config)
+	if err := linter.Validate(); err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+
+	inventory, err := loadInventory(ctx, c.profile, c.region, c.options.Inventory)
+	if err != nil {
+		return err
+	}
+
+	roles := aws.FilterRoles(inventory.Roles, c.options.toAWS())
+	findings := linter.Lint(roles)
+
+	format := formatter.Format(strings.ToLower(c.options.Output))
+	if err := formatter.FormatLintFindings(linter, findings, format); err != nil {
+		return errors.Wrap(err, "failed to format output")
+	}
+
+	if failOn != "" {
+		failed := 0
+		for _, finding := range findings {
+			if finding.Severity.AtLeast(failOn) {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return errors.Errorf("%d lint findings at or above severity %s", failed, failOn)
+		}
+	}
+
+	return nil
+}
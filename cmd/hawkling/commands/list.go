@@ -14,6 +14,20 @@ type ListOptions struct {
 	FilterOptions
 	Output  string
 	ShowAll bool
+	Risk    bool
+	SortBy  string
+
+	// Analyzer reads Access Analyzer unused-access findings into the roles
+	Analyzer bool
+
+	// Stacks looks up the CloudFormation stacks that created the roles
+	Stacks bool
+
+	// TerraformState lists state files or directories to mark roles with
+	TerraformState []string
+
+	// Creator looks up who created the roles in CloudTrail
+	Creator CreatorOptions
 }
 
 // ListCommand represents the list command
@@ -44,19 +58,58 @@ func (c *ListCommand) Execute(ctx context.Context) error {
 		return errors.Wrap(err, "failed to list roles")
 	}
 
+	// Mark roles Terraform manages, comparing the state with every role
+	if err := readTerraformState(c.options.TerraformState, roles); err != nil {
+		return err
+	}
+
 	// Filter roles if needed
 	filterOptions := aws.FilterOptions{
 		Days:       c.options.FilterOptions.Days,
 		OnlyUsed:   c.options.FilterOptions.OnlyUsed,
 		OnlyUnused: c.options.FilterOptions.OnlyUnused,
+
+		RequireAnalyzerAgreement: c.options.FilterOptions.RequireAnalyzerAgreement,
+	}
+
+	if err := readUnusedAccess(ctx, c.profile, c.region, roles, c.options.FilterOptions, c.options.Analyzer); err != nil {
+		return err
 	}
 
 	// Use unified filter implementation
 	roles = aws.FilterRoles(roles, filterOptions)
 
+	// Sorting by risk needs the scores
+	showRisk := c.options.Risk || c.options.SortBy == aws.SortByRisk
+	if showRisk {
+		if _, err := scoreRoles(ctx, client, roles); err != nil {
+			return err
+		}
+	}
+
+	if c.options.Stacks {
+		inventory, err := client.GetInventory(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to get inventory")
+		}
+		if err := readStackOwnership(ctx, c.profile, c.region, client, inventory, roles); err != nil {
+			return err
+		}
+	}
+
+	if err := readCreators(ctx, c.profile, c.options.Creator, roles); err != nil {
+		return err
+	}
+
+	if c.options.SortBy != "" {
+		if err := aws.SortRoles(roles, c.options.SortBy); err != nil {
+			return errors.NewValidationError(err.Error())
+		}
+	}
+
 	// Format output
 	format := formatter.Format(strings.ToLower(c.options.Output))
-	if err := formatter.FormatRoles(roles, format, c.options.ShowAll); err != nil {
+	if err := formatter.FormatRoles(roles, format, c.options.ShowAll, showRisk); err != nil {
 		return errors.Wrap(err, "failed to format output")
 	}
 
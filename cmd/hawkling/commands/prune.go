@@ -3,6 +3,7 @@ package commands
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	"hawkling/pkg/aws"
@@ -14,6 +15,28 @@ type PruneOptions struct {
 	FilterOptions
 	DryRun bool
 	Force  bool
+
+	// Analyzer reads Access Analyzer unused-access findings into the roles
+	Analyzer bool
+
+	// IgnoreReferences deletes roles that resources still use
+	IgnoreReferences bool
+
+	// ScanRegions limits the regions checked for resources that use the
+	// roles, instead of every region enabled in the account
+	ScanRegions []string
+
+	// IncludeStackManaged deletes roles that live CloudFormation stacks manage
+	IncludeStackManaged bool
+
+	// TerraformState lists state files or directories whose roles are kept
+	TerraformState []string
+
+	// IncludeTerraformManaged deletes roles declared in Terraform state
+	IncludeTerraformManaged bool
+
+	// Creator looks up who created the roles, so their owners can be told
+	Creator CreatorOptions
 }
 
 // PruneCommand represents the prune command
@@ -45,11 +68,22 @@ func (c *PruneCommand) Execute(ctx context.Context) error {
 		return errors.Wrap(err, "failed to list roles")
 	}
 
+	// Mark roles Terraform manages, comparing the state with every role
+	if err := readTerraformState(c.options.TerraformState, roles); err != nil {
+		return err
+	}
+
 	// Find roles based on the specified options
 	filterOptions := aws.FilterOptions{
 		Days:       c.options.FilterOptions.Days,
 		OnlyUnused: c.options.FilterOptions.OnlyUnused,
 		OnlyUsed:   c.options.FilterOptions.OnlyUsed,
+
+		RequireAnalyzerAgreement: c.options.FilterOptions.RequireAnalyzerAgreement,
+	}
+
+	if err := readUnusedAccess(ctx, c.profile, c.region, roles, c.options.FilterOptions, c.options.Analyzer); err != nil {
+		return err
 	}
 
 	filteredRoles := aws.FilterRoles(roles, filterOptions)
@@ -72,13 +106,135 @@ func (c *PruneCommand) Execute(ctx context.Context) error {
 		message = "Found %d IAM roles (not used in the last %d days)"
 	}
 
+	// Order the plan so the riskiest roles come first
+	scored := true
+	inventory, err := scoreRoles(ctx, client, filteredRoles)
+	if err != nil {
+		scored = false
+		fmt.Fprintf(os.Stderr, "Warning: Roles are not ordered by risk (needs iam:GetAccountAuthorizationDetails): %v\n", err)
+	} else if err := aws.SortRoles(filteredRoles, aws.SortByRisk); err != nil {
+		return err
+	}
+
+	// Roles left behind by deleted stacks are the safest to remove. Without
+	// the inventory, the stack tags are listed for each role instead.
+	if err := readStackOwnership(ctx, c.profile, c.region, client, inventory, filteredRoles); err != nil {
+		if !c.options.IncludeStackManaged {
+			return errors.Errorf("failed to check which roles CloudFormation stacks manage: %v; use --include-stack-managed to continue anyway", err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: Could not check which roles CloudFormation stacks manage: %v\n", err)
+	}
+	orphansFirst(filteredRoles)
+
+	if err := readCreators(ctx, c.profile, c.options.Creator, filteredRoles); err != nil {
+		return err
+	}
+
 	if strings.Contains(message, "%d days") {
 		fmt.Printf(message+":\n", len(filteredRoles), c.options.FilterOptions.Days)
 	} else {
 		fmt.Printf(message+":\n", len(filteredRoles))
 	}
 	for i, role := range filteredRoles {
-		fmt.Printf("%d. %s\n", i+1, role.Name)
+		line := fmt.Sprintf("%d. %s", i+1, role.Name)
+		if scored {
+			line += fmt.Sprintf(" (risk %d: %s)", role.RiskScore, strings.Join(role.RiskFactors, "; "))
+		}
+		if role.Stack != nil {
+			line += fmt.Sprintf(" [%s]", role.Stack)
+		}
+		if role.TerraformAddress != "" {
+			line += fmt.Sprintf(" [managed by Terraform at %s]", role.TerraformAddress)
+		}
+		if role.Creator != nil {
+			line += fmt.Sprintf(" [created by %s]", role.Creator)
+		}
+		fmt.Println(line)
+	}
+
+	if inventory != nil {
+		warnChainedRoles(inventory, filteredRoles)
+	}
+
+	// Keep roles that live stacks manage out of the deletion, since deleting
+	// them out-of-band makes the stack drift and fail its next update
+	if !c.options.IncludeStackManaged {
+		unmanaged := make([]aws.Role, 0, len(filteredRoles))
+		for _, role := range filteredRoles {
+			if !isStackManaged(role) {
+				unmanaged = append(unmanaged, role)
+			}
+		}
+		if skipped := len(filteredRoles) - len(unmanaged); skipped > 0 {
+			fmt.Printf("\nSkipping %d roles managed by CloudFormation stacks, delete them through their stack or use --include-stack-managed\n", skipped)
+			filteredRoles = unmanaged
+		}
+		if len(filteredRoles) == 0 {
+			fmt.Println("No IAM roles left to delete")
+			return nil
+		}
+	}
+
+	// Keep roles that Terraform manages out of the deletion, since the next
+	// apply would recreate them
+	if !c.options.IncludeTerraformManaged {
+		var declared []aws.Role
+		unmanaged := make([]aws.Role, 0, len(filteredRoles))
+		for _, role := range filteredRoles {
+			if role.TerraformAddress != "" {
+				declared = append(declared, role)
+			} else {
+				unmanaged = append(unmanaged, role)
+			}
+		}
+		if len(declared) > 0 {
+			fmt.Printf("\nSkipping %d roles managed by Terraform, remove these resources from the configuration instead or use --include-terraform-managed:\n", len(declared))
+			for _, role := range declared {
+				fmt.Printf("  - %s: %s\n", role.Name, role.TerraformAddress)
+			}
+			filteredRoles = unmanaged
+		}
+		if len(filteredRoles) == 0 {
+			fmt.Println("No IAM roles left to delete")
+			return nil
+		}
+	}
+
+	// Keep roles that resources still use out of the deletion
+	references, err := scanReferences(ctx, c.profile, c.region, filteredRoles, c.options.IgnoreReferences, c.options.ScanRegions)
+	if err != nil {
+		return err
+	}
+	unreferenced := make([]aws.Role, 0, len(filteredRoles))
+	for _, role := range filteredRoles {
+		used := references[role.Arn]
+		if len(used) > 0 {
+			fmt.Println()
+			printReferences(role.Name, used)
+			if !c.options.IgnoreReferences {
+				continue
+			}
+		}
+		unreferenced = append(unreferenced, role)
+	}
+	if skipped := len(filteredRoles) - len(unreferenced); skipped > 0 {
+		fmt.Printf("\nSkipping %d roles that are still in use, use --ignore-references to delete them anyway\n", skipped)
+		filteredRoles = unreferenced
+	}
+	if len(filteredRoles) == 0 {
+		fmt.Println("No IAM roles left to delete")
+		return nil
+	}
+
+	// Show the resource policies whose grants the deletion orphans
+	grants := scanResourcePolicies(ctx, c.profile, c.region, filteredRoles, c.options.ScanRegions)
+	orphaned := 0
+	for _, role := range filteredRoles {
+		if granted := grants[role.Arn]; len(granted) > 0 {
+			fmt.Println()
+			printResourcePolicies(role.Name, granted)
+			orphaned += len(granted)
+		}
 	}
 
 	// If dry run, stop here
@@ -90,6 +246,9 @@ func (c *PruneCommand) Execute(ctx context.Context) error {
 	// Confirm deletion if force flag is not set
 	if !c.options.Force {
 		prompt := fmt.Sprintf("\nAre you sure you want to delete %d roles? This cannot be undone. [y/N]: ", len(filteredRoles))
+		if orphaned > 0 {
+			prompt = fmt.Sprintf("\nAre you sure you want to delete %d roles? This cannot be undone and orphans %d resource policy grants. [y/N]: ", len(filteredRoles), orphaned)
+		}
 		confirmed, err := ConfirmAction(prompt)
 		if err != nil {
 			return errors.Wrap(err, "failed to read confirmation")
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/errors"
+)
+
+// scanReferences finds the resources that still use the roles, keyed by
+// role ARN, in the given regions or every enabled region. A failed scan is
+// an error unless ignore is set, since the roles might then be in use
+// without us knowing.
+func scanReferences(ctx context.Context, profile, region string, roles []aws.Role, ignore bool, scanRegions []string) (map[string][]aws.Reference, error) {
+	scanners, err := aws.NewReferenceScanners(ctx, profile, region, scanRegions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create reference scanners")
+	}
+
+	roleArns := make([]string, 0, len(roles))
+	for _, role := range roles {
+		roleArns = append(roleArns, role.Arn)
+	}
+
+	references, failures := aws.ScanReferences(ctx, scanners, roleArns)
+	if len(failures) > 0 {
+		message := "failed to check whether roles are still in use: " + aws.JoinScanFailures(failures)
+		if !ignore {
+			return nil, errors.Errorf("%s; use --ignore-references to continue anyway", message)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
+	}
+	if len(scanRegions) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: Only regions %s were checked for resources that use the roles, resources in other regions may still use them\n", strings.Join(scanRegions, ", "))
+	}
+
+	return references, nil
+}
+
+// printReferences lists the resources that use a role
+func printReferences(roleName string, references []aws.Reference) {
+	fmt.Printf("Role %s is used by %d resources:\n", roleName, len(references))
+	for _, reference := range references {
+		fmt.Printf("  - %s\n", reference)
+	}
+}
+
+// scanResourcePolicies finds the resource policies that grant the roles,
+// keyed by role ARN, in the given regions or every enabled region. These
+// grants do not keep a role in use, so a failed scan only warns.
+func scanResourcePolicies(ctx context.Context, profile, region string, roles []aws.Role, scanRegions []string) map[string][]aws.Reference {
+	scanners, err := aws.NewResourcePolicyScanners(ctx, profile, region, scanRegions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not check resource policies: %v\n", err)
+		return nil
+	}
+
+	roleArns := make([]string, 0, len(roles))
+	for _, role := range roles {
+		roleArns = append(roleArns, role.Arn)
+	}
+
+	grants, failures := aws.ScanReferences(ctx, scanners, roleArns)
+	if len(failures) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: Could not check resource policies: %s\n", aws.JoinScanFailures(failures))
+	}
+	return grants
+}
+
+// printResourcePolicies lists the resource policies that grant a role
+func printResourcePolicies(roleName string, grants []aws.Reference) {
+	fmt.Printf("Role %s is granted access by %d resource policies:\n", roleName, len(grants))
+	for _, grant := range grants {
+		fmt.Printf("  - %s\n", grant)
+	}
+}
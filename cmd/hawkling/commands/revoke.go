@@ -0,0 +1,365 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/backup"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/policy"
+)
+
+// RevokeSessionsPolicyName is the inline policy name used by the IAM
+// console's "Revoke active sessions" action
+const RevokeSessionsPolicyName = "AWSRevokeOlderSessions"
+
+// RevokeSessionsOptions contains options for the revoke-sessions command
+type RevokeSessionsOptions struct {
+	FilterOptions
+	Trusting  string
+	DryRun    bool
+	Force     bool
+	BackupDir string
+}
+
+// SessionRevocation records a session revocation so it can be cleaned up later
+type SessionRevocation struct {
+	PolicyName string
+	RevokedAt  time.Time
+	Roles      []string
+
+	// Document is the revocation policy put on the roles. Cleanup only
+	// removes the policy from roles where it is still this document.
+	Document string `json:",omitempty"`
+	// Previous holds the revocation policies the roles had before, by role
+	// name. Cleanup puts them back instead of removing the policy.
+	Previous map[string]string `json:",omitempty"`
+}
+
+// revocationDocument returns the policy recorded as put on the roles.
+// Records written before the document was recorded are rebuilt from the
+// revocation time.
+func (r SessionRevocation) revocationDocument() (string, error) {
+	if r.Document != "" {
+		return r.Document, nil
+	}
+	return revokeSessionsPolicy(r.RevokedAt).JSON()
+}
+
+// RevokeSessionsCommand represents the revoke-sessions command
+type RevokeSessionsCommand struct {
+	profile   string
+	region    string
+	roleNames []string
+	options   RevokeSessionsOptions
+}
+
+// NewRevokeSessionsCommand creates a new revoke-sessions command
+func NewRevokeSessionsCommand(profile, region string, roleNames []string, options RevokeSessionsOptions) *RevokeSessionsCommand {
+	return &RevokeSessionsCommand{
+		profile:   profile,
+		region:    region,
+		roleNames: roleNames,
+		options:   options,
+	}
+}
+
+// Execute runs the revoke-sessions command
+func (c *RevokeSessionsCommand) Execute(ctx context.Context) error {
+	hasFilter := c.options.PathPrefix != "" || c.options.NamePattern != "" || c.options.Trusting != ""
+	if len(c.roleNames) > 0 && hasFilter {
+		return errors.NewValidationError("specify either role names or filters, not both")
+	}
+	if len(c.roleNames) == 0 && !hasFilter {
+		return errors.NewValidationError("specify role names, --path-prefix, --name or --trusting")
+	}
+	if err := c.options.FilterOptions.validate(); err != nil {
+		return err
+	}
+
+	client, err := aws.NewAWSClient(ctx, c.profile, c.region)
+	if err != nil {
+		return errors.Wrap(err, "failed to create AWS client")
+	}
+
+	roleNames := c.roleNames
+	if hasFilter {
+		roleNames, err = c.selectRoles(ctx, client)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(roleNames) == 0 {
+		fmt.Println("No IAM roles found matching criteria")
+		return nil
+	}
+
+	revokedAt := time.Now().UTC().Truncate(time.Second)
+	document, err := revokeSessionsPolicy(revokedAt).JSON()
+	if err != nil {
+		return errors.Wrap(err, "failed to build revocation policy")
+	}
+
+	fmt.Printf("Found %d IAM roles to revoke sessions for (issued before %s):\n", len(roleNames), revokedAt.Format(time.RFC3339))
+	for i, name := range roleNames {
+		fmt.Printf("%d. %s\n", i+1, name)
+	}
+
+	// If dry run, stop here
+	if c.options.DryRun {
+		fmt.Printf("\nInline policy %s:\n%s", RevokeSessionsPolicyName, document)
+		fmt.Println("\nDRY RUN: No sessions were revoked")
+		return nil
+	}
+
+	// Confirm revocation if force flag is not set
+	if !c.options.Force {
+		prompt := fmt.Sprintf("\nAre you sure you want to revoke active sessions of %d roles? [y/N]: ", len(roleNames))
+		confirmed, err := ConfirmAction(prompt)
+		if err != nil {
+			return errors.Wrap(err, "failed to read confirmation")
+		}
+
+		if !confirmed {
+			fmt.Println("Revocation cancelled")
+			return nil
+		}
+	}
+
+	// Keep the revocation policy a role already has, so cleanup can put it back
+	var mu sync.Mutex
+	previous := make(map[string]string)
+	failures := forEachRole(roleNames, func(roleName string) error {
+		existing, err := client.GetRolePolicy(ctx, roleName, RevokeSessionsPolicyName)
+		if err != nil {
+			return err
+		}
+		if err := client.PutRolePolicy(ctx, roleName, RevokeSessionsPolicyName, document); err != nil {
+			return err
+		}
+		if existing != "" {
+			mu.Lock()
+			previous[roleName] = existing
+			mu.Unlock()
+		}
+		return nil
+	})
+
+	revoked := make([]string, 0, len(roleNames))
+	for _, name := range roleNames {
+		if err, failed := failures[name]; failed {
+			fmt.Printf("Failed to revoke sessions of role %s: %v\n", name, err)
+			continue
+		}
+		revoked = append(revoked, name)
+		if _, replaced := previous[name]; replaced {
+			fmt.Printf("Revoked sessions of role: %s (replaced an earlier %s policy)\n", name, RevokeSessionsPolicyName)
+		} else {
+			fmt.Printf("Revoked sessions of role: %s\n", name)
+		}
+	}
+
+	// Record what was done so the deny policies can be removed later
+	if len(revoked) > 0 {
+		record := SessionRevocation{
+			PolicyName: RevokeSessionsPolicyName,
+			RevokedAt:  revokedAt,
+			Roles:      revoked,
+			Document:   document,
+		}
+		if len(previous) > 0 {
+			record.Previous = previous
+		}
+		recordPath, err := backup.SaveJSON(c.options.BackupDir, "session-revocation", "revocation", record)
+		if err != nil {
+			return errors.Wrap(err, "failed to record session revocation")
+		}
+		fmt.Printf("\nRecorded revocation in %s\n", recordPath)
+		fmt.Printf("Remove the deny policies later with: hawkling revoke-sessions cleanup %s\n", recordPath)
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("failed to revoke sessions of %d roles", len(failures))
+	}
+
+	fmt.Printf("\nSuccessfully revoked sessions of %d IAM roles\n", len(revoked))
+	return nil
+}
+
+// selectRoles returns the names of roles matching the filters
+func (c *RevokeSessionsCommand) selectRoles(ctx context.Context, client aws.IAMClient) ([]string, error) {
+	roles, err := client.ListRoles(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list roles")
+	}
+
+	roles = aws.FilterRoles(roles, c.options.FilterOptions.toAWS())
+
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if c.options.Trusting != "" {
+			doc, err := policy.Parse(role.TrustPolicy)
+			if err != nil || !doc.TrustsPrincipal(c.options.Trusting) {
+				continue
+			}
+		}
+		names = append(names, role.Name)
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// revokeSessionsPolicy builds the policy that denies everything to sessions
+// issued before the given time
+func revokeSessionsPolicy(revokedAt time.Time) *policy.Document {
+	return &policy.Document{
+		Version: "2012-10-17",
+		Statement: policy.Statements{
+			{
+				Effect:   policy.EffectDeny,
+				Action:   policy.StringList{"*"},
+				Resource: policy.StringList{"*"},
+				Condition: policy.Condition{
+					"DateLessThan": {
+						"aws:TokenIssueTime": {revokedAt.Format(time.RFC3339)},
+					},
+				},
+			},
+		},
+	}
+}
+
+// RevokeSessionsCleanupCommand represents the revoke-sessions cleanup command
+type RevokeSessionsCleanupCommand struct {
+	profile    string
+	region     string
+	recordPath string
+	options    DeleteOptions
+}
+
+// NewRevokeSessionsCleanupCommand creates a new revoke-sessions cleanup command
+func NewRevokeSessionsCleanupCommand(profile, region, recordPath string, options DeleteOptions) *RevokeSessionsCleanupCommand {
+	return &RevokeSessionsCleanupCommand{
+		profile:    profile,
+		region:     region,
+		recordPath: recordPath,
+		options:    options,
+	}
+}
+
+// Execute runs the revoke-sessions cleanup command
+func (c *RevokeSessionsCleanupCommand) Execute(ctx context.Context) error {
+	var record SessionRevocation
+	if err := backup.LoadJSON(c.recordPath, &record); err != nil {
+		return errors.Wrap(err, "failed to load revocation record")
+	}
+
+	if len(record.Roles) == 0 {
+		fmt.Println("No roles recorded in revocation record")
+		return nil
+	}
+
+	fmt.Printf("Found %d IAM roles with policy %s (revoked at %s):\n", len(record.Roles), record.PolicyName, record.RevokedAt.Format(time.RFC3339))
+	for i, name := range record.Roles {
+		fmt.Printf("%d. %s\n", i+1, name)
+	}
+
+	// If dry run, stop here
+	if c.options.DryRun {
+		fmt.Println("\nDRY RUN: No policies were removed")
+		return nil
+	}
+
+	// Confirm removal if force flag is not set
+	if !c.options.Force {
+		prompt := fmt.Sprintf("\nAre you sure you want to remove the revocation policy from %d roles? [y/N]: ", len(record.Roles))
+		confirmed, err := ConfirmAction(prompt)
+		if err != nil {
+			return errors.Wrap(err, "failed to read confirmation")
+		}
+
+		if !confirmed {
+			fmt.Println("Cleanup cancelled")
+			return nil
+		}
+	}
+
+	client, err := aws.NewAWSClient(ctx, c.profile, c.region)
+	if err != nil {
+		return errors.Wrap(err, "failed to create AWS client")
+	}
+
+	document, err := record.revocationDocument()
+	if err != nil {
+		return errors.Wrap(err, "failed to build revocation policy")
+	}
+
+	// A role whose policy changed since the record was written was revoked
+	// again later, and that newer revocation must be kept
+	var mu sync.Mutex
+	skipped := make(map[string]string)
+	failures := forEachRole(record.Roles, func(roleName string) error {
+		current, err := client.GetRolePolicy(ctx, roleName, record.PolicyName)
+		if err != nil {
+			return err
+		}
+
+		reason := ""
+		switch {
+		case current == "":
+			reason = "policy already removed"
+		case !audit.SameDocument(current, document):
+			reason = "policy changed since this revocation, likely by a newer one"
+		}
+		if reason != "" {
+			mu.Lock()
+			skipped[roleName] = reason
+			mu.Unlock()
+			return nil
+		}
+
+		if previous := record.Previous[roleName]; previous != "" {
+			return client.PutRolePolicy(ctx, roleName, record.PolicyName, previous)
+		}
+		return client.DeleteRolePolicy(ctx, roleName, record.PolicyName)
+	})
+
+	var failedRoles []string
+	cleaned := 0
+	for _, name := range record.Roles {
+		if err, failed := failures[name]; failed {
+			failedRoles = append(failedRoles, name)
+			fmt.Printf("Failed to remove policy from role %s: %v\n", name, err)
+			continue
+		}
+		if reason, ok := skipped[name]; ok {
+			fmt.Printf("Skipped role %s: %s\n", name, reason)
+			continue
+		}
+		cleaned++
+		if record.Previous[name] != "" {
+			fmt.Printf("Restored the earlier policy of role: %s\n", name)
+		} else {
+			fmt.Printf("Removed policy from role: %s\n", name)
+		}
+	}
+
+	if len(failedRoles) > 0 {
+		fmt.Printf("\nFailed to clean up %d roles: %s\n", len(failedRoles), strings.Join(failedRoles, ", "))
+		return errors.Errorf("failed to clean up %d roles", len(failedRoles))
+	}
+
+	if len(skipped) > 0 {
+		fmt.Printf("\nSkipped %d roles whose policy no longer matches the record\n", len(skipped))
+	}
+	fmt.Printf("\nSuccessfully removed the revocation policy from %d IAM roles\n", cleaned)
+	return nil
+}
@@ -0,0 +1,178 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/formatter"
+)
+
+// Defaults for polling service last accessed report jobs
+const (
+	defaultPollInterval = 2 * time.Second
+	defaultPollTimeout  = 5 * time.Minute
+)
+
+// RightsizeOptions contains options for the rightsize command
+type RightsizeOptions struct {
+	FilterOptions
+	Output string
+
+	// PollInterval and PollTimeout control how report jobs are polled; zero
+	// values use the defaults
+	PollInterval time.Duration
+	PollTimeout  time.Duration
+}
+
+// RightsizeCommand represents the rightsize command
+type RightsizeCommand struct {
+	profile  string
+	region   string
+	roleName string
+	options  RightsizeOptions
+}
+
+// NewRightsizeCommand creates a new rightsize command. An empty role name
+// selects every used role matching the filter options.
+func NewRightsizeCommand(profile, region, roleName string, options RightsizeOptions) *RightsizeCommand {
+	return &RightsizeCommand{
+		profile:  profile,
+		region:   region,
+		roleName: roleName,
+		options:  options,
+	}
+}
+
+// Execute runs the rightsize command
+func (c *RightsizeCommand) Execute(ctx context.Context) error {
+	if err := c.options.validate(); err != nil {
+		return err
+	}
+	if c.options.Days <= 0 {
+		return errors.NewValidationError("--days must be greater than zero")
+	}
+
+	client, err := aws.NewAWSClient(ctx, c.profile, c.region)
+	if err != nil {
+		return errors.Wrap(err, "failed to create AWS client")
+	}
+
+	roles, err := client.ListRoles(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list roles")
+	}
+
+	roles, err = c.selectRoles(roles)
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]aws.Role, len(roles))
+	roleNames := make([]string, 0, len(roles))
+	for _, role := range roles {
+		byName[role.Name] = role
+		roleNames = append(roleNames, role.Name)
+	}
+
+	now := time.Now()
+	var mu sync.Mutex
+	reports := make([]audit.RightsizeReport, 0, len(roles))
+	failures := forEachRole(roleNames, func(roleName string) error {
+		role := byName[roleName]
+		services, err := serviceLastAccessed(ctx, client, role.Arn, c.options.PollInterval, c.options.PollTimeout)
+		if err != nil {
+			return err
+		}
+
+		report := audit.Rightsize(role, services, c.options.Days, now)
+		mu.Lock()
+		reports = append(reports, report)
+		mu.Unlock()
+		return nil
+	})
+	for _, name := range roleNames {
+		if err, ok := failures[name]; ok {
+			fmt.Fprintf(os.Stderr, "Warning: Skipping role %s: %s\n", name, err)
+		}
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].RoleName < reports[j].RoleName
+	})
+
+	format := formatter.Format(strings.ToLower(c.options.Output))
+	if err := formatter.FormatRightsizeReports(reports, format); err != nil {
+		return errors.Wrap(err, "failed to format output")
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("failed to rightsize %d roles", len(failures))
+	}
+
+	return nil
+}
+
+// selectRoles returns the named role, or every used role matching the
+// filter options. Days sets the access period and does not filter roles.
+func (c *RightsizeCommand) selectRoles(roles []aws.Role) ([]aws.Role, error) {
+	if c.roleName != "" {
+		for _, role := range roles {
+			if role.Name == c.roleName {
+				return []aws.Role{role}, nil
+			}
+		}
+		return nil, errors.Errorf("role '%s' not found", c.roleName)
+	}
+
+	filter := c.options.toAWS()
+	filter.Days = 0
+	filter.OnlyUsed = true
+	return aws.FilterRoles(roles, filter), nil
+}
+
+// serviceLastAccessed generates a service last accessed report for an IAM
+// entity and polls the job until it completes
+func serviceLastAccessed(ctx context.Context, client aws.IAMClient, arn string, interval, timeout time.Duration) ([]aws.ServiceLastAccessed, error) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	if timeout <= 0 {
+		timeout = defaultPollTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	jobID, err := client.GenerateServiceLastAccessedDetails(ctx, arn)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		job, err := client.GetServiceLastAccessedDetails(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch job.Status {
+		case aws.JobStatusCompleted:
+			return job.Services, nil
+		case aws.JobStatusFailed:
+			return nil, errors.Errorf("service last accessed job %s failed: %s", jobID, job.Error)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Errorf("timed out waiting for service last accessed job %s", jobID)
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/errors"
+)
+
+// readStackOwnership looks up the CloudFormation stacks of the roles, with
+// their tags taken from the inventory, or listed for each role without one.
+// Roles whose stack cannot be looked up are treated as managed.
+func readStackOwnership(ctx context.Context, profile, region string, client aws.IAMClient, inventory *aws.Inventory, roles []aws.Role) error {
+	if inventory != nil {
+		inventory.AttachTags(roles)
+	} else if err := readRoleTags(ctx, client, roles); err != nil {
+		return err
+	}
+
+	tagged := false
+	for _, role := range roles {
+		if role.Tags[aws.StackNameTag] != "" {
+			tagged = true
+			break
+		}
+	}
+	if !tagged {
+		return nil
+	}
+
+	stackClient, err := aws.NewStackClient(ctx, profile, region)
+	if err != nil {
+		return errors.Wrap(err, "failed to create CloudFormation client")
+	}
+
+	failures := aws.AttachStackOwnership(ctx, stackClient, roles)
+	stacks := make([]string, 0, len(failures))
+	for stack := range failures {
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+	for _, stack := range stacks {
+		fmt.Fprintf(os.Stderr, "Warning: Could not look up stack %s, treating its roles as managed: %v\n", stack, failures[stack])
+	}
+	return nil
+}
+
+// readRoleTags lists the tags of each role, which only needs
+// iam:ListRoleTags rather than the whole account's authorization details
+func readRoleTags(ctx context.Context, client aws.IAMClient, roles []aws.Role) error {
+	names := make([]string, 0, len(roles))
+	index := make(map[string]int, len(roles))
+	for i, role := range roles {
+		names = append(names, role.Name)
+		index[role.Name] = i
+	}
+
+	var mu sync.Mutex
+	failures := forEachRole(names, func(roleName string) error {
+		tags, err := client.ListRoleTags(ctx, roleName)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		roles[index[roleName]].Tags = tags
+		mu.Unlock()
+		return nil
+	})
+	if len(failures) == 0 {
+		return nil
+	}
+
+	failed := make([]string, 0, len(failures))
+	for name := range failures {
+		failed = append(failed, name)
+	}
+	sort.Strings(failed)
+	return errors.Errorf("failed to list the tags of roles %s (needs iam:ListRoleTags): %v", strings.Join(failed, ", "), failures[failed[0]])
+}
+
+// orphansFirst moves roles orphaned from their stack to the front, keeping
+// the order within both groups
+func orphansFirst(roles []aws.Role) {
+	sort.SliceStable(roles, func(i, j int) bool {
+		return isOrphaned(roles[i]) && !isOrphaned(roles[j])
+	})
+}
+
+// isOrphaned reports whether the stack that created the role is gone
+func isOrphaned(role aws.Role) bool {
+	return role.Stack != nil && role.Stack.Orphaned
+}
+
+// isStackManaged reports whether a live stack still manages the role
+func isStackManaged(role aws.Role) bool {
+	return role.Stack != nil && !role.Stack.Orphaned
+}
@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/terraform"
+)
+
+// readTerraformState marks the roles declared in the Terraform state files
+// with their resource address, and warns about declared roles that no
+// longer exist in the account
+func readTerraformState(paths []string, roles []aws.Role) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	managed, err := terraform.LoadRoles(paths)
+	if err != nil {
+		return errors.Wrap(err, "failed to load Terraform state")
+	}
+
+	for _, drifted := range terraform.AttachAddresses(roles, managed) {
+		fmt.Fprintf(os.Stderr, "Warning: Role %s is declared at %s in %s but does not exist in the account\n",
+			drifted.Name, drifted.Address, drifted.StateFile)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/backup"
+	"hawkling/pkg/diff"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/policy"
+)
+
+// TrustOptions contains options for the trust commands
+type TrustOptions struct {
+	FilterOptions
+	DryRun    bool
+	Force     bool
+	BackupDir string
+}
+
+// TrustRemovePrincipalCommand represents the trust remove-principal command
+type TrustRemovePrincipalCommand struct {
+	profile   string
+	region    string
+	principal string
+	options   TrustOptions
+}
+
+// trustChange describes the edit of a single trust policy
+type trustChange struct {
+	role         aws.Role
+	removed      []string
+	before       string
+	after        string
+	noPrincipals bool
+}
+
+// NewTrustRemovePrincipalCommand creates a new trust remove-principal command
+func NewTrustRemovePrincipalCommand(profile, region, principal string, options TrustOptions) *TrustRemovePrincipalCommand {
+	return &TrustRemovePrincipalCommand{
+		profile:   profile,
+		region:    region,
+		principal: principal,
+		options:   options,
+	}
+}
+
+// Execute runs the trust remove-principal command
+func (c *TrustRemovePrincipalCommand) Execute(ctx context.Context) error {
+	if !policy.IsAccountID(c.principal) && !strings.HasPrefix(c.principal, "arn:") {
+		return errors.NewValidationError(fmt.Sprintf("principal %q must be an account ID or an ARN", c.principal))
+	}
+	if err := c.options.FilterOptions.validate(); err != nil {
+		return err
+	}
+
+	client, err := aws.NewAWSClient(ctx, c.profile, c.region)
+	if err != nil {
+		return errors.Wrap(err, "failed to create AWS client")
+	}
+
+	roles, err := client.ListRoles(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list roles")
+	}
+
+	roles = aws.FilterRoles(roles, c.options.FilterOptions.toAWS())
+
+	changes := c.planChanges(roles)
+	if len(changes) == 0 {
+		fmt.Printf("No trust policies reference %s\n", c.principal)
+		return nil
+	}
+
+	fmt.Printf("Found %d IAM roles trusting %s:\n", len(changes), c.principal)
+	for i, change := range changes {
+		fmt.Printf("%d. %s (removing: %s)\n", i+1, change.role.Name, strings.Join(change.removed, ", "))
+	}
+
+	// If dry run, show the policy changes and stop here
+	if c.options.DryRun {
+		for _, change := range changes {
+			fmt.Println()
+			fmt.Print(diff.Unified(change.role.Name+" (current)", change.role.Name+" (updated)", change.before, change.after))
+		}
+		printDeletionCandidates(changes)
+		fmt.Println("\nDRY RUN: No trust policies were modified")
+		return nil
+	}
+
+	// Confirm the update if force flag is not set
+	if !c.options.Force {
+		prompt := fmt.Sprintf("\nAre you sure you want to update %d trust policies? [y/N]: ", len(changes))
+		confirmed, err := ConfirmAction(prompt)
+		if err != nil {
+			return errors.Wrap(err, "failed to read confirmation")
+		}
+
+		if !confirmed {
+			fmt.Println("Update cancelled")
+			return nil
+		}
+	}
+
+	var failedRoles []string
+	for _, change := range changes {
+		// Back up the current trust policy before replacing it
+		backupPath, err := backup.Save(c.options.BackupDir, "trust-policy", change.role.Name, []byte(change.role.TrustPolicy))
+		if err != nil {
+			failedRoles = append(failedRoles, change.role.Name)
+			fmt.Printf("Failed to back up trust policy of role %s: %v\n", change.role.Name, err)
+			continue
+		}
+
+		if err := client.UpdateAssumeRolePolicy(ctx, change.role.Name, change.after); err != nil {
+			failedRoles = append(failedRoles, change.role.Name)
+			fmt.Printf("Failed to update trust policy of role %s: %v\n", change.role.Name, err)
+			continue
+		}
+
+		fmt.Printf("Updated trust policy of role %s (backup: %s)\n", change.role.Name, backupPath)
+	}
+
+	printDeletionCandidates(changes)
+
+	if len(failedRoles) > 0 {
+		fmt.Printf("\nFailed to update %d roles: %s\n", len(failedRoles), strings.Join(failedRoles, ", "))
+		return errors.Errorf("failed to update %d roles", len(failedRoles))
+	}
+
+	fmt.Printf("\nSuccessfully updated %d trust policies\n", len(changes))
+	return nil
+}
+
+// planChanges works out the trust policy edits for the given roles
+func (c *TrustRemovePrincipalCommand) planChanges(roles []aws.Role) []trustChange {
+	changes := make([]trustChange, 0, len(roles))
+
+	for _, role := range roles {
+		if role.TrustPolicy == "" {
+			continue
+		}
+
+		current, err := policy.Parse(role.TrustPolicy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Skipping role %s: %v\n", role.Name, err)
+			continue
+		}
+
+		updated := current.Clone()
+		removed := updated.RemovePrincipal(c.principal)
+		if len(removed) == 0 {
+			continue
+		}
+
+		noPrincipals := !updated.HasPrincipals()
+		if len(updated.Statement) == 0 {
+			updated = policy.DenyAllTrustPolicy()
+		}
+
+		before, err := current.JSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Skipping role %s: %v\n", role.Name, err)
+			continue
+		}
+		after, err := updated.JSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Skipping role %s: %v\n", role.Name, err)
+			continue
+		}
+
+		changes = append(changes, trustChange{
+			role:         role,
+			removed:      removed,
+			before:       before,
+			after:        after,
+			noPrincipals: noPrincipals,
+		})
+	}
+
+	return changes
+}
+
+// printDeletionCandidates lists roles that no principal can assume any more
+func printDeletionCandidates(changes []trustChange) {
+	var candidates []string
+	for _, change := range changes {
+		if change.noPrincipals {
+			candidates = append(candidates, change.role.Name)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%d roles have no principals left and are candidates for deletion:\n", len(candidates))
+	for i, name := range candidates {
+		fmt.Printf("%d. %s\n", i+1, name)
+	}
+}
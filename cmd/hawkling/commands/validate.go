@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/formatter"
+)
+
+// ValidateOptions contains options for the validate command
+type ValidateOptions struct {
+	FilterOptions
+	Inventory    string
+	Output       string
+	FindingTypes []string
+	EndpointURL  string
+}
+
+// ValidateCommand represents the validate command
+type ValidateCommand struct {
+	profile string
+	region  string
+	options ValidateOptions
+}
+
+// NewValidateCommand creates a new validate command
+func NewValidateCommand(profile, region string, options ValidateOptions) *ValidateCommand {
+	return &ValidateCommand{
+		profile: profile,
+		region:  region,
+		options: options,
+	}
+}
+
+// Execute runs the validate command
+func (c *ValidateCommand) Execute(ctx context.Context) error {
+	if err := c.options.validate(); err != nil {
+		return err
+	}
+
+	findingTypes := make([]string, 0, len(c.options.FindingTypes))
+	for _, name := range c.options.FindingTypes {
+		findingType, ok := audit.ParseFindingType(name)
+		if !ok {
+			return errors.NewValidationError(fmt.Sprintf("unknown finding type %q, expected error, security-warning, warning or suggestion", name))
+		}
+		findingTypes = append(findingTypes, findingType)
+	}
+
+	inventory, err := loadInventory(ctx, c.profile, c.region, c.options.Inventory)
+	if err != nil {
+		return err
+	}
+
+	validator, err := aws.NewAnalyzerClient(ctx, c.profile, c.region, c.options.EndpointURL)
+	if err != nil {
+		return errors.Wrap(err, "failed to create Access Analyzer client")
+	}
+
+	roles := aws.FilterRoles(inventory.Roles, c.options.toAWS())
+	byName := make(map[string]aws.Role, len(roles))
+	roleNames := make([]string, 0, len(roles))
+	for _, role := range roles {
+		byName[role.Name] = role
+		roleNames = append(roleNames, role.Name)
+	}
+
+	var mu sync.Mutex
+	findings := make(map[string][]audit.PolicyFinding, len(roles))
+	failures := forEachRole(roleNames, func(roleName string) error {
+		roleFindings, err := audit.ValidateRole(ctx, validator, byName[roleName])
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		findings[roleName] = roleFindings
+		mu.Unlock()
+		return nil
+	})
+	for _, name := range roleNames {
+		if err, ok := failures[name]; ok {
+			fmt.Fprintf(os.Stderr, "Warning: Skipping role %s: %s\n", name, err)
+		}
+	}
+
+	validations := audit.SummarizeValidation(roles, findings, findingTypes)
+
+	format := formatter.Format(strings.ToLower(c.options.Output))
+	if err := formatter.FormatRoleValidations(validations, format); err != nil {
+		return errors.Wrap(err, "failed to format output")
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("failed to validate %d roles", len(failures))
+	}
+
+	return nil
+}
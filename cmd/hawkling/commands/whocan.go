@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/errors"
+	"hawkling/pkg/formatter"
+	"hawkling/pkg/policy"
+)
+
+// WhoCanOptions contains options for the who-can command
+type WhoCanOptions struct {
+	Days      int
+	Inventory string
+	Context   []string
+	Output    string
+}
+
+// WhoCanCommand represents the who-can command
+type WhoCanCommand struct {
+	profile  string
+	region   string
+	action   string
+	resource string
+	options  WhoCanOptions
+}
+
+// NewWhoCanCommand creates a new who-can command
+func NewWhoCanCommand(profile, region, action, resource string, options WhoCanOptions) *WhoCanCommand {
+	return &WhoCanCommand{
+		profile:  profile,
+		region:   region,
+		action:   action,
+		resource: resource,
+		options:  options,
+	}
+}
+
+// Execute runs the who-can command
+func (c *WhoCanCommand) Execute(ctx context.Context) error {
+	requestContext, err := parseRequestContext(c.options.Context)
+	if err != nil {
+		return err
+	}
+
+	inventory, err := loadInventory(ctx, c.profile, c.region, c.options.Inventory)
+	if err != nil {
+		return err
+	}
+
+	request := policy.Request{
+		Action:   c.action,
+		Resource: c.resource,
+		Context:  requestContext,
+	}
+	roles, skipped := audit.WhoCan(inventory, request, c.options.Days)
+
+	for _, role := range skipped {
+		fmt.Fprintf(os.Stderr, "Warning: Skipping role %s: %s\n", role.RoleName, role.Reason)
+	}
+
+	format := formatter.Format(strings.ToLower(c.options.Output))
+	if err := formatter.FormatRoleAccess(roles, format); err != nil {
+		return errors.Wrap(err, "failed to format output")
+	}
+
+	return nil
+}
@@ -20,6 +20,19 @@ var (
 	showAllInfo bool
 	onlyUsed    bool
 	onlyUnused  bool
+	backupDir   string
+	pathPrefix  string
+	namePattern string
+	trusting    string
+	inventory   string
+	tfstate     []string
+
+	useAnalyzer      bool
+	requireAgreement bool
+	ignoreReferences bool
+	scanRegions      []string
+
+	creator commands.CreatorOptions
 )
 
 func main() {
@@ -45,6 +58,9 @@ Complete documentation is available at https://github.com/watany-dev/hawkling`,
 
 	// List command
 	var listDays int
+	var listRisk bool
+	var listSortBy string
+	var listStacks bool
 	listCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List IAM roles, optionally filtering for unused roles",
@@ -54,9 +70,18 @@ Complete documentation is available at https://github.com/watany-dev/hawkling`,
 					Days:       listDays,
 					OnlyUsed:   onlyUsed,
 					OnlyUnused: onlyUnused,
+
+					RequireAnalyzerAgreement: requireAgreement,
 				},
-				Output:  output,
-				ShowAll: showAllInfo,
+				Output:   output,
+				ShowAll:  showAllInfo,
+				Risk:     listRisk,
+				SortBy:   listSortBy,
+				Analyzer: useAnalyzer,
+				Stacks:   listStacks,
+
+				TerraformState: tfstate,
+				Creator:        creator,
 			}
 
 			listCmd := commands.NewListCommand(profile, region, listOptions)
@@ -65,6 +90,12 @@ Complete documentation is available at https://github.com/watany-dev/hawkling`,
 	}
 	commands.AddFilterFlags(listCmd, &listDays, &onlyUsed, &onlyUnused)
 	commands.AddOutputFlags(listCmd, &output, &showAllInfo)
+	listCmd.Flags().BoolVar(&listRisk, "risk", false, "Show the risk score of each role and the factors behind it")
+	listCmd.Flags().StringVar(&listSortBy, "sort", "", "Sort roles by name, last-used or risk")
+	commands.AddAnalyzerFlags(listCmd, &useAnalyzer, &requireAgreement)
+	commands.AddTerraformFlag(listCmd, &tfstate)
+	listCmd.Flags().BoolVar(&listStacks, "stacks", false, "Show which CloudFormation stack manages each role, or that its stack is gone")
+	commands.AddCreatorFlags(listCmd, &creator)
 
 	// Delete command
 	deleteCmd := &cobra.Command{
@@ -74,8 +105,10 @@ Complete documentation is available at https://github.com/watany-dev/hawkling`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			roleName := args[0]
 			deleteOptions := commands.DeleteOptions{
-				DryRun: dryRun,
-				Force:  force,
+				DryRun:           dryRun,
+				Force:            force,
+				IgnoreReferences: ignoreReferences,
+				ScanRegions:      scanRegions,
 			}
 
 			deleteCmd := commands.NewDeleteCommand(profile, region, roleName, deleteOptions)
@@ -83,11 +116,14 @@ Complete documentation is available at https://github.com/watany-dev/hawkling`,
 		},
 	}
 	commands.AddDeletionFlags(deleteCmd, &dryRun, &force)
+	commands.AddReferenceFlags(deleteCmd, &ignoreReferences, &scanRegions)
 
 	// Prune command
 	var pruneDays int
 	var pruneOnlyUnused bool
 	var pruneOnlyUsed bool
+	var includeStackManaged bool
+	var includeTerraformManaged bool
 	pruneCmd := &cobra.Command{
 		Use:   "prune",
 		Short: "Delete IAM roles based on specified criteria",
@@ -97,9 +133,21 @@ Complete documentation is available at https://github.com/watany-dev/hawkling`,
 					Days:       pruneDays,
 					OnlyUnused: pruneOnlyUnused,
 					OnlyUsed:   pruneOnlyUsed,
+
+					RequireAnalyzerAgreement: requireAgreement,
 				},
-				DryRun: dryRun,
-				Force:  force,
+				DryRun:           dryRun,
+				Force:            force,
+				Analyzer:         useAnalyzer,
+				IgnoreReferences: ignoreReferences,
+				ScanRegions:      scanRegions,
+
+				IncludeStackManaged: includeStackManaged,
+
+				TerraformState:          tfstate,
+				IncludeTerraformManaged: includeTerraformManaged,
+
+				Creator: creator,
 			}
 
 			pruneCmd := commands.NewPruneCommand(profile, region, pruneOptions)
@@ -109,9 +157,797 @@ Complete documentation is available at https://github.com/watany-dev/hawkling`,
 	commands.AddPruneFlags(pruneCmd, &pruneDays, &dryRun, &force)
 	pruneCmd.Flags().BoolVar(&pruneOnlyUnused, "unused", false, "Delete only unused roles")
 	pruneCmd.Flags().BoolVar(&pruneOnlyUsed, "used", false, "Delete only used roles")
+	commands.AddAnalyzerFlags(pruneCmd, &useAnalyzer, &requireAgreement)
+	commands.AddReferenceFlags(pruneCmd, &ignoreReferences, &scanRegions)
+	pruneCmd.Flags().BoolVar(&includeStackManaged, "include-stack-managed", false, "Delete roles even if a live CloudFormation stack manages them")
+	commands.AddTerraformFlag(pruneCmd, &tfstate)
+	pruneCmd.Flags().BoolVar(&includeTerraformManaged, "include-terraform-managed", false, "Delete roles even if Terraform state declares them")
+	commands.AddCreatorFlags(pruneCmd, &creator)
 
 	// Add commands to root command
-	rootCmd.AddCommand(listCmd, deleteCmd, pruneCmd)
+	rootCmd.AddCommand(listCmd, deleteCmd, pruneCmd, createTrustCommand(), createRevokeSessionsCommand(), createAuditCommand(),
+		createCanCommand(), createWhoCanCommand(), createInventoryCommand(), createLintCommand(),
+		createBoundaryCommand(), createDeprecatedPoliciesCommand(), createValidateCommand(),
+		createRightsizeCommand(), createLeastPrivilegeCommand(), createDuplicatesCommand(), createDiffRolesCommand(),
+		createGraphCommand(), createExportCommand(), createCopyRoleCommand())
 
 	return rootCmd
 }
+
+// createTrustCommand sets up the trust command and its subcommands
+func createTrustCommand() *cobra.Command {
+	trustCmd := &cobra.Command{
+		Use:   "trust",
+		Short: "Manage IAM role trust policies",
+	}
+
+	removePrincipalCmd := &cobra.Command{
+		Use:   "remove-principal [account-id|arn]",
+		Short: "Remove a principal from the trust policies of all roles",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			trustOptions := commands.TrustOptions{
+				FilterOptions: commands.FilterOptions{
+					PathPrefix:  pathPrefix,
+					NamePattern: namePattern,
+				},
+				DryRun:    dryRun,
+				Force:     force,
+				BackupDir: backupDir,
+			}
+
+			removeCmd := commands.NewTrustRemovePrincipalCommand(profile, region, args[0], trustOptions)
+			return removeCmd.Execute(context.Background())
+		},
+	}
+	commands.AddSelectionFlags(removePrincipalCmd, &pathPrefix, &namePattern)
+	commands.AddModifyFlags(removePrincipalCmd, &dryRun, &force, &backupDir)
+
+	trustCmd.AddCommand(removePrincipalCmd)
+
+	return trustCmd
+}
+
+// createRevokeSessionsCommand sets up the revoke-sessions command and its cleanup subcommand
+func createRevokeSessionsCommand() *cobra.Command {
+	revokeCmd := &cobra.Command{
+		Use:   "revoke-sessions [role-name...]",
+		Short: "Revoke active sessions of IAM roles",
+		Long: `Revoke active sessions of IAM roles by attaching an inline policy that denies
+every action to sessions issued before now, like the IAM console does.
+Roles can be given by name or selected with --path-prefix, --name and --trusting.
+Put role names after -- to revoke a role named like a subcommand, such as
+"hawkling revoke-sessions -- cleanup".`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			revokeOptions := commands.RevokeSessionsOptions{
+				FilterOptions: commands.FilterOptions{
+					PathPrefix:  pathPrefix,
+					NamePattern: namePattern,
+				},
+				Trusting:  trusting,
+				DryRun:    dryRun,
+				Force:     force,
+				BackupDir: backupDir,
+			}
+
+			revokeSessionsCmd := commands.NewRevokeSessionsCommand(profile, region, args, revokeOptions)
+			return revokeSessionsCmd.Execute(context.Background())
+		},
+	}
+	commands.AddSelectionFlags(revokeCmd, &pathPrefix, &namePattern)
+	revokeCmd.Flags().StringVar(&trusting, "trusting", "", "Select every role trusting this account ID or ARN")
+	commands.AddModifyFlags(revokeCmd, &dryRun, &force, &backupDir)
+
+	cleanupCmd := &cobra.Command{
+		Use:   "cleanup [record-file]",
+		Short: "Remove the deny policies recorded by an earlier revocation",
+		Long: `Remove the deny policies recorded by an earlier revocation. Roles whose
+policy changed since, such as by a newer revocation, are skipped, and roles
+that had an earlier revocation policy get it back.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cleanupOptions := commands.DeleteOptions{
+				DryRun: dryRun,
+				Force:  force,
+			}
+
+			revokeCleanupCmd := commands.NewRevokeSessionsCleanupCommand(profile, region, args[0], cleanupOptions)
+			return revokeCleanupCmd.Execute(context.Background())
+		},
+	}
+	commands.AddDeletionFlags(cleanupCmd, &dryRun, &force)
+
+	revokeCmd.AddCommand(cleanupCmd)
+
+	return revokeCmd
+}
+
+// createAuditCommand sets up the audit command and its subcommands
+func createAuditCommand() *cobra.Command {
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Audit IAM roles for risky configurations",
+	}
+
+	oidcCmd := &cobra.Command{
+		Use:   "oidc",
+		Short: "Audit roles trusted through OIDC providers such as EKS and GitHub Actions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			auditOptions := commands.AuditOptions{
+				Output: output,
+			}
+
+			auditOIDCCmd := commands.NewAuditOIDCCommand(profile, region, auditOptions)
+			return auditOIDCCmd.Execute(context.Background())
+		},
+	}
+	oidcCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json)")
+
+	var privescDays int
+	privescCmd := &cobra.Command{
+		Use:   "privesc",
+		Short: "Find roles with privilege escalation paths",
+		Long: `Find roles whose permissions allow known privilege escalation patterns, such as
+iam:PassRole on * with lambda:CreateFunction or ec2:RunInstances,
+iam:AttachRolePolicy, iam:PutRolePolicy, iam:UpdateAssumeRolePolicy, or an
+sts:AssumeRole chain into an admin role. Roles not used within --days are
+marked unused and listed first.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			auditOptions := commands.AuditOptions{
+				Days:      privescDays,
+				Inventory: inventory,
+				Output:    output,
+			}
+
+			auditPrivescCmd := commands.NewAuditPrivescCommand(profile, region, auditOptions)
+			return auditPrivescCmd.Execute(context.Background())
+		},
+	}
+	privescCmd.Flags().IntVarP(&privescDays, "days", "d", 90, "Consider roles unused if not used in this many days")
+	commands.AddInventoryFlag(privescCmd, &inventory)
+	privescCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json)")
+
+	var serviceRolesCatalog string
+	var onlyOrphaned bool
+	serviceRolesCmd := &cobra.Command{
+		Use:   "service-roles",
+		Short: "Find auto-generated service roles whose resource is gone",
+		Long: `Find roles that follow the naming and path conventions of roles service
+consoles create for a resource, such as <function>-role-abc123 for Lambda,
+codebuild-<project>-service-role for CodeBuild and AWSGlueServiceRole-* for Glue,
+and check whether a resource of that service still uses them. A role no
+resource uses is orphaned, whenever it was last used. Orphans with the path of
+their convention, found while every service could be scanned in every enabled
+region, are reported with high confidence. --scan-regions limits the scan to
+the given regions and lowers the confidence to medium.
+
+The conventions come from a built-in catalog. A local catalog given with
+--catalog adds patterns, which are tried first, and replaces built-in patterns
+with the same name.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			auditOptions := commands.AuditOptions{
+				Inventory:    inventory,
+				Output:       output,
+				Catalog:      serviceRolesCatalog,
+				OnlyOrphaned: onlyOrphaned,
+				ScanRegions:  scanRegions,
+			}
+
+			auditServiceRolesCmd := commands.NewAuditServiceRolesCommand(profile, region, auditOptions)
+			return auditServiceRolesCmd.Execute(context.Background())
+		},
+	}
+	serviceRolesCmd.PersistentFlags().StringVar(&serviceRolesCatalog, "catalog", "", "Local catalog file adding to the built-in service role patterns")
+	serviceRolesCmd.Flags().BoolVar(&onlyOrphaned, "orphaned", false, "Only show roles no resource uses any more")
+	commands.AddScanRegionsFlag(serviceRolesCmd, &scanRegions)
+	commands.AddInventoryFlag(serviceRolesCmd, &inventory)
+	serviceRolesCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json)")
+
+	serviceRolesCatalogCmd := &cobra.Command{
+		Use:   "catalog",
+		Short: "Print the service role patterns as JSON, to save and edit as a local catalog",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			auditServiceRolesCatalogCmd := commands.NewAuditServiceRolesCatalogCommand(serviceRolesCatalog)
+			return auditServiceRolesCatalogCmd.Execute(context.Background())
+		},
+	}
+	serviceRolesCmd.AddCommand(serviceRolesCatalogCmd)
+
+	auditCmd.AddCommand(oidcCmd, privescCmd, serviceRolesCmd)
+
+	return auditCmd
+}
+
+// createCanCommand sets up the can command
+func createCanCommand() *cobra.Command {
+	var requestContext []string
+	canCmd := &cobra.Command{
+		Use:   "can [role-name] [action] [resource]",
+		Short: "Evaluate offline whether a role is allowed to perform an action",
+		Long: `Evaluate the identity policies and permissions boundary of a role offline and
+report whether the action is allowed, together with the matching statements.
+When the resource is omitted, the action is checked against any resource.`,
+		Args: cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resource := ""
+			if len(args) == 3 {
+				resource = args[2]
+			}
+
+			canOptions := commands.CanOptions{
+				Inventory: inventory,
+				Context:   requestContext,
+				Output:    output,
+			}
+
+			canCmd := commands.NewCanCommand(profile, region, args[0], args[1], resource, canOptions)
+			return canCmd.Execute(context.Background())
+		},
+	}
+	commands.AddInventoryFlag(canCmd, &inventory)
+	canCmd.Flags().StringArrayVar(&requestContext, "context", nil, "Condition context entry as key=value (repeatable)")
+	canCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json)")
+
+	return canCmd
+}
+
+// createWhoCanCommand sets up the who-can command
+func createWhoCanCommand() *cobra.Command {
+	var whoCanDays int
+	var requestContext []string
+	whoCanCmd := &cobra.Command{
+		Use:   "who-can [action] [resource]",
+		Short: "List the roles allowed to perform an action on a resource",
+		Long: `Evaluate the identity policies and permissions boundary of every role offline
+and list the roles allowed to perform the action. Roles not used within --days
+are marked unused and listed first, since they are the best cleanup candidates.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resource := ""
+			if len(args) == 2 {
+				resource = args[1]
+			}
+
+			whoCanOptions := commands.WhoCanOptions{
+				Days:      whoCanDays,
+				Inventory: inventory,
+				Context:   requestContext,
+				Output:    output,
+			}
+
+			whoCanCmd := commands.NewWhoCanCommand(profile, region, args[0], resource, whoCanOptions)
+			return whoCanCmd.Execute(context.Background())
+		},
+	}
+	whoCanCmd.Flags().IntVarP(&whoCanDays, "days", "d", 90, "Consider roles unused if not used in this many days")
+	commands.AddInventoryFlag(whoCanCmd, &inventory)
+	whoCanCmd.Flags().StringArrayVar(&requestContext, "context", nil, "Condition context entry as key=value (repeatable)")
+	whoCanCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json)")
+
+	return whoCanCmd
+}
+
+// createInventoryCommand sets up the inventory command
+func createInventoryCommand() *cobra.Command {
+	var inventoryFile string
+	inventoryCmd := &cobra.Command{
+		Use:   "inventory",
+		Short: "Save all roles with their policies for offline analysis",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inventoryOptions := commands.InventoryOptions{
+				File: inventoryFile,
+			}
+
+			inventoryCmd := commands.NewInventoryCommand(profile, region, inventoryOptions)
+			return inventoryCmd.Execute(context.Background())
+		},
+	}
+	inventoryCmd.Flags().StringVarP(&inventoryFile, "file", "f", "hawkling-inventory.json", "File to write the inventory to")
+
+	return inventoryCmd
+}
+
+// createLintCommand sets up the lint command
+func createLintCommand() *cobra.Command {
+	var lintConfig string
+	var failOn string
+	lintCmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check roles against configurable lint rules",
+		Long: `Check every role against the built-in lint rules. Rules can be disabled and
+their severities changed in a JSON config file. With --fail-on, the command
+exits with a non-zero status when a finding is at least that severe.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lintOptions := commands.LintOptions{
+				FilterOptions: commands.FilterOptions{
+					PathPrefix:  pathPrefix,
+					NamePattern: namePattern,
+				},
+				Config:    lintConfig,
+				Inventory: inventory,
+				Output:    output,
+				FailOn:    failOn,
+			}
+
+			lintCmd := commands.NewLintCommand(profile, region, lintOptions)
+			return lintCmd.Execute(context.Background())
+		},
+	}
+	commands.AddSelectionFlags(lintCmd, &pathPrefix, &namePattern)
+	commands.AddInventoryFlag(lintCmd, &inventory)
+	lintCmd.Flags().StringVarP(&lintConfig, "config", "c", "", "JSON file configuring lint rules")
+	lintCmd.Flags().StringVar(&failOn, "fail-on", "", "Exit with a non-zero status on findings of this severity or higher (low, medium, high, critical)")
+	lintCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json, sarif)")
+
+	return lintCmd
+}
+
+// createBoundaryCommand sets up the boundary command and its subcommands
+func createBoundaryCommand() *cobra.Command {
+	var boundaryConfig string
+	boundaryCmd := &cobra.Command{
+		Use:   "boundary",
+		Short: "Check and enforce required permissions boundaries",
+	}
+
+	checkCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Report roles missing their required permissions boundary or using the wrong one",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			boundaryOptions := commands.BoundaryOptions{
+				FilterOptions: commands.FilterOptions{
+					PathPrefix:  pathPrefix,
+					NamePattern: namePattern,
+				},
+				Config:    boundaryConfig,
+				Inventory: inventory,
+				Output:    output,
+			}
+
+			boundaryCheckCmd := commands.NewBoundaryCheckCommand(profile, region, boundaryOptions)
+			return boundaryCheckCmd.Execute(context.Background())
+		},
+	}
+	checkCmd.Flags().StringVarP(&boundaryConfig, "config", "c", "", "JSON file defining RequiredBoundaries")
+	commands.AddSelectionFlags(checkCmd, &pathPrefix, &namePattern)
+	commands.AddInventoryFlag(checkCmd, &inventory)
+	checkCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json)")
+
+	applyCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Attach the required permissions boundary to non-compliant roles",
+		Long: `Attach the permissions boundary required by the config file to every role
+that is missing it or uses a different one. The previous boundary of each role
+is backed up so it can be restored with "hawkling boundary restore".`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			boundaryOptions := commands.BoundaryOptions{
+				FilterOptions: commands.FilterOptions{
+					PathPrefix:  pathPrefix,
+					NamePattern: namePattern,
+				},
+				Config:    boundaryConfig,
+				DryRun:    dryRun,
+				Force:     force,
+				BackupDir: backupDir,
+			}
+
+			boundaryApplyCmd := commands.NewBoundaryApplyCommand(profile, region, boundaryOptions)
+			return boundaryApplyCmd.Execute(context.Background())
+		},
+	}
+	applyCmd.Flags().StringVarP(&boundaryConfig, "config", "c", "", "JSON file defining RequiredBoundaries")
+	commands.AddSelectionFlags(applyCmd, &pathPrefix, &namePattern)
+	commands.AddModifyFlags(applyCmd, &dryRun, &force, &backupDir)
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore [backup-file...]",
+		Short: "Restore permissions boundaries from backups written by boundary apply",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			restoreOptions := commands.DeleteOptions{
+				DryRun: dryRun,
+				Force:  force,
+			}
+
+			boundaryRestoreCmd := commands.NewBoundaryRestoreCommand(profile, region, args, restoreOptions)
+			return boundaryRestoreCmd.Execute(context.Background())
+		},
+	}
+	commands.AddRestoreFlags(restoreCmd, &dryRun, &force)
+
+	boundaryCmd.AddCommand(checkCmd, applyCmd, restoreCmd)
+
+	return boundaryCmd
+}
+
+// createDeprecatedPoliciesCommand sets up the deprecated-policies command
+func createDeprecatedPoliciesCommand() *cobra.Command {
+	var deprecatedDays int
+	var catalogPath string
+	var replace bool
+	deprecatedCmd := &cobra.Command{
+		Use:   "deprecated-policies",
+		Short: "Report roles attaching deprecated AWS managed policies",
+		Long: `Report every role, used or not, that attaches an AWS managed policy listed in
+the deprecated policy catalog. With --replace, attach the successor policy and
+detach the deprecated one, after backing up the attachments so
+"hawkling deprecated-policies restore" can roll the change back. A local
+catalog given with --catalog adds to and overrides the built-in catalog.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			deprecatedOptions := commands.DeprecatedPoliciesOptions{
+				FilterOptions: commands.FilterOptions{
+					Days:        deprecatedDays,
+					PathPrefix:  pathPrefix,
+					NamePattern: namePattern,
+				},
+				Catalog:   catalogPath,
+				Inventory: inventory,
+				Output:    output,
+				Replace:   replace,
+				DryRun:    dryRun,
+				Force:     force,
+				BackupDir: backupDir,
+			}
+
+			deprecatedPoliciesCmd := commands.NewDeprecatedPoliciesCommand(profile, region, deprecatedOptions)
+			return deprecatedPoliciesCmd.Execute(context.Background())
+		},
+	}
+	deprecatedCmd.PersistentFlags().StringVar(&catalogPath, "catalog", "", "Local catalog file adding to the built-in catalog")
+	deprecatedCmd.Flags().IntVarP(&deprecatedDays, "days", "d", 90, "Consider roles unused if not used in this many days")
+	deprecatedCmd.Flags().BoolVar(&replace, "replace", false, "Attach the successor policy and detach the deprecated one")
+	deprecatedCmd.Flags().BoolVar(&dryRun, "dry-run", true, "With --replace, show what would be replaced without making changes")
+	deprecatedCmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation prompts")
+	commands.AddBackupDirFlag(deprecatedCmd, &backupDir)
+	commands.AddSelectionFlags(deprecatedCmd, &pathPrefix, &namePattern)
+	commands.AddInventoryFlag(deprecatedCmd, &inventory)
+	deprecatedCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json)")
+
+	catalogCmd := &cobra.Command{
+		Use:   "catalog",
+		Short: "Print the deprecated policy catalog as JSON, to save and edit as a local catalog",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			deprecatedCatalogCmd := commands.NewDeprecatedCatalogCommand(catalogPath)
+			return deprecatedCatalogCmd.Execute(context.Background())
+		},
+	}
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore [backup-file...]",
+		Short: "Restore deprecated policy attachments from backups written by --replace",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			restoreOptions := commands.DeleteOptions{
+				DryRun: dryRun,
+				Force:  force,
+			}
+
+			deprecatedRestoreCmd := commands.NewDeprecatedRestoreCommand(profile, region, args, restoreOptions)
+			return deprecatedRestoreCmd.Execute(context.Background())
+		},
+	}
+	commands.AddRestoreFlags(restoreCmd, &dryRun, &force)
+
+	deprecatedCmd.AddCommand(catalogCmd, restoreCmd)
+
+	return deprecatedCmd
+}
+
+// createValidateCommand sets up the validate command
+func createValidateCommand() *cobra.Command {
+	var findingTypes []string
+	var endpointURL string
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate role policies with IAM Access Analyzer",
+		Long: `Send the inline policies and trust policy of every role to IAM Access Analyzer
+ValidatePolicy and report its ERROR, SECURITY_WARNING, WARNING and SUGGESTION
+findings per role, with the location of each finding in the policy.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			validateOptions := commands.ValidateOptions{
+				FilterOptions: commands.FilterOptions{
+					PathPrefix:  pathPrefix,
+					NamePattern: namePattern,
+				},
+				Inventory:    inventory,
+				Output:       output,
+				FindingTypes: findingTypes,
+				EndpointURL:  endpointURL,
+			}
+
+			validateCmd := commands.NewValidateCommand(profile, region, validateOptions)
+			return validateCmd.Execute(context.Background())
+		},
+	}
+	commands.AddSelectionFlags(validateCmd, &pathPrefix, &namePattern)
+	commands.AddInventoryFlag(validateCmd, &inventory)
+	validateCmd.Flags().StringSliceVar(&findingTypes, "finding-type", nil, "Only report these finding types (error, security-warning, warning, suggestion)")
+	validateCmd.Flags().StringVar(&endpointURL, "endpoint-url", "", "Override the Access Analyzer endpoint, for example to use a local stand-in")
+	validateCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json, sarif)")
+
+	return validateCmd
+}
+
+// createRightsizeCommand sets up the rightsize command
+func createRightsizeCommand() *cobra.Command {
+	var rightsizeDays int
+	rightsizeCmd := &cobra.Command{
+		Use:   "rightsize [role-name]",
+		Short: "Report services granted to used roles but not accessed recently",
+		Long: `Generate an IAM service last accessed report for the named role, or for every
+used role matching --path-prefix and --name, and report the services each role
+is granted but has not accessed in --days days. For services where AWS tracks
+actions, accessed services also list their unused actions.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			roleName := ""
+			if len(args) == 1 {
+				roleName = args[0]
+			}
+			rightsizeOptions := commands.RightsizeOptions{
+				FilterOptions: commands.FilterOptions{
+					Days:        rightsizeDays,
+					PathPrefix:  pathPrefix,
+					NamePattern: namePattern,
+				},
+				Output: output,
+			}
+
+			rightsizeCmd := commands.NewRightsizeCommand(profile, region, roleName, rightsizeOptions)
+			return rightsizeCmd.Execute(context.Background())
+		},
+	}
+	rightsizeCmd.Flags().IntVarP(&rightsizeDays, "days", "d", 90, "Consider services unused if not accessed in this many days")
+	commands.AddSelectionFlags(rightsizeCmd, &pathPrefix, &namePattern)
+	rightsizeCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json)")
+
+	return rightsizeCmd
+}
+
+// createLeastPrivilegeCommand sets up the least-privilege command and its restore subcommand
+func createLeastPrivilegeCommand() *cobra.Command {
+	var leastPrivilegeDays int
+	var policyName string
+	var policyFile string
+	var fileFormat string
+	var apply bool
+	leastPrivilegeCmd := &cobra.Command{
+		Use:   "least-privilege [role-name]",
+		Short: "Generate a least-privilege replacement policy from service last accessed data",
+		Long: `Generate a policy for the role that keeps only the services and tracked actions
+it accessed in --days days, and show a diff against its current permissions.
+With --file, write the proposed policy as JSON or as a Terraform
+aws_iam_role_policy resource. With --apply, put the proposed policy as an inline
+policy and remove the role's other policies, after backing them up.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			leastPrivilegeOptions := commands.LeastPrivilegeOptions{
+				Days:       leastPrivilegeDays,
+				PolicyName: policyName,
+				File:       policyFile,
+				FileFormat: fileFormat,
+				Apply:      apply,
+				DryRun:     dryRun,
+				Force:      force,
+				BackupDir:  backupDir,
+			}
+
+			leastPrivilegeCmd := commands.NewLeastPrivilegeCommand(profile, region, args[0], leastPrivilegeOptions)
+			return leastPrivilegeCmd.Execute(context.Background())
+		},
+	}
+	leastPrivilegeCmd.Flags().IntVarP(&leastPrivilegeDays, "days", "d", 90, "Keep services accessed in this many days")
+	leastPrivilegeCmd.Flags().StringVar(&policyName, "policy-name", commands.DefaultLeastPrivilegePolicyName, "Name of the generated inline policy")
+	leastPrivilegeCmd.Flags().StringVar(&policyFile, "file", "", "Write the proposed policy to this file")
+	leastPrivilegeCmd.Flags().StringVar(&fileFormat, "format", "", "File format: json or terraform (default: terraform for .tf files, json otherwise)")
+	leastPrivilegeCmd.Flags().BoolVar(&apply, "apply", false, "Replace the role's policies with the proposed policy")
+	commands.AddModifyFlags(leastPrivilegeCmd, &dryRun, &force, &backupDir)
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore [backup-file...]",
+		Short: "Restore role policies from backups written by least-privilege --apply",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			restoreOptions := commands.DeleteOptions{
+				DryRun: dryRun,
+				Force:  force,
+			}
+
+			leastPrivilegeRestoreCmd := commands.NewLeastPrivilegeRestoreCommand(profile, region, args, restoreOptions)
+			return leastPrivilegeRestoreCmd.Execute(context.Background())
+		},
+	}
+	commands.AddRestoreFlags(restoreCmd, &dryRun, &force)
+
+	leastPrivilegeCmd.AddCommand(restoreCmd)
+
+	return leastPrivilegeCmd
+}
+
+// createDuplicatesCommand sets up the duplicates command
+func createDuplicatesCommand() *cobra.Command {
+	var duplicatesDays int
+	duplicatesCmd := &cobra.Command{
+		Use:   "duplicates",
+		Short: "Group roles with the same trust policy and permissions",
+		Long: `Normalize the trust policy, attached managed policies, inline policies and
+permissions boundary of every role and group the roles that are identical, or
+that differ only by principal or resource ARNs. Each group shows when its roles
+were last used and suggests one survivor to keep, so the others can be pruned.
+Roles not used in --days days are marked unused.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			duplicatesOptions := commands.DuplicatesOptions{
+				FilterOptions: commands.FilterOptions{
+					Days:        duplicatesDays,
+					PathPrefix:  pathPrefix,
+					NamePattern: namePattern,
+				},
+				Inventory: inventory,
+				Output:    output,
+			}
+
+			duplicatesCmd := commands.NewDuplicatesCommand(profile, region, duplicatesOptions)
+			return duplicatesCmd.Execute(context.Background())
+		},
+	}
+	duplicatesCmd.Flags().IntVarP(&duplicatesDays, "days", "d", 90, "Consider roles unused if not used in this many days")
+	commands.AddSelectionFlags(duplicatesCmd, &pathPrefix, &namePattern)
+	commands.AddInventoryFlag(duplicatesCmd, &inventory)
+	duplicatesCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json)")
+
+	return duplicatesCmd
+}
+
+// createDiffRolesCommand sets up the diff-roles command
+func createDiffRolesCommand() *cobra.Command {
+	var otherProfile, otherRegion, otherInventory string
+	diffRolesCmd := &cobra.Command{
+		Use:   "diff-roles <role-a> <role-b>",
+		Short: "Compare the configuration of two roles",
+		Long: `Compare the trust policy, attached managed policies, inline policy statements,
+tags, permissions boundary and maximum session duration of two roles, given by
+name or ARN. Policy documents are canonicalized first, so statement IDs, policy
+names and the order of statements and values do not show as differences.
+
+The second role is read from the same account unless --other-profile,
+--other-region or --other-inventory is given, for example to check that staging
+and production roles match.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			diffRolesOptions := commands.DiffRolesOptions{
+				Inventory:      inventory,
+				OtherProfile:   otherProfile,
+				OtherRegion:    otherRegion,
+				OtherInventory: otherInventory,
+				Output:         output,
+			}
+
+			diffRolesCmd := commands.NewDiffRolesCommand(profile, region, args[0], args[1], diffRolesOptions)
+			return diffRolesCmd.Execute(context.Background())
+		},
+	}
+	commands.AddInventoryFlag(diffRolesCmd, &inventory)
+	diffRolesCmd.Flags().StringVar(&otherProfile, "other-profile", "", "AWS profile to read the second role from")
+	diffRolesCmd.Flags().StringVar(&otherRegion, "other-region", "", "AWS region to read the second role from")
+	diffRolesCmd.Flags().StringVar(&otherInventory, "other-inventory", "", "Read the second role from a saved inventory file")
+	diffRolesCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json)")
+
+	return diffRolesCmd
+}
+
+// createGraphCommand sets up the graph command
+func createGraphCommand() *cobra.Command {
+	var graphDays int
+	var graphOutput string
+	graphCmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Export the graph of who can assume which role",
+		Long: `Build a directed graph of who can assume which role. Edges come from the
+principals of each trust policy (services, accounts, federated providers and
+other roles) and from identity policies granting sts:AssumeRole on role ARNs.
+Roles not used in --days days are drawn dashed.
+
+Output is Graphviz DOT, a Mermaid flowchart, a table of edges or JSON.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			graphOptions := commands.GraphOptions{
+				Days:      graphDays,
+				Inventory: inventory,
+				Output:    graphOutput,
+			}
+
+			graphCmd := commands.NewGraphCommand(profile, region, graphOptions)
+			return graphCmd.Execute(context.Background())
+		},
+	}
+	graphCmd.Flags().IntVarP(&graphDays, "days", "d", 90, "Consider roles unused if not used in this many days")
+	commands.AddInventoryFlag(graphCmd, &inventory)
+	graphCmd.Flags().StringVarP(&graphOutput, "output", "o", "dot", "Output format (dot, mermaid, table, json)")
+
+	return graphCmd
+}
+
+// createExportCommand sets up the export command
+func createExportCommand() *cobra.Command {
+	var exportDays int
+	var exportFormat string
+	var unmanaged bool
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export roles as Terraform or CloudFormation code",
+		Long: `Render the selected roles with their trust policy, inline policies, attached
+managed policies, permissions boundary and tags as infrastructure code, so that
+unmanaged roles can be adopted. Terraform output has aws_iam_role,
+aws_iam_role_policy and aws_iam_role_policy_attachment resources with import
+blocks; CloudFormation output is a YAML template for a resource import.
+
+Roles are selected with the same filters as list. --unmanaged leaves out roles
+created by CloudFormation and, with --tfstate, roles declared in Terraform state.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exportOptions := commands.ExportOptions{
+				FilterOptions: commands.FilterOptions{
+					Days:        exportDays,
+					OnlyUsed:    onlyUsed,
+					OnlyUnused:  onlyUnused,
+					PathPrefix:  pathPrefix,
+					NamePattern: namePattern,
+				},
+				Inventory:      inventory,
+				Format:         exportFormat,
+				Unmanaged:      unmanaged,
+				TerraformState: tfstate,
+			}
+
+			exportCmd := commands.NewExportCommand(profile, region, exportOptions)
+			return exportCmd.Execute(context.Background())
+		},
+	}
+	commands.AddFilterFlags(exportCmd, &exportDays, &onlyUsed, &onlyUnused)
+	commands.AddSelectionFlags(exportCmd, &pathPrefix, &namePattern)
+	commands.AddInventoryFlag(exportCmd, &inventory)
+	exportCmd.Flags().StringVar(&exportFormat, "format", "terraform", "Output format (terraform, cloudformation)")
+	exportCmd.Flags().BoolVar(&unmanaged, "unmanaged", false, "Only export roles not managed by a CloudFormation stack or the Terraform state given with --tfstate")
+	commands.AddTerraformFlag(exportCmd, &tfstate)
+
+	return exportCmd
+}
+
+func createCopyRoleCommand() *cobra.Command {
+	var toProfile, toRegion string
+	var accountMap []string
+	copyRoleCmd := &cobra.Command{
+		Use:   "copy-role <role>",
+		Short: "Recreate a role in another account",
+		Long: `Create a role in the account of --to-profile with the same trust policy, inline
+policies, tags, permissions boundary and instance profiles as the given role.
+Attached AWS managed policies are attached as they are; customer managed
+policies are created in the destination unless a policy with the same name and
+document already exists there.
+
+Account IDs in documents and policy ARNs are rewritten with --account-map
+source=destination pairs, and the role's own account maps to the destination
+account. Anything that cannot be mapped is reported, such as accounts without a
+mapping, policies that differ in the destination and tags reserved by AWS.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			copyRoleOptions := commands.CopyRoleOptions{
+				Inventory:  inventory,
+				ToProfile:  toProfile,
+				ToRegion:   toRegion,
+				AccountMap: accountMap,
+				DryRun:     dryRun,
+				Force:      force,
+			}
+
+			copyRoleCmd := commands.NewCopyRoleCommand(profile, region, args[0], copyRoleOptions)
+			return copyRoleCmd.Execute(context.Background())
+		},
+	}
+	commands.AddInventoryFlag(copyRoleCmd, &inventory)
+	copyRoleCmd.Flags().StringVar(&toProfile, "to-profile", "", "AWS profile of the account to create the role in")
+	copyRoleCmd.Flags().StringVar(&toRegion, "to-region", "", "AWS region to use for the destination account")
+	copyRoleCmd.Flags().StringArrayVar(&accountMap, "account-map", nil, "Rewrite an account ID as source=destination (repeatable)")
+	copyRoleCmd.Flags().BoolVar(&dryRun, "dry-run", true, "Show what would be created without making changes")
+	copyRoleCmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation prompts")
+
+	return copyRoleCmd
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// TestFlagDefaults checks that every flag still holds its own default once
+// all commands are registered. Commands share flag variables, and a flag
+// registered later with another default overwrites the value of the others.
+func TestFlagDefaults(t *testing.T) {
+	rootCmd := createRootCommand()
+
+	var check func(cmd *cobra.Command)
+	check = func(cmd *cobra.Command) {
+		cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+			if flag.Value.String() != flag.DefValue {
+				t.Errorf("%s --%s: expected the default %q, got %q", cmd.CommandPath(), flag.Name, flag.DefValue, flag.Value.String())
+			}
+		})
+		for _, sub := range cmd.Commands() {
+			check(sub)
+		}
+	}
+	check(rootCmd)
+
+	for _, path := range [][]string{{"list"}, {"who-can"}, {"lint"}} {
+		cmd, _, err := rootCmd.Find(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value := cmd.Flags().Lookup("output").Value.String(); value != "table" {
+			t.Errorf("expected %s to output a table by default, got %q", cmd.CommandPath(), value)
+		}
+	}
+}
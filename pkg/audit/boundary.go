@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"sort"
+	"strings"
+
+	"hawkling/pkg/aws"
+)
+
+// Boundary compliance statuses
+const (
+	BoundaryMissing = "missing"
+	BoundaryWrong   = "wrong"
+)
+
+// BoundaryRequirement requires roles under a path to use a permissions boundary
+type BoundaryRequirement struct {
+	PathPrefix string
+	Boundary   string
+}
+
+// BoundaryViolation is a role that does not use its required boundary
+type BoundaryViolation struct {
+	RoleName string
+	RoleArn  string
+	Path     string
+	Status   string
+	Current  string `json:",omitempty"`
+	Required string
+}
+
+// RequiredBoundary returns the boundary required for a role path. When
+// several requirements match, the longest path prefix wins.
+func RequiredBoundary(requirements []BoundaryRequirement, rolePath string) (string, bool) {
+	best := -1
+	for i, requirement := range requirements {
+		if !strings.HasPrefix(rolePath, requirement.PathPrefix) {
+			continue
+		}
+		if best < 0 || len(requirement.PathPrefix) > len(requirements[best].PathPrefix) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return "", false
+	}
+	return requirements[best].Boundary, true
+}
+
+// CheckBoundaryCompliance returns the roles that are missing their required
+// permissions boundary or use a different one, sorted by role name
+func CheckBoundaryCompliance(roles []aws.Role, requirements []BoundaryRequirement) []BoundaryViolation {
+	var violations []BoundaryViolation
+	for _, role := range roles {
+		required, ok := RequiredBoundary(requirements, role.Path)
+		if !ok || role.PermissionsBoundary == required {
+			continue
+		}
+
+		status := BoundaryWrong
+		if role.PermissionsBoundary == "" {
+			status = BoundaryMissing
+		}
+
+		violations = append(violations, BoundaryViolation{
+			RoleName: role.Name,
+			RoleArn:  role.Arn,
+			Path:     role.Path,
+			Status:   status,
+			Current:  role.PermissionsBoundary,
+			Required: required,
+		})
+	}
+
+	sort.Slice(violations, func(i, j int) bool {
+		return violations[i].RoleName < violations[j].RoleName
+	})
+
+	return violations
+}
@@ -0,0 +1,161 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/policy"
+)
+
+// PolicyCopy is a customer managed policy that a copied role needs in the
+// destination account
+type PolicyCopy struct {
+	SourceArn string
+
+	// Arn is the ARN the policy has in the destination account
+	Arn      string
+	Name     string
+	Path     string
+	Document string
+}
+
+// RoleCopy is the plan to recreate a role in another account
+type RoleCopy struct {
+	// Role is the role to create, with account IDs rewritten in its
+	// documents and policy ARNs
+	Role aws.Role
+
+	// Policies are the customer managed policies the role attaches or uses
+	// as permissions boundary
+	Policies []PolicyCopy
+
+	// Unmapped describes what could not be carried over as is
+	Unmapped []string
+}
+
+// PlanRoleCopy plans recreating a role of an inventory in another account.
+// Account IDs in the trust policy, inline policies, customer managed
+// policies and permissions boundary are rewritten by mapping, which should
+// map the role's own account to the destination account. AWS managed
+// policies are attached as they are. Customer managed policies whose
+// account has no mapping or whose document is not in the inventory are
+// left out, and tags reserved by AWS are not copied; all of these are
+// reported as unmapped.
+func PlanRoleCopy(inventory *aws.Inventory, role aws.Role, mapping map[string]string) *RoleCopy {
+	plan := &RoleCopy{}
+	rewrite := func(what, text string) string {
+		rewritten, unmapped := policy.RewriteAccounts(text, mapping)
+		for _, account := range unmapped {
+			plan.Unmapped = append(plan.Unmapped, fmt.Sprintf("account %s in %s has no mapping and was kept", account, what))
+		}
+		return rewritten
+	}
+
+	copied := aws.Role{
+		Name:               role.Name,
+		Path:               role.Path,
+		Description:        role.Description,
+		MaxSessionDuration: role.MaxSessionDuration,
+		TrustPolicy:        rewrite("the trust policy", role.TrustPolicy),
+		InstanceProfiles:   role.InstanceProfiles,
+	}
+	copied.Arn, _ = policy.RewriteAccounts(role.Arn, mapping)
+
+	for _, inline := range role.InlinePolicies {
+		copied.InlinePolicies = append(copied.InlinePolicies, aws.Policy{
+			Name:     inline.Name,
+			IsInline: true,
+			Document: rewrite("inline policy "+inline.Name, inline.Document),
+		})
+	}
+
+	keys := make([]string, 0, len(role.Tags))
+	for key := range role.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if strings.HasPrefix(key, "aws:") {
+			plan.Unmapped = append(plan.Unmapped, fmt.Sprintf("tag %s is reserved by AWS and was not copied", key))
+			continue
+		}
+		if copied.Tags == nil {
+			copied.Tags = make(map[string]string)
+		}
+		copied.Tags[key] = role.Tags[key]
+	}
+
+	planned := make(map[string]string)
+	copyManaged := func(arn string) (string, bool) {
+		if policy.AccountFromARN(arn) == "aws" {
+			return arn, true
+		}
+		if target, ok := planned[arn]; ok {
+			return target, true
+		}
+
+		target, unmapped := policy.RewriteAccounts(arn, mapping)
+		if len(unmapped) > 0 {
+			plan.Unmapped = append(plan.Unmapped, fmt.Sprintf("policy %s is in account %s, which has no mapping, and was not copied", arn, unmapped[0]))
+			return "", false
+		}
+		managed, ok := inventory.Policies[arn]
+		if !ok || managed.Document == "" {
+			plan.Unmapped = append(plan.Unmapped, fmt.Sprintf("policy %s has no document in the inventory and was not copied", arn))
+			return "", false
+		}
+
+		path, name := policyPathAndName(arn)
+		plan.Policies = append(plan.Policies, PolicyCopy{
+			SourceArn: arn,
+			Arn:       target,
+			Name:      name,
+			Path:      path,
+			Document:  rewrite("policy "+name, managed.Document),
+		})
+		planned[arn] = target
+		return target, true
+	}
+
+	for _, attached := range role.AttachedPolicies {
+		if target, ok := copyManaged(attached.Arn); ok {
+			copied.AttachedPolicies = append(copied.AttachedPolicies, aws.Policy{Name: attached.Name, Arn: target})
+		}
+	}
+	if role.PermissionsBoundary != "" {
+		if target, ok := copyManaged(role.PermissionsBoundary); ok {
+			copied.PermissionsBoundary = target
+		}
+	}
+
+	plan.Role = copied
+	return plan
+}
+
+// SameDocument reports whether two policy documents grant the same
+// permissions, ignoring their layout
+func SameDocument(a, b string) bool {
+	docA, errA := policy.Parse(a)
+	docB, errB := policy.Parse(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+
+	encodedA, errA := json.Marshal(docA.Canonical())
+	encodedB, errB := json.Marshal(docB.Canonical())
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
+
+// policyPathAndName splits the resource of a managed policy ARN, such as
+// policy/team/ReadOnly, into its path and name
+func policyPathAndName(arn string) (string, string) {
+	resource := arn
+	if i := strings.Index(arn, ":policy/"); i >= 0 {
+		resource = arn[i+len(":policy"):]
+	}
+	i := strings.LastIndex(resource, "/")
+	return resource[:i+1], resource[i+1:]
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"sort"
+	"time"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/catalog"
+)
+
+// DeprecatedAttachment is a deprecated AWS managed policy attached to a role
+type DeprecatedAttachment struct {
+	RoleName    string
+	RoleArn     string
+	LastUsed    *time.Time
+	Unused      bool
+	PolicyName  string
+	PolicyArn   string
+	Replacement string `json:",omitempty"`
+	Note        string `json:",omitempty"`
+}
+
+// FindDeprecatedPolicies returns every attachment of a policy listed in the
+// catalog, whether or not the role is still used. Roles not used within
+// days are marked unused. Attachments are sorted by role and policy.
+func FindDeprecatedPolicies(roles []aws.Role, deprecated *catalog.Catalog, days int) []DeprecatedAttachment {
+	var attachments []DeprecatedAttachment
+	for _, role := range roles {
+		for _, attached := range role.AttachedPolicies {
+			entry, ok := deprecated.Lookup(attached.Arn)
+			if !ok {
+				continue
+			}
+
+			attachments = append(attachments, DeprecatedAttachment{
+				RoleName:    role.Name,
+				RoleArn:     role.Arn,
+				LastUsed:    role.LastUsed,
+				Unused:      role.IsUnused(days),
+				PolicyName:  attached.Name,
+				PolicyArn:   attached.Arn,
+				Replacement: entry.Replacement,
+				Note:        entry.Note,
+			})
+		}
+	}
+
+	sort.SliceStable(attachments, func(i, j int) bool {
+		if attachments[i].RoleName != attachments[j].RoleName {
+			return attachments[i].RoleName < attachments[j].RoleName
+		}
+		return attachments[i].PolicyArn < attachments[j].PolicyArn
+	})
+
+	return attachments
+}
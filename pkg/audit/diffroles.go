@@ -0,0 +1,258 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/diff"
+	"hawkling/pkg/policy"
+)
+
+// RoleSnapshot is the comparable configuration of a role. Policy documents
+// are canonicalized and inline statements are merged across inline
+// policies, so layout, statement IDs and policy names do not show as
+// differences.
+type RoleSnapshot struct {
+	TrustPolicy         *policy.Document  `json:",omitempty"`
+	AttachedPolicies    []string          `json:",omitempty"`
+	InlineStatements    policy.Statements `json:",omitempty"`
+	PermissionsBoundary string            `json:",omitempty"`
+	MaxSessionDuration  int32             `json:",omitempty"`
+	Tags                map[string]string `json:",omitempty"`
+}
+
+// ListChange lists the values only present in one of two roles
+type ListChange struct {
+	Removed []string `json:",omitempty"`
+	Added   []string `json:",omitempty"`
+}
+
+// StatementChange lists the inline statements only present in one of two
+// roles
+type StatementChange struct {
+	Removed policy.Statements `json:",omitempty"`
+	Added   policy.Statements `json:",omitempty"`
+}
+
+// ValueChange is a setting that differs between two roles
+type ValueChange struct {
+	From string
+	To   string
+}
+
+// RoleDiff compares two roles. Removed values are those of the From role
+// missing from the To role.
+type RoleDiff struct {
+	From  string
+	To    string
+	Equal bool
+
+	TrustPolicyChanged  bool             `json:",omitempty"`
+	AttachedPolicies    *ListChange      `json:",omitempty"`
+	InlineStatements    *StatementChange `json:",omitempty"`
+	Tags                *ListChange      `json:",omitempty"`
+	PermissionsBoundary *ValueChange     `json:",omitempty"`
+	MaxSessionDuration  *ValueChange     `json:",omitempty"`
+
+	// Unified is a unified diff of the two role snapshots
+	Unified string `json:",omitempty"`
+}
+
+// Snapshot returns the comparable configuration of a role. The account ID
+// of the role is replaced with a placeholder wherever it appears, so roles
+// in different accounts that only refer to their own account compare
+// equal.
+func Snapshot(role aws.Role) (RoleSnapshot, error) {
+	account := policy.AccountFromARN(role.Arn)
+	ownAccount := func(text string) string {
+		if account == "" {
+			return text
+		}
+		rewritten, _ := policy.RewriteAccounts(text, map[string]string{account: accountPlaceholder})
+		return rewritten
+	}
+	parse := func(document string) (*policy.Document, error) {
+		doc, err := policy.Parse(document)
+		if err != nil {
+			return nil, err
+		}
+		// Rewrite the decoded document, as encoded ones hide the digits
+		text, err := doc.JSON()
+		if err != nil {
+			return nil, err
+		}
+		return policy.Parse(ownAccount(text))
+	}
+
+	snapshot := RoleSnapshot{
+		PermissionsBoundary: ownAccount(role.PermissionsBoundary),
+		MaxSessionDuration:  role.MaxSessionDuration,
+		Tags:                role.Tags,
+	}
+
+	if role.TrustPolicy != "" {
+		trust, err := parse(role.TrustPolicy)
+		if err != nil {
+			return RoleSnapshot{}, fmt.Errorf("trust policy: %w", err)
+		}
+		snapshot.TrustPolicy = trust.Canonical()
+	}
+
+	for _, attached := range role.AttachedPolicies {
+		snapshot.AttachedPolicies = append(snapshot.AttachedPolicies, ownAccount(attached.Arn))
+	}
+	sort.Strings(snapshot.AttachedPolicies)
+
+	merged := &policy.Document{}
+	for _, inline := range role.InlinePolicies {
+		doc, err := parse(inline.Document)
+		if err != nil {
+			return RoleSnapshot{}, fmt.Errorf("inline policy %s: %w", inline.Name, err)
+		}
+		merged.Statement = append(merged.Statement, doc.Statement...)
+	}
+	snapshot.InlineStatements = merged.Canonical().Statement
+
+	return snapshot, nil
+}
+
+// DiffRoles compares two roles, labelled from and to in the unified diff
+func DiffRoles(from, to string, a, b aws.Role) (*RoleDiff, error) {
+	snapshotA, err := Snapshot(a)
+	if err != nil {
+		return nil, fmt.Errorf("role %s: %w", a.Name, err)
+	}
+	snapshotB, err := Snapshot(b)
+	if err != nil {
+		return nil, fmt.Errorf("role %s: %w", b.Name, err)
+	}
+
+	textA, err := snapshotA.JSON()
+	if err != nil {
+		return nil, err
+	}
+	textB, err := snapshotB.JSON()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RoleDiff{
+		From:    from,
+		To:      to,
+		Equal:   textA == textB,
+		Unified: diff.Unified(from, to, textA, textB),
+	}
+	if result.Equal {
+		return result, nil
+	}
+
+	trustA, _ := json.Marshal(snapshotA.TrustPolicy)
+	trustB, _ := json.Marshal(snapshotB.TrustPolicy)
+	result.TrustPolicyChanged = !bytes.Equal(trustA, trustB)
+
+	result.AttachedPolicies = compareLists(snapshotA.AttachedPolicies, snapshotB.AttachedPolicies)
+	result.Tags = compareLists(tagList(snapshotA.Tags), tagList(snapshotB.Tags))
+	result.InlineStatements = compareStatements(snapshotA.InlineStatements, snapshotB.InlineStatements)
+
+	if snapshotA.PermissionsBoundary != snapshotB.PermissionsBoundary {
+		result.PermissionsBoundary = &ValueChange{From: snapshotA.PermissionsBoundary, To: snapshotB.PermissionsBoundary}
+	}
+	if snapshotA.MaxSessionDuration != snapshotB.MaxSessionDuration {
+		result.MaxSessionDuration = &ValueChange{
+			From: fmt.Sprintf("%ds", snapshotA.MaxSessionDuration),
+			To:   fmt.Sprintf("%ds", snapshotB.MaxSessionDuration),
+		}
+	}
+
+	return result, nil
+}
+
+// JSON returns the snapshot as indented JSON, with map keys sorted so that
+// equal snapshots render equally
+func (s RoleSnapshot) JSON() (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(s); err != nil {
+		return "", fmt.Errorf("failed to encode role snapshot: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// compareLists returns the values only in a or only in b, or nil if the
+// sorted lists are equal
+func compareLists(a, b []string) *ListChange {
+	inA := make(map[string]bool, len(a))
+	for _, value := range a {
+		inA[value] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, value := range b {
+		inB[value] = true
+	}
+
+	change := &ListChange{}
+	for _, value := range a {
+		if !inB[value] {
+			change.Removed = append(change.Removed, value)
+		}
+	}
+	for _, value := range b {
+		if !inA[value] {
+			change.Added = append(change.Added, value)
+		}
+	}
+
+	if len(change.Removed) == 0 && len(change.Added) == 0 {
+		return nil
+	}
+	return change
+}
+
+// compareStatements returns the canonical statements only in a or only in b
+func compareStatements(a, b policy.Statements) *StatementChange {
+	keyed := func(statements policy.Statements) map[string]bool {
+		keys := make(map[string]bool, len(statements))
+		for _, stmt := range statements {
+			encoded, _ := json.Marshal(stmt)
+			keys[string(encoded)] = true
+		}
+		return keys
+	}
+	inA, inB := keyed(a), keyed(b)
+
+	change := &StatementChange{}
+	for _, stmt := range a {
+		encoded, _ := json.Marshal(stmt)
+		if !inB[string(encoded)] {
+			change.Removed = append(change.Removed, stmt)
+		}
+	}
+	for _, stmt := range b {
+		encoded, _ := json.Marshal(stmt)
+		if !inA[string(encoded)] {
+			change.Added = append(change.Added, stmt)
+		}
+	}
+
+	if len(change.Removed) == 0 && len(change.Added) == 0 {
+		return nil
+	}
+	return change
+}
+
+// tagList renders tags as sorted key=value strings
+func tagList(tags map[string]string) []string {
+	list := make([]string, 0, len(tags))
+	for key, value := range tags {
+		list = append(list, key+"="+value)
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,240 @@
+package audit
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/policy"
+)
+
+// Kinds of duplicate groups
+const (
+	// DuplicateIdentical groups roles with the same trust and permissions
+	DuplicateIdentical = "identical"
+
+	// DuplicateSimilar groups roles that differ only by principal or
+	// resource ARNs
+	DuplicateSimilar = "similar"
+)
+
+// DuplicateRole is a member of a duplicate group with its usage data
+type DuplicateRole struct {
+	RoleName   string
+	RoleArn    string
+	CreateDate time.Time
+	LastUsed   *time.Time
+	Unused     bool
+
+	// Set numbers the identical subsets of a similar group from 1: roles
+	// with the same set are identical to each other. It is zero in
+	// identical groups.
+	Set int `json:",omitempty"`
+}
+
+// DuplicateGroup is a set of roles that could be consolidated into one
+type DuplicateGroup struct {
+	Kind string
+
+	// Survivor is the suggested role to keep: the most recently used one,
+	// or the oldest if none was used
+	Survivor string
+
+	// Roles starts with the survivor, followed by the most recently used
+	Roles []DuplicateRole
+}
+
+// accountPlaceholder replaces account IDs that do not matter in a comparison
+const accountPlaceholder = "<account>"
+
+// roleFingerprint is the normalized trust and permissions of a role
+type roleFingerprint struct {
+	Trust               *policy.Document
+	AttachedPolicies    []string
+	InlinePolicies      []string
+	PermissionsBoundary string
+}
+
+// FindDuplicates groups roles whose trust policy and policies are the same
+// once normalized. Roles that also match with principal and resource ARNs
+// masked form similar groups, whose roles are numbered by identical subset.
+// Roles not used within days are marked unused. Groups are sorted by size,
+// largest first.
+func FindDuplicates(roles []aws.Role, days int) ([]DuplicateGroup, []SkippedRole) {
+	var skipped []SkippedRole
+	exact := make(map[string]string, len(roles))
+	groups := make(map[string][]aws.Role)
+	var order []string
+
+	for _, role := range roles {
+		exactKey, err := fingerprint(role, false)
+		if err != nil {
+			skipped = append(skipped, SkippedRole{RoleName: role.Name, Reason: err.Error()})
+			continue
+		}
+		maskedKey, err := fingerprint(role, true)
+		if err != nil {
+			skipped = append(skipped, SkippedRole{RoleName: role.Name, Reason: err.Error()})
+			continue
+		}
+
+		exact[role.Name] = exactKey
+		if _, ok := groups[maskedKey]; !ok {
+			order = append(order, maskedKey)
+		}
+		groups[maskedKey] = append(groups[maskedKey], role)
+	}
+
+	var result []DuplicateGroup
+	for _, key := range order {
+		members := groups[key]
+		if len(members) < 2 {
+			continue
+		}
+
+		group := DuplicateGroup{Kind: DuplicateIdentical}
+		for _, role := range members {
+			if exact[role.Name] != exact[members[0].Name] {
+				group.Kind = DuplicateSimilar
+			}
+			group.Roles = append(group.Roles, DuplicateRole{
+				RoleName:   role.Name,
+				RoleArn:    role.Arn,
+				CreateDate: role.CreateDate,
+				LastUsed:   role.LastUsed,
+				Unused:     role.IsUnused(days),
+			})
+		}
+
+		sort.SliceStable(group.Roles, func(i, j int) bool {
+			return survivesOver(group.Roles[i], group.Roles[j])
+		})
+		group.Survivor = group.Roles[0].RoleName
+
+		// Number the identical subsets, starting with the survivor's
+		if group.Kind == DuplicateSimilar {
+			sets := make(map[string]int)
+			for i := range group.Roles {
+				key := exact[group.Roles[i].RoleName]
+				if _, ok := sets[key]; !ok {
+					sets[key] = len(sets) + 1
+				}
+				group.Roles[i].Set = sets[key]
+			}
+		}
+		result = append(result, group)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if len(result[i].Roles) != len(result[j].Roles) {
+			return len(result[i].Roles) > len(result[j].Roles)
+		}
+		return result[i].Survivor < result[j].Survivor
+	})
+
+	return result, skipped
+}
+
+// survivesOver reports whether role a is a better survivor than role b:
+// used more recently, or older if neither was used
+func survivesOver(a, b DuplicateRole) bool {
+	switch {
+	case a.LastUsed != nil && b.LastUsed != nil && !a.LastUsed.Equal(*b.LastUsed):
+		return a.LastUsed.After(*b.LastUsed)
+	case a.LastUsed != nil && b.LastUsed == nil:
+		return true
+	case a.LastUsed == nil && b.LastUsed != nil:
+		return false
+	case !a.CreateDate.Equal(b.CreateDate):
+		return a.CreateDate.Before(b.CreateDate)
+	}
+	return a.RoleName < b.RoleName
+}
+
+// fingerprint returns the normalized trust and permissions of a role as a
+// string. With mask, ARNs and account IDs in principals and resources are
+// reduced to their partition and service.
+func fingerprint(role aws.Role, mask bool) (string, error) {
+	normalize := func(document string) (*policy.Document, error) {
+		doc, err := policy.Parse(document)
+		if err != nil {
+			return nil, err
+		}
+		if mask {
+			doc = maskDocument(doc)
+		}
+		return doc.Canonical(), nil
+	}
+
+	var print roleFingerprint
+	if role.TrustPolicy != "" {
+		trust, err := normalize(role.TrustPolicy)
+		if err != nil {
+			return "", err
+		}
+		print.Trust = trust
+	}
+
+	for _, attached := range role.AttachedPolicies {
+		print.AttachedPolicies = append(print.AttachedPolicies, attached.Arn)
+	}
+	sort.Strings(print.AttachedPolicies)
+
+	for _, inline := range role.InlinePolicies {
+		doc, err := normalize(inline.Document)
+		if err != nil {
+			return "", err
+		}
+		encoded, err := json.Marshal(doc)
+		if err != nil {
+			return "", err
+		}
+		print.InlinePolicies = append(print.InlinePolicies, string(encoded))
+	}
+	sort.Strings(print.InlinePolicies)
+
+	print.PermissionsBoundary = role.PermissionsBoundary
+
+	encoded, err := json.Marshal(print)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// maskDocument returns a copy of the document with the ARNs and account IDs
+// of its principals and resources masked
+func maskDocument(doc *policy.Document) *policy.Document {
+	masked := doc.Clone()
+	for i := range masked.Statement {
+		stmt := &masked.Statement[i]
+		maskValues(stmt.Resource)
+		maskValues(stmt.NotResource)
+		for _, principal := range []*policy.Principal{stmt.Principal, stmt.NotPrincipal} {
+			if principal == nil {
+				continue
+			}
+			for _, values := range principal.Entries {
+				maskValues(values)
+			}
+		}
+	}
+	return masked
+}
+
+// maskValues masks ARNs and account IDs in place
+func maskValues(values policy.StringList) {
+	for i, value := range values {
+		switch {
+		case policy.IsAccountID(value):
+			values[i] = accountPlaceholder
+		case strings.HasPrefix(value, "arn:"):
+			parts := strings.SplitN(value, ":", 6)
+			if len(parts) == 6 {
+				values[i] = strings.Join(parts[:3], ":") + ":*"
+			}
+		}
+	}
+}
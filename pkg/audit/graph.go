@@ -0,0 +1,243 @@
+package audit
+
+import (
+	"sort"
+	"strings"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/policy"
+)
+
+// Kinds of nodes in a role graph
+const (
+	NodeRole      = "role"
+	NodeService   = "service"
+	NodeAccount   = "account"
+	NodeFederated = "federated"
+	NodePrincipal = "principal"
+	NodePublic    = "public"
+)
+
+// assumeActions are the STS actions that assume a role
+var assumeActions = []string{"sts:AssumeRole", "sts:AssumeRoleWithWebIdentity", "sts:AssumeRoleWithSAML"}
+
+// GraphNode is a role or a principal that can assume one
+type GraphNode struct {
+	ID    string
+	Label string
+	Kind  string
+
+	// Unused is set for roles not used within the graph's days
+	Unused bool `json:",omitempty"`
+}
+
+// GraphEdge links a principal to a role it can assume
+type GraphEdge struct {
+	From string
+	To   string
+
+	// Trust is set when the trust policy of the target allows the source
+	Trust bool
+
+	// Identity is set when the identity policies of a source role grant
+	// sts:AssumeRole on the target role's ARN
+	Identity bool
+
+	// Conditional is set when the trust policy only allows the source
+	// under conditions
+	Conditional bool `json:",omitempty"`
+}
+
+// RoleGraph is a directed graph of who can assume which role
+type RoleGraph struct {
+	Nodes []GraphNode
+	Edges []GraphEdge
+}
+
+// RoleChain is a role that other roles assume and that assumes other roles
+// in turn, so deleting it breaks the chains through it
+type RoleChain struct {
+	RoleName string
+	From     []string
+	To       []string
+}
+
+// BuildRoleGraph builds the graph of who can assume the roles of an
+// inventory. Edges into a role come from the principals of its trust
+// policy and from the roles whose identity policies grant sts:AssumeRole
+// on its ARN. Roles not used within days are marked unused. Roles whose
+// policies cannot be read are returned as skipped; their trust policy
+// edges are still included when it parses.
+func BuildRoleGraph(inventory *aws.Inventory, days int) (*RoleGraph, []SkippedRole) {
+	var skipped []SkippedRole
+	graph := &RoleGraph{}
+	nodes := make(map[string]bool)
+	edges := make(map[[2]string]int)
+
+	addNode := func(node GraphNode) {
+		if !nodes[node.ID] {
+			nodes[node.ID] = true
+			graph.Nodes = append(graph.Nodes, node)
+		}
+	}
+	addEdge := func(from, to string) *GraphEdge {
+		key := [2]string{from, to}
+		if i, ok := edges[key]; ok {
+			return &graph.Edges[i]
+		}
+		edges[key] = len(graph.Edges)
+		graph.Edges = append(graph.Edges, GraphEdge{From: from, To: to})
+		return &graph.Edges[len(graph.Edges)-1]
+	}
+
+	roleArns := make(map[string]bool, len(inventory.Roles))
+	for _, role := range inventory.Roles {
+		roleArns[role.Arn] = true
+		addNode(GraphNode{ID: role.Arn, Label: role.Name, Kind: NodeRole, Unused: role.IsUnused(days)})
+	}
+
+	trustPolicies := make(map[string]*policy.Document, len(inventory.Roles))
+	for _, role := range inventory.Roles {
+		if role.TrustPolicy == "" {
+			continue
+		}
+		trust, err := policy.Parse(role.TrustPolicy)
+		if err != nil {
+			skipped = append(skipped, SkippedRole{RoleName: role.Name, Reason: err.Error()})
+			continue
+		}
+		trustPolicies[role.Arn] = trust
+
+		for _, stmt := range trust.Statement {
+			if stmt.Effect != policy.EffectAllow || stmt.Principal == nil || !matchesAnyAction(stmt, assumeActions) {
+				continue
+			}
+
+			for _, principalType := range stmt.Principal.Types() {
+				for _, value := range stmt.Principal.Values(principalType) {
+					node := principalNode(principalType, value, roleArns)
+					addNode(node)
+					edge := addEdge(node.ID, role.Arn)
+					edge.Trust = true
+					edge.Conditional = edge.Conditional || len(stmt.Condition) > 0
+				}
+			}
+		}
+	}
+
+	for _, source := range inventory.Roles {
+		policySet, err := inventory.PolicySet(source)
+		if err != nil {
+			skipped = append(skipped, SkippedRole{RoleName: source.Name, Reason: err.Error()})
+			continue
+		}
+
+		for _, target := range inventory.Roles {
+			if target.Arn == source.Arn || !grantsAssumeRole(policySet, target.Arn) {
+				continue
+			}
+			edge := addEdge(source.Arn, target.Arn)
+			edge.Identity = true
+			if trust, ok := trustPolicies[target.Arn]; ok && trustsRole(trust, source.Arn) {
+				edge.Trust = true
+			}
+		}
+	}
+
+	sort.SliceStable(graph.Nodes, func(i, j int) bool {
+		if graph.Nodes[i].Kind != graph.Nodes[j].Kind {
+			return graph.Nodes[i].Kind < graph.Nodes[j].Kind
+		}
+		return graph.Nodes[i].Label < graph.Nodes[j].Label
+	})
+	sort.SliceStable(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+
+	return graph, skipped
+}
+
+// Chains returns the given roles that other roles chain through: roles
+// that another role can assume and that can assume another role
+func (g *RoleGraph) Chains(roles []aws.Role) []RoleChain {
+	names := make(map[string]string)
+	for _, node := range g.Nodes {
+		if node.Kind == NodeRole {
+			names[node.ID] = node.Label
+		}
+	}
+
+	var chains []RoleChain
+	for _, role := range roles {
+		chain := RoleChain{RoleName: role.Name}
+		for _, edge := range g.Edges {
+			if edge.From == edge.To {
+				continue
+			}
+			if _, ok := names[edge.From]; ok && edge.To == role.Arn {
+				chain.From = append(chain.From, names[edge.From])
+			}
+			if _, ok := names[edge.To]; ok && edge.From == role.Arn {
+				chain.To = append(chain.To, names[edge.To])
+			}
+		}
+		if len(chain.From) > 0 && len(chain.To) > 0 {
+			chains = append(chains, chain)
+		}
+	}
+	return chains
+}
+
+// principalNode returns the graph node of a trust policy principal. Role
+// ARNs of the inventory map to their role node.
+func principalNode(principalType, value string, roleArns map[string]bool) GraphNode {
+	switch {
+	case value == "*":
+		return GraphNode{ID: "*", Label: "anyone", Kind: NodePublic}
+	case principalType == policy.PrincipalService:
+		return GraphNode{ID: "service:" + value, Label: value, Kind: NodeService}
+	case principalType == policy.PrincipalFederated:
+		return GraphNode{ID: "federated:" + value, Label: value, Kind: NodeFederated}
+	case roleArns[value]:
+		return GraphNode{ID: value, Kind: NodeRole}
+	case policy.IsAccountID(value):
+		return GraphNode{ID: "account:" + value, Label: "account " + value, Kind: NodeAccount}
+	case strings.HasSuffix(value, ":root") && policy.AccountFromARN(value) != "":
+		account := policy.AccountFromARN(value)
+		return GraphNode{ID: "account:" + account, Label: "account " + account, Kind: NodeAccount}
+	}
+	return GraphNode{ID: value, Label: value, Kind: NodePrincipal}
+}
+
+// grantsAssumeRole reports whether the policies allow sts:AssumeRole on the
+// role ARN through a statement that names role ARNs, rather than only "*"
+func grantsAssumeRole(policySet policy.PolicySet, roleArn string) bool {
+	result := policySet.Evaluate(policy.Request{Action: "sts:AssumeRole", Resource: roleArn})
+	if !result.Allowed() {
+		return false
+	}
+	for _, match := range result.Matches {
+		if match.Effect != policy.EffectAllow || match.SourceType != policy.SourceIdentity {
+			continue
+		}
+		for _, resource := range match.Statement.Resource {
+			if resource != "*" && policy.MatchWildcard(resource, roleArn, false) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesAnyAction reports whether the statement covers any of the actions
+func matchesAnyAction(stmt policy.Statement, actions []string) bool {
+	for _, action := range actions {
+		if stmt.MatchesAction(action) {
+			return true
+		}
+	}
+	return false
+}
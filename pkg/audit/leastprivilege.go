@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/policy"
+)
+
+// policyVersion is the current policy language version
+const policyVersion = "2012-10-17"
+
+// LeastPrivilegePolicy is a proposed replacement for the identity policies
+// of a role, keeping only the services and actions it accessed
+type LeastPrivilegePolicy struct {
+	RoleName string
+
+	// Current merges the statements of every identity policy of the role
+	Current *policy.Document
+
+	// Proposed is the replacement policy
+	Proposed *policy.Document
+
+	// AccessedServices are the service namespaces accessed in the period
+	AccessedServices []string
+
+	// Notes explain where the proposal is broader than the access data
+	Notes []string `json:",omitempty"`
+}
+
+// GenerateLeastPrivilege narrows the Allow statements of the identity
+// policies of a role to the services accessed in the last days days.
+// Statements keep their resources and conditions. Tracked actions that
+// were not used are removed, while wildcards and untracked actions of
+// accessed services are kept because AWS does not report their use. Deny
+// statements are kept unchanged, and so are NotAction statements that
+// exclude only some actions of an accessed service, since no Action list
+// can grant the rest of that service without the excluded actions.
+func GenerateLeastPrivilege(role aws.Role, policySet policy.PolicySet, services []aws.ServiceLastAccessed, days int, now time.Time) *LeastPrivilegePolicy {
+	threshold := now.AddDate(0, 0, -days)
+	accessed := func(lastAccessed *time.Time) bool {
+		return lastAccessed != nil && !lastAccessed.Before(threshold)
+	}
+
+	accessedServices := make(map[string]bool)
+	tracked := make(map[string]bool)
+	usedActions := make(map[string]bool)
+	for _, service := range services {
+		namespace := strings.ToLower(service.ServiceNamespace)
+		if accessed(service.LastAccessed) {
+			accessedServices[namespace] = true
+		}
+		for _, action := range service.Actions {
+			name := strings.ToLower(namespace + ":" + action.ActionName)
+			tracked[name] = true
+			if accessed(action.LastAccessed) {
+				usedActions[name] = true
+			}
+		}
+	}
+
+	namespaces := make([]string, 0, len(accessedServices))
+	for namespace := range accessedServices {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	result := &LeastPrivilegePolicy{
+		RoleName:         role.Name,
+		Current:          &policy.Document{Version: policyVersion, Statement: policy.Statements{}},
+		Proposed:         &policy.Document{Version: policyVersion, Statement: policy.Statements{}},
+		AccessedServices: namespaces,
+	}
+
+	for _, src := range policySet.Identity {
+		for i, stmt := range src.Document.Statement {
+			result.Current.Statement = append(result.Current.Statement, stmt.Clone())
+
+			proposed := stmt.Clone()
+			proposed.Sid = ""
+			if stmt.Effect != policy.EffectAllow {
+				result.Proposed.Statement = append(result.Proposed.Statement, proposed)
+				continue
+			}
+
+			var actions policy.StringList
+			keep := func(action string) {
+				if !actions.Contains(action) {
+					actions = append(actions, action)
+				}
+			}
+
+			if len(stmt.NotAction) > 0 {
+				// Grant the accessed services NotAction leaves whole
+				var partial []string
+				for _, namespace := range namespaces {
+					switch notActionExcludes(stmt.NotAction, namespace) {
+					case excludesNone:
+						keep(namespace + ":*")
+					case excludesSome:
+						partial = append(partial, namespace)
+					}
+				}
+				if len(partial) > 0 {
+					result.Proposed.Statement = append(result.Proposed.Statement, proposed)
+					result.Notes = append(result.Notes, fmt.Sprintf("statement %d of %s uses NotAction excluding some %s actions and was kept unchanged", i, src.Name, strings.Join(partial, ", ")))
+					continue
+				}
+				proposed.NotAction = nil
+				result.Notes = append(result.Notes, fmt.Sprintf("statement %d of %s uses NotAction and was replaced with the accessed services it allows", i, src.Name))
+			}
+
+			for _, action := range stmt.Action {
+				if action == "*" {
+					for _, namespace := range namespaces {
+						keep(namespace + ":*")
+					}
+					continue
+				}
+
+				namespace, _, _ := strings.Cut(strings.ToLower(action), ":")
+				if !accessedServices[namespace] {
+					continue
+				}
+
+				name := strings.ToLower(action)
+				if strings.ContainsAny(action, "*?") {
+					result.Notes = append(result.Notes, fmt.Sprintf("wildcard action %s of %s was kept because not all of its actions are tracked", action, src.Name))
+				} else if tracked[name] && !usedActions[name] {
+					continue
+				}
+				keep(action)
+			}
+
+			if len(actions) == 0 {
+				continue
+			}
+			proposed.Action = actions
+			result.Proposed.Statement = append(result.Proposed.Statement, proposed)
+		}
+	}
+
+	return result
+}
+
+// How much of a service a NotAction list excludes
+const (
+	excludesNone = iota
+	excludesSome
+	excludesAll
+)
+
+// notActionExcludes reports how much of the actions of a service namespace
+// the patterns of a NotAction list exclude
+func notActionExcludes(notAction policy.StringList, namespace string) int {
+	excludes := excludesNone
+	for _, pattern := range notAction {
+		if pattern == "*" {
+			return excludesAll
+		}
+		patternNamespace, action, ok := strings.Cut(pattern, ":")
+		if !ok || !policy.MatchWildcard(patternNamespace, namespace, true) {
+			continue
+		}
+		if action == "*" {
+			return excludesAll
+		}
+		excludes = excludesSome
+	}
+	return excludes
+}
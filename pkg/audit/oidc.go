@@ -0,0 +1,244 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/policy"
+)
+
+// OIDC provider kinds
+const (
+	ProviderKindEKS    = "eks"
+	ProviderKindGitHub = "github"
+	ProviderKindOther  = "other"
+)
+
+// gitHubProvider is the issuer of GitHub Actions OIDC tokens
+const gitHubProvider = "token.actions.githubusercontent.com"
+
+// ServiceAccount identifies a Kubernetes service account
+type ServiceAccount struct {
+	Namespace string
+	Name      string
+}
+
+// String returns the service account as namespace/name
+func (s ServiceAccount) String() string {
+	return s.Namespace + "/" + s.Name
+}
+
+// OIDCRoleTrust describes how a role is trusted through an OIDC provider
+type OIDCRoleTrust struct {
+	RoleName        string
+	RoleArn         string
+	LastUsed        *time.Time
+	Subjects        []string
+	ServiceAccounts []ServiceAccount `json:",omitempty"`
+	Issues          []string         `json:",omitempty"`
+}
+
+// OIDCProviderGroup groups the roles trusted through one OIDC provider
+type OIDCProviderGroup struct {
+	ProviderArn string
+	Provider    string
+	Kind        string
+	Exists      bool
+	Roles       []OIDCRoleTrust
+}
+
+// AuditOIDCTrust classifies roles trusted through OIDC providers and groups
+// them by provider. providerArns are the OIDC providers that exist in the
+// account.
+func AuditOIDCTrust(roles []aws.Role, providerArns []string) []OIDCProviderGroup {
+	existing := make(map[string]bool, len(providerArns))
+	for _, arn := range providerArns {
+		existing[arn] = true
+	}
+
+	groups := make(map[string]*OIDCProviderGroup)
+	// indexes holds the position of each role in its provider group
+	indexes := make(map[string]map[string]int)
+	for _, role := range roles {
+		if role.TrustPolicy == "" {
+			continue
+		}
+		doc, err := policy.Parse(role.TrustPolicy)
+		if err != nil {
+			continue
+		}
+
+		for _, stmt := range doc.Statement {
+			if stmt.Effect != policy.EffectAllow {
+				continue
+			}
+
+			for _, providerArn := range stmt.Principal.Values(policy.PrincipalFederated) {
+				provider := providerFromArn(providerArn)
+				if provider == "" {
+					continue
+				}
+
+				group, ok := groups[providerArn]
+				if !ok {
+					group = &OIDCProviderGroup{
+						ProviderArn: providerArn,
+						Provider:    provider,
+						Kind:        providerKind(provider),
+						Exists:      existing[providerArn],
+					}
+					groups[providerArn] = group
+				}
+
+				// Merge the statements of a role trusting the same provider
+				trust := classifyTrust(role, stmt, group)
+				if i, ok := indexes[providerArn][role.Arn]; ok {
+					group.Roles[i].merge(trust)
+					continue
+				}
+				if indexes[providerArn] == nil {
+					indexes[providerArn] = make(map[string]int)
+				}
+				indexes[providerArn][role.Arn] = len(group.Roles)
+				group.Roles = append(group.Roles, trust)
+			}
+		}
+	}
+
+	result := make([]OIDCProviderGroup, 0, len(groups))
+	for _, group := range groups {
+		sort.Slice(group.Roles, func(i, j int) bool {
+			return group.Roles[i].RoleName < group.Roles[j].RoleName
+		})
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Provider < result[j].Provider
+	})
+
+	return result
+}
+
+// classifyTrust inspects the subject conditions of a statement trusting an OIDC provider
+func classifyTrust(role aws.Role, stmt policy.Statement, group *OIDCProviderGroup) OIDCRoleTrust {
+	trust := OIDCRoleTrust{
+		RoleName: role.Name,
+		RoleArn:  role.Arn,
+		LastUsed: role.LastUsed,
+	}
+
+	if !group.Exists {
+		trust.Issues = append(trust.Issues, "OIDC provider does not exist in this account")
+	}
+
+	restricted := false
+	for _, entry := range stmt.ConditionEntries(group.Provider + ":sub") {
+		// A negated condition lets every other identity of the provider
+		// assume the role, so it does not restrict who can
+		if entry.IsNegated() {
+			trust.Issues = append(trust.Issues, fmt.Sprintf("negated sub condition %s only excludes %s", entry.Operator, strings.Join(entry.Values, ", ")))
+			continue
+		}
+		restricted = true
+
+		for _, subject := range entry.Values {
+			trust.Subjects = append(trust.Subjects, subject)
+			wildcard := entry.IsWildcardMatch() && strings.ContainsAny(subject, "*?")
+
+			switch group.Kind {
+			case ProviderKindEKS:
+				if sa, ok := parseServiceAccount(subject); ok {
+					trust.ServiceAccounts = append(trust.ServiceAccounts, sa)
+				}
+				if wildcard {
+					trust.Issues = append(trust.Issues, fmt.Sprintf("wildcard service account condition: %s", subject))
+				}
+			case ProviderKindGitHub:
+				if issue := gitHubSubjectIssue(subject, wildcard); issue != "" {
+					trust.Issues = append(trust.Issues, issue)
+				}
+			}
+		}
+	}
+
+	if !restricted && group.Kind != ProviderKindOther {
+		trust.Issues = append(trust.Issues, "no sub condition: any identity from the provider can assume the role")
+	}
+
+	return trust
+}
+
+// merge adds the subjects and issues of another statement trusting the
+// same provider, skipping issues already reported
+func (t *OIDCRoleTrust) merge(other OIDCRoleTrust) {
+	t.Subjects = append(t.Subjects, other.Subjects...)
+	t.ServiceAccounts = append(t.ServiceAccounts, other.ServiceAccounts...)
+	reported := make(map[string]bool, len(t.Issues))
+	for _, issue := range t.Issues {
+		reported[issue] = true
+	}
+	for _, issue := range other.Issues {
+		if !reported[issue] {
+			reported[issue] = true
+			t.Issues = append(t.Issues, issue)
+		}
+	}
+}
+
+// gitHubSubjectIssue reports wildcards in the repository or branch part of
+// a GitHub Actions subject such as repo:org/repo:ref:refs/heads/main
+func gitHubSubjectIssue(subject string, wildcard bool) string {
+	if !wildcard {
+		return ""
+	}
+
+	rest, ok := strings.CutPrefix(subject, "repo:")
+	if !ok {
+		return fmt.Sprintf("wildcard repository condition: %s", subject)
+	}
+
+	repo, _, _ := strings.Cut(rest, ":")
+	if strings.ContainsAny(repo, "*?") {
+		return fmt.Sprintf("wildcard repository condition: %s", subject)
+	}
+	return fmt.Sprintf("wildcard branch condition: %s", subject)
+}
+
+// parseServiceAccount parses a system:serviceaccount:namespace:name subject
+func parseServiceAccount(subject string) (ServiceAccount, bool) {
+	rest, ok := strings.CutPrefix(subject, "system:serviceaccount:")
+	if !ok {
+		return ServiceAccount{}, false
+	}
+
+	namespace, name, ok := strings.Cut(rest, ":")
+	if !ok {
+		return ServiceAccount{}, false
+	}
+	return ServiceAccount{Namespace: namespace, Name: name}, true
+}
+
+// providerFromArn returns the provider URL of an OIDC provider ARN, or an
+// empty string if the ARN is not an OIDC provider
+func providerFromArn(arn string) string {
+	_, provider, ok := strings.Cut(arn, ":oidc-provider/")
+	if !ok {
+		return ""
+	}
+	return provider
+}
+
+// providerKind classifies an OIDC provider URL
+func providerKind(provider string) string {
+	switch {
+	case strings.HasPrefix(provider, "oidc.eks."):
+		return ProviderKindEKS
+	case provider == gitHubProvider:
+		return ProviderKindGitHub
+	default:
+		return ProviderKindOther
+	}
+}
@@ -0,0 +1,269 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/policy"
+)
+
+// maxChainLength limits how many roles an assume-role chain may traverse
+const maxChainLength = 5
+
+// Escalation pattern names
+const (
+	PatternPassRoleLambda         = "passrole-lambda"
+	PatternPassRoleEC2            = "passrole-ec2"
+	PatternAttachRolePolicy       = "attach-role-policy"
+	PatternPutRolePolicy          = "put-role-policy"
+	PatternUpdateAssumeRolePolicy = "update-assume-role-policy"
+	PatternAssumeRoleChain        = "assume-role-chain"
+)
+
+// escalationPattern is a set of permissions that together allow a role to
+// gain more privileges than it was granted
+type escalationPattern struct {
+	name        string
+	description string
+	requests    []policy.Request
+}
+
+// escalationPatterns are the known single-role escalation patterns. A
+// Resource of * requires the permission on every resource.
+var escalationPatterns = []escalationPattern{
+	{
+		name:        PatternPassRoleLambda,
+		description: "can pass any role to a new Lambda function and run code as that role",
+		requests: []policy.Request{
+			{Action: "iam:PassRole", Resource: "*"},
+			{Action: "lambda:CreateFunction"},
+		},
+	},
+	{
+		name:        PatternPassRoleEC2,
+		description: "can pass any role to a new EC2 instance and use its credentials",
+		requests: []policy.Request{
+			{Action: "iam:PassRole", Resource: "*"},
+			{Action: "ec2:RunInstances"},
+		},
+	},
+	{
+		name:        PatternAttachRolePolicy,
+		description: "can attach any managed policy, including AdministratorAccess, to a role",
+		requests:    []policy.Request{{Action: "iam:AttachRolePolicy"}},
+	},
+	{
+		name:        PatternPutRolePolicy,
+		description: "can write an inline policy granting any permission to a role",
+		requests:    []policy.Request{{Action: "iam:PutRolePolicy"}},
+	},
+	{
+		name:        PatternUpdateAssumeRolePolicy,
+		description: "can change who may assume a role",
+		requests:    []policy.Request{{Action: "iam:UpdateAssumeRolePolicy"}},
+	},
+}
+
+// adminRequest is allowed only for roles with full administrative access
+var adminRequest = policy.Request{Action: "*", Resource: "*"}
+
+// EscalationFinding is a privilege escalation path available to a role
+type EscalationFinding struct {
+	RoleName    string
+	RoleArn     string
+	LastUsed    *time.Time
+	Unused      bool
+	Pattern     string
+	Description string
+	// Path lists the permissions or, for assume-role chains, the roles
+	// that make up the escalation
+	Path []string
+}
+
+// AuditPrivilegeEscalation finds roles whose permissions allow a known
+// privilege escalation pattern or an sts:AssumeRole chain into an admin
+// role. Roles that already have administrative access are not reported.
+// Findings for roles not used within days are marked unused and ranked
+// first. Roles whose policies cannot be evaluated are returned as skipped.
+func AuditPrivilegeEscalation(inventory *aws.Inventory, days int) ([]EscalationFinding, []SkippedRole) {
+	var findings []EscalationFinding
+	var skipped []SkippedRole
+
+	policySets := make(map[string]policy.PolicySet, len(inventory.Roles))
+	admins := make(map[string]bool)
+	for _, role := range inventory.Roles {
+		policySet, err := inventory.PolicySet(role)
+		if err != nil {
+			skipped = append(skipped, SkippedRole{RoleName: role.Name, Reason: err.Error()})
+			continue
+		}
+		policySets[role.Arn] = policySet
+		admins[role.Arn] = policySet.Evaluate(adminRequest).Allowed()
+	}
+
+	graph := newAssumeRoleGraph(inventory.Roles, policySets)
+
+	for _, role := range inventory.Roles {
+		policySet, ok := policySets[role.Arn]
+		if !ok || admins[role.Arn] {
+			continue
+		}
+
+		newFinding := func(pattern, description string, path []string) EscalationFinding {
+			return EscalationFinding{
+				RoleName:    role.Name,
+				RoleArn:     role.Arn,
+				LastUsed:    role.LastUsed,
+				Unused:      role.IsUnused(days),
+				Pattern:     pattern,
+				Description: description,
+				Path:        path,
+			}
+		}
+
+		for _, pattern := range escalationPatterns {
+			if path, ok := matchPattern(policySet, pattern); ok {
+				findings = append(findings, newFinding(pattern.name, pattern.description, path))
+			}
+		}
+
+		if chain := graph.shortestChain(role.Arn, admins); chain != nil {
+			findings = append(findings, newFinding(PatternAssumeRoleChain,
+				fmt.Sprintf("can assume its way into admin role %s", chain[len(chain)-1]), chain))
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Unused != findings[j].Unused {
+			return findings[i].Unused
+		}
+		return findings[i].RoleName < findings[j].RoleName
+	})
+
+	return findings, skipped
+}
+
+// matchPattern reports whether every request of the pattern is allowed and
+// returns the permissions as the escalation path
+func matchPattern(policySet policy.PolicySet, pattern escalationPattern) ([]string, bool) {
+	path := make([]string, 0, len(pattern.requests))
+	for _, request := range pattern.requests {
+		if !policySet.Evaluate(request).Allowed() {
+			return nil, false
+		}
+
+		step := request.Action
+		if request.Resource != "" {
+			step += " on " + request.Resource
+		}
+		path = append(path, step)
+	}
+	return path, true
+}
+
+// assumeRoleGraph holds, for each role ARN, the roles it can assume
+type assumeRoleGraph struct {
+	edges map[string][]string
+	names map[string]string
+}
+
+// newAssumeRoleGraph links role A to role B when A's policies allow
+// sts:AssumeRole on B and B's trust policy trusts A
+func newAssumeRoleGraph(roles []aws.Role, policySets map[string]policy.PolicySet) *assumeRoleGraph {
+	graph := &assumeRoleGraph{
+		edges: make(map[string][]string),
+		names: make(map[string]string, len(roles)),
+	}
+
+	trustPolicies := make(map[string]*policy.Document, len(roles))
+	for _, role := range roles {
+		graph.names[role.Arn] = role.Name
+		if doc, err := policy.Parse(role.TrustPolicy); err == nil && role.TrustPolicy != "" {
+			trustPolicies[role.Arn] = doc
+		}
+	}
+
+	for _, source := range roles {
+		policySet, ok := policySets[source.Arn]
+		if !ok {
+			continue
+		}
+
+		for _, target := range roles {
+			trust, ok := trustPolicies[target.Arn]
+			if !ok || target.Arn == source.Arn {
+				continue
+			}
+			if !trustsRole(trust, source.Arn) {
+				continue
+			}
+			if policySet.Evaluate(policy.Request{Action: "sts:AssumeRole", Resource: target.Arn}).Allowed() {
+				graph.edges[source.Arn] = append(graph.edges[source.Arn], target.Arn)
+			}
+		}
+	}
+
+	return graph
+}
+
+// shortestChain returns the role names along the shortest assume-role
+// chain from start to an admin role, or nil if there is none
+func (g *assumeRoleGraph) shortestChain(start string, admins map[string]bool) []string {
+	previous := map[string]string{start: ""}
+	queue := []string{start}
+
+	for depth := 0; depth < maxChainLength && len(queue) > 0; depth++ {
+		var next []string
+		for _, current := range queue {
+			for _, target := range g.edges[current] {
+				if _, seen := previous[target]; seen {
+					continue
+				}
+				previous[target] = current
+
+				if admins[target] {
+					var chain []string
+					for arn := target; arn != ""; arn = previous[arn] {
+						chain = append([]string{g.names[arn]}, chain...)
+					}
+					return chain
+				}
+				next = append(next, target)
+			}
+		}
+		queue = next
+	}
+
+	return nil
+}
+
+// trustsRole reports whether a trust policy allows the role to call
+// sts:AssumeRole, either directly, through its account or through a
+// wildcard principal
+func trustsRole(trust *policy.Document, roleArn string) bool {
+	account := policy.AccountFromARN(roleArn)
+
+	for _, stmt := range trust.Statement {
+		if stmt.Effect != policy.EffectAllow || stmt.Principal == nil || !stmt.MatchesAction("sts:AssumeRole") {
+			continue
+		}
+		if len(stmt.Condition) > 0 {
+			// Conditions such as sts:ExternalId cannot be satisfied offline
+			continue
+		}
+
+		for _, principal := range stmt.Principal.Values(policy.PrincipalAWS) {
+			if principal == "*" || principal == roleArn {
+				return true
+			}
+			if account != "" && (principal == account || strings.HasSuffix(principal, ":root") && policy.AccountFromARN(principal) == account) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"sort"
+	"time"
+
+	"hawkling/pkg/aws"
+)
+
+// UnusedService is a service granted to a role that the role did not access
+// within the period, or whose tracked actions it did not all use
+type UnusedService struct {
+	ServiceNamespace string
+	ServiceName      string
+	LastAccessed     *time.Time
+
+	// Unused is true if the whole service was not accessed within the period
+	Unused bool
+
+	// UnusedActions lists tracked actions not used within the period, for
+	// services where AWS reports action-level data
+	UnusedActions []string `json:",omitempty"`
+}
+
+// RightsizeReport lists the services a role is granted but does not use
+type RightsizeReport struct {
+	RoleName        string
+	RoleArn         string
+	LastUsed        *time.Time
+	ServicesGranted int
+	UnusedServices  []UnusedService
+}
+
+// Rightsize compares the services granted to a role with the services it
+// accessed in the last days days. Services are sorted by namespace.
+func Rightsize(role aws.Role, services []aws.ServiceLastAccessed, days int, now time.Time) RightsizeReport {
+	threshold := now.AddDate(0, 0, -days)
+	accessed := func(lastAccessed *time.Time) bool {
+		return lastAccessed != nil && !lastAccessed.Before(threshold)
+	}
+
+	report := RightsizeReport{
+		RoleName:        role.Name,
+		RoleArn:         role.Arn,
+		LastUsed:        role.LastUsed,
+		ServicesGranted: len(services),
+	}
+
+	for _, service := range services {
+		unused := UnusedService{
+			ServiceNamespace: service.ServiceNamespace,
+			ServiceName:      service.ServiceName,
+			LastAccessed:     service.LastAccessed,
+			Unused:           !accessed(service.LastAccessed),
+		}
+		if !unused.Unused {
+			for _, action := range service.Actions {
+				if !accessed(action.LastAccessed) {
+					unused.UnusedActions = append(unused.UnusedActions, action.ActionName)
+				}
+			}
+			if len(unused.UnusedActions) == 0 {
+				continue
+			}
+			sort.Strings(unused.UnusedActions)
+		}
+		report.UnusedServices = append(report.UnusedServices, unused)
+	}
+
+	sort.SliceStable(report.UnusedServices, func(i, j int) bool {
+		return report.UnusedServices[i].ServiceNamespace < report.UnusedServices[j].ServiceNamespace
+	})
+
+	return report
+}
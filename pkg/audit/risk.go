@@ -0,0 +1,205 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/policy"
+)
+
+// Maximum contribution of each risk factor to the score. The score ranges
+// from 0 to 100.
+const (
+	maxStalenessRisk = 30
+	maxPrivilegeRisk = 40
+	maxTrustRisk     = 20
+	noBoundaryRisk   = 10
+)
+
+// RiskAssessment is the risk score of a role and the factors behind it
+type RiskAssessment struct {
+	Score   int
+	Factors []string
+}
+
+// ScoreRoles assesses every role found in the inventory, matched by ARN,
+// and sets its RiskScore and RiskFactors. Roles missing from the inventory
+// or whose policies cannot be evaluated are returned as skipped.
+func ScoreRoles(inventory *aws.Inventory, roles []aws.Role, now time.Time) []SkippedRole {
+	details := make(map[string]aws.Role, len(inventory.Roles))
+	for _, role := range inventory.Roles {
+		details[role.Arn] = role
+	}
+
+	var skipped []SkippedRole
+	for i := range roles {
+		detail, ok := details[roles[i].Arn]
+		if !ok {
+			skipped = append(skipped, SkippedRole{RoleName: roles[i].Name, Reason: "role not found in inventory"})
+			continue
+		}
+
+		policySet, err := inventory.PolicySet(detail)
+		if err != nil {
+			skipped = append(skipped, SkippedRole{RoleName: roles[i].Name, Reason: err.Error()})
+			continue
+		}
+
+		// Usage data on the listed role may be more recent than the inventory
+		detail.LastUsed = roles[i].LastUsed
+		assessment := AssessRisk(detail, policySet, now)
+		roles[i].RiskScore = assessment.Score
+		roles[i].RiskFactors = assessment.Factors
+	}
+
+	return skipped
+}
+
+// AssessRisk scores a role by combining how long it has been idle, how
+// privileged it is, how broadly it is trusted and whether it has a
+// permissions boundary
+func AssessRisk(role aws.Role, policySet policy.PolicySet, now time.Time) RiskAssessment {
+	var assessment RiskAssessment
+	add := func(score int, factors []string) {
+		assessment.Score += score
+		assessment.Factors = append(assessment.Factors, factors...)
+	}
+
+	add(stalenessRisk(role, now))
+	add(privilegeRisk(policySet))
+	add(trustRisk(role))
+
+	if role.PermissionsBoundary == "" {
+		add(noBoundaryRisk, []string{"no permissions boundary"})
+	}
+
+	return assessment
+}
+
+// stalenessRisk scores how long a role has been idle, counting from its
+// creation date if it was never used
+func stalenessRisk(role aws.Role, now time.Time) (int, []string) {
+	since := role.CreateDate
+	if role.LastUsed != nil {
+		since = *role.LastUsed
+	}
+
+	idleDays := int(now.Sub(since).Hours() / 24)
+	if since.IsZero() {
+		idleDays = -1
+	}
+
+	var score int
+	switch {
+	case idleDays < 0 || idleDays >= 365:
+		score = maxStalenessRisk
+	case idleDays >= 90:
+		score = 20
+	case idleDays >= 30:
+		score = 10
+	}
+
+	if role.LastUsed == nil {
+		if idleDays < 0 {
+			return score, []string{"never used"}
+		}
+		return score, []string{fmt.Sprintf("never used (created %d days ago)", idleDays)}
+	}
+	if score == 0 {
+		return 0, nil
+	}
+	return score, []string{fmt.Sprintf("unused for %d days", idleDays)}
+}
+
+// privilegeRisk scores administrative access, escalation permissions and
+// wildcard actions
+func privilegeRisk(policySet policy.PolicySet) (int, []string) {
+	if policySet.Evaluate(adminRequest).Allowed() {
+		return maxPrivilegeRisk, []string{"administrator access"}
+	}
+
+	var factors []string
+	for _, pattern := range escalationPatterns {
+		if _, ok := matchPattern(policySet, pattern); ok {
+			factors = append(factors, "escalation: "+pattern.name)
+		}
+	}
+	if len(factors) > 0 {
+		return 30, factors
+	}
+
+	for _, src := range policySet.Identity {
+		for _, stmt := range src.Document.Statement {
+			if stmt.Effect != policy.EffectAllow {
+				continue
+			}
+			if len(stmt.NotAction) > 0 {
+				return 15, []string{fmt.Sprintf("NotAction allow in %s", src.Name)}
+			}
+			for _, action := range stmt.Action {
+				if action == "*" || strings.HasSuffix(action, ":*") {
+					return 15, []string{fmt.Sprintf("wildcard action %s in %s", action, src.Name)}
+				}
+			}
+		}
+	}
+
+	return 0, nil
+}
+
+// trustRisk scores how broadly a role can be assumed: by any principal, by
+// other accounts, or through federation without conditions
+func trustRisk(role aws.Role) (int, []string) {
+	if role.TrustPolicy == "" {
+		return 0, nil
+	}
+	doc, err := policy.Parse(role.TrustPolicy)
+	if err != nil {
+		return 0, nil
+	}
+
+	account := policy.AccountFromARN(role.Arn)
+	score := 0
+	var factors []string
+	raise := func(value int, factor string) {
+		if value > score {
+			score = value
+		}
+		factors = append(factors, factor)
+	}
+
+	for _, stmt := range doc.Statement {
+		if stmt.Effect != policy.EffectAllow || stmt.Principal == nil {
+			continue
+		}
+		conditional := len(stmt.Condition) > 0
+
+		for _, principal := range stmt.Principal.Values(policy.PrincipalAWS) {
+			principalAccount := principal
+			if !policy.IsAccountID(principal) {
+				principalAccount = policy.AccountFromARN(principal)
+			}
+
+			switch {
+			case principal == "*" && conditional:
+				raise(10, "trusts any AWS principal with conditions")
+			case principal == "*":
+				raise(maxTrustRisk, "trusts any AWS principal")
+			case principalAccount == "" || principalAccount == account:
+				continue
+			case conditional:
+				raise(5, fmt.Sprintf("trusts external account %s with conditions", principalAccount))
+			default:
+				raise(15, fmt.Sprintf("trusts external account %s without conditions", principalAccount))
+			}
+		}
+
+		if !conditional && len(stmt.Principal.Values(policy.PrincipalFederated)) > 0 {
+			raise(10, "trusts a federated provider without conditions")
+		}
+	}
+
+	return score, factors
+}
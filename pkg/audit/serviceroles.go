@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/catalog"
+)
+
+// Statuses of an auto-generated service role
+const (
+	ServiceRoleOrphaned = "orphaned"
+	ServiceRoleInUse    = "in use"
+	ServiceRoleUnknown  = "unknown"
+)
+
+// Confidences of an orphaned service role
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+)
+
+// ServiceRoleFinding is a role that follows the naming convention of roles
+// a service console creates for a resource
+type ServiceRoleFinding struct {
+	RoleName string
+	RoleArn  string
+	Path     string
+	LastUsed *time.Time
+
+	// Pattern is the name of the catalog pattern the role follows
+	Pattern string
+	Service string
+	// Owner describes the owning resource, such as "function checkout"
+	Owner string
+	// PathMatches is set when the role also has the path of the convention
+	PathMatches bool
+
+	Status     string
+	Confidence string   `json:",omitempty"`
+	UsedBy     []string `json:",omitempty"`
+	Reason     string   `json:",omitempty"`
+}
+
+// FindServiceRoles returns the roles that follow a pattern of the catalog,
+// whether or not they are still used
+func FindServiceRoles(roles []aws.Role, patterns *catalog.ServiceRoleCatalog) []ServiceRoleFinding {
+	var findings []ServiceRoleFinding
+	for _, role := range roles {
+		match, ok := patterns.Match(role.Name, role.Path)
+		if !ok {
+			continue
+		}
+
+		owner := match.Pattern.Owner
+		if match.OwnerName != "" {
+			owner += " " + match.OwnerName
+		}
+		findings = append(findings, ServiceRoleFinding{
+			RoleName:    role.Name,
+			RoleArn:     role.Arn,
+			Path:        role.Path,
+			LastUsed:    role.LastUsed,
+			Pattern:     match.Pattern.Name,
+			Service:     match.Pattern.Service,
+			Owner:       strings.TrimSpace(owner),
+			PathMatches: match.PathMatches,
+		})
+	}
+	return findings
+}
+
+// ClassifyServiceRoles sets the status of each finding from the references
+// found by the scanners of the given services, regardless of when the role
+// was last used. onlyRegions are the regions scanned if the scan did not
+// cover every enabled region. A role no resource uses is orphaned, with high
+// confidence if it has the path of its convention and every scan of every
+// region succeeded. A role is unknown if the scan of its service failed or
+// no scanner covers it. Findings are sorted with orphaned roles first, then
+// by name.
+func ClassifyServiceRoles(findings []ServiceRoleFinding, scanned, onlyRegions []string, references map[string][]aws.Reference, failures map[string]error) {
+	covered := make(map[string]bool, len(scanned))
+	for _, service := range scanned {
+		covered[service] = true
+	}
+
+	for i := range findings {
+		finding := &findings[i]
+		finding.Status, finding.Confidence, finding.UsedBy = "", "", nil
+
+		if used := references[finding.RoleArn]; len(used) > 0 {
+			finding.Status = ServiceRoleInUse
+			for _, reference := range used {
+				finding.UsedBy = append(finding.UsedBy, reference.String())
+			}
+			finding.Reason = ""
+			continue
+		}
+
+		switch {
+		case !covered[finding.Service]:
+			finding.Status = ServiceRoleUnknown
+			finding.Reason = fmt.Sprintf("no scanner for service %s", finding.Service)
+			continue
+		case failures[finding.Service] != nil:
+			finding.Status = ServiceRoleUnknown
+			finding.Reason = fmt.Sprintf("%s scan failed: %v", finding.Service, failures[finding.Service])
+			continue
+		}
+
+		finding.Status = ServiceRoleOrphaned
+		finding.Confidence = ConfidenceHigh
+		reasons := []string{fmt.Sprintf("no %s resource uses the role", finding.Service)}
+		if !finding.PathMatches {
+			finding.Confidence = ConfidenceMedium
+			reasons = append(reasons, fmt.Sprintf("path %s is not the path the console uses", finding.Path))
+		}
+		if len(failures) > 0 {
+			finding.Confidence = ConfidenceMedium
+			reasons = append(reasons, "other services could not be scanned")
+		}
+		// Resources in the other regions may still use the role
+		switch len(onlyRegions) {
+		case 0:
+		case 1:
+			finding.Confidence = ConfidenceMedium
+			reasons = append(reasons, fmt.Sprintf("only region %s scanned", onlyRegions[0]))
+		default:
+			finding.Confidence = ConfidenceMedium
+			reasons = append(reasons, fmt.Sprintf("only regions %s scanned", strings.Join(onlyRegions, ", ")))
+		}
+		finding.Reason = strings.Join(reasons, "; ")
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		orphanedI := findings[i].Status == ServiceRoleOrphaned
+		orphanedJ := findings[j].Status == ServiceRoleOrphaned
+		if orphanedI != orphanedJ {
+			return orphanedI
+		}
+		return findings[i].RoleName < findings[j].RoleName
+	})
+}
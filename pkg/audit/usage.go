@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"fmt"
+	"time"
+
+	"hawkling/pkg/aws"
+)
+
+// UsageDisagreement is a role whose last-used date and Access Analyzer
+// unused-access findings disagree on whether it is used
+type UsageDisagreement struct {
+	RoleName       string
+	RoleArn        string
+	LastUsed       *time.Time
+	LastUsedUnused bool
+	AnalyzerUnused bool
+	Reason         string
+}
+
+// FindUsageDisagreements compares the two usage signals of every role with
+// Access Analyzer data. A role counts as unused by its last-used date if it
+// was never used or, with days above zero, not used within days.
+func FindUsageDisagreements(roles []aws.Role, days int) []UsageDisagreement {
+	var disagreements []UsageDisagreement
+	for _, role := range roles {
+		if role.UnusedAccess == nil {
+			continue
+		}
+
+		lastUsedUnused := role.LastUsed == nil || (days > 0 && role.IsUnused(days))
+		analyzerUnused := role.UnusedAccess.UnusedRole
+		if lastUsedUnused == analyzerUnused {
+			continue
+		}
+
+		var reason string
+		switch {
+		case role.LastUsed == nil:
+			reason = "never used, but Access Analyzer reports it used"
+		case lastUsedUnused:
+			reason = fmt.Sprintf("last used %s, not within %d days, but Access Analyzer reports it used", role.LastUsed.Format("2006-01-02"), days)
+		case days > 0:
+			reason = fmt.Sprintf("last used %s, within %d days, but Access Analyzer reports it unused", role.LastUsed.Format("2006-01-02"), days)
+		default:
+			reason = fmt.Sprintf("last used %s with no unused window set, but Access Analyzer reports it unused", role.LastUsed.Format("2006-01-02"))
+		}
+
+		disagreements = append(disagreements, UsageDisagreement{
+			RoleName:       role.Name,
+			RoleArn:        role.Arn,
+			LastUsed:       role.LastUsed,
+			LastUsedUnused: lastUsedUnused,
+			AnalyzerUnused: analyzerUnused,
+			Reason:         reason,
+		})
+	}
+
+	return disagreements
+}
@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"hawkling/pkg/aws"
+)
+
+// trustPolicyName names the trust policy in validation findings
+const trustPolicyName = "trust policy"
+
+// findingTypeRanks orders validation finding types, most severe first
+var findingTypeRanks = map[string]int{
+	aws.FindingTypeError:           0,
+	aws.FindingTypeSecurityWarning: 1,
+	aws.FindingTypeWarning:         2,
+	aws.FindingTypeSuggestion:      3,
+}
+
+// PolicyFinding is an Access Analyzer finding in one policy of a role
+type PolicyFinding struct {
+	PolicyName    string
+	PolicyKind    aws.PolicyKind
+	FindingType   string
+	IssueCode     string
+	Details       string
+	Location      string `json:",omitempty"`
+	LearnMoreLink string `json:",omitempty"`
+}
+
+// RoleValidation aggregates the validation findings of a role
+type RoleValidation struct {
+	RoleName         string
+	RoleArn          string
+	Errors           int
+	SecurityWarnings int
+	Warnings         int
+	Suggestions      int
+	Findings         []PolicyFinding
+}
+
+// ParseFindingType parses a validation finding type name, ignoring case and
+// accepting dashes in place of underscores
+func ParseFindingType(name string) (string, bool) {
+	findingType := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	_, ok := findingTypeRanks[findingType]
+	return findingType, ok
+}
+
+// ValidateRole validates the inline policies and trust policy of a role.
+// Each finding is reported once per location in the policy.
+func ValidateRole(ctx context.Context, validator aws.AnalyzerClient, role aws.Role) ([]PolicyFinding, error) {
+	var findings []PolicyFinding
+	validate := func(name, document string, kind aws.PolicyKind) error {
+		results, err := validator.ValidatePolicy(ctx, document, kind)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			finding := PolicyFinding{
+				PolicyName:    name,
+				PolicyKind:    kind,
+				FindingType:   result.FindingType,
+				IssueCode:     result.IssueCode,
+				Details:       result.Details,
+				LearnMoreLink: result.LearnMoreLink,
+			}
+			if len(result.Locations) == 0 {
+				findings = append(findings, finding)
+				continue
+			}
+			for _, location := range result.Locations {
+				finding.Location = location
+				findings = append(findings, finding)
+			}
+		}
+		return nil
+	}
+
+	for _, inline := range role.InlinePolicies {
+		if err := validate(inline.Name, inline.Document, aws.PolicyKindInline); err != nil {
+			return nil, err
+		}
+	}
+	if role.TrustPolicy != "" {
+		if err := validate(trustPolicyName, role.TrustPolicy, aws.PolicyKindTrust); err != nil {
+			return nil, err
+		}
+	}
+
+	return findings, nil
+}
+
+// SummarizeValidation aggregates findings per role, keeping only the given
+// finding types, or every type if none are given. Roles without findings
+// are omitted. Roles are sorted by name and their findings by type, most
+// severe first, then by policy and location.
+func SummarizeValidation(roles []aws.Role, findings map[string][]PolicyFinding, findingTypes []string) []RoleValidation {
+	wanted := make(map[string]bool, len(findingTypes))
+	for _, findingType := range findingTypes {
+		wanted[findingType] = true
+	}
+
+	var validations []RoleValidation
+	for _, role := range roles {
+		validation := RoleValidation{RoleName: role.Name, RoleArn: role.Arn}
+		for _, finding := range findings[role.Name] {
+			if len(wanted) > 0 && !wanted[finding.FindingType] {
+				continue
+			}
+			switch finding.FindingType {
+			case aws.FindingTypeError:
+				validation.Errors++
+			case aws.FindingTypeSecurityWarning:
+				validation.SecurityWarnings++
+			case aws.FindingTypeWarning:
+				validation.Warnings++
+			case aws.FindingTypeSuggestion:
+				validation.Suggestions++
+			}
+			validation.Findings = append(validation.Findings, finding)
+		}
+		if len(validation.Findings) == 0 {
+			continue
+		}
+
+		sort.SliceStable(validation.Findings, func(i, j int) bool {
+			a, b := validation.Findings[i], validation.Findings[j]
+			if findingTypeRanks[a.FindingType] != findingTypeRanks[b.FindingType] {
+				return findingTypeRanks[a.FindingType] < findingTypeRanks[b.FindingType]
+			}
+			if a.PolicyName != b.PolicyName {
+				return a.PolicyName < b.PolicyName
+			}
+			return a.Location < b.Location
+		})
+		validations = append(validations, validation)
+	}
+
+	sort.SliceStable(validations, func(i, j int) bool {
+		return validations[i].RoleName < validations[j].RoleName
+	})
+
+	return validations
+}
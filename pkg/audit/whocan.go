@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"sort"
+	"time"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/policy"
+)
+
+// RoleAccess describes a role that is allowed to perform a request
+type RoleAccess struct {
+	RoleName            string
+	RoleArn             string
+	LastUsed            *time.Time
+	Unused              bool
+	PermissionsBoundary string `json:",omitempty"`
+	// Conditional is set when the role is allowed only if the Unresolved
+	// condition keys, missing from the request context, have suitable values
+	Conditional bool     `json:",omitempty"`
+	Unresolved  []string `json:",omitempty"`
+	Matches     []policy.Match
+}
+
+// SkippedRole is a role whose policies could not be evaluated
+type SkippedRole struct {
+	RoleName string
+	Reason   string
+}
+
+// WhoCan evaluates the request against every role in the inventory and
+// returns the roles that are allowed to perform it, including roles allowed
+// only under conditions on keys missing from the request context, which are
+// marked conditional. Roles not used within days are marked unused and
+// ranked first, then roles are ordered by name.
+func WhoCan(inventory *aws.Inventory, request policy.Request, days int) ([]RoleAccess, []SkippedRole) {
+	var allowed []RoleAccess
+	var skipped []SkippedRole
+
+	for _, role := range inventory.Roles {
+		policySet, err := inventory.PolicySet(role)
+		if err != nil {
+			skipped = append(skipped, SkippedRole{RoleName: role.Name, Reason: err.Error()})
+			continue
+		}
+
+		result := policySet.Evaluate(request)
+		if !result.Allowed() && !result.Conditional {
+			continue
+		}
+
+		allowed = append(allowed, RoleAccess{
+			RoleName:            role.Name,
+			RoleArn:             role.Arn,
+			LastUsed:            role.LastUsed,
+			Unused:              role.IsUnused(days),
+			PermissionsBoundary: role.PermissionsBoundary,
+			Conditional:         result.Conditional,
+			Unresolved:          result.Unresolved,
+			Matches:             append(result.Matches, result.ConditionalMatches...),
+		})
+	}
+
+	sort.SliceStable(allowed, func(i, j int) bool {
+		if allowed[i].Unused != allowed[j].Unused {
+			return allowed[i].Unused
+		}
+		return allowed[i].RoleName < allowed[j].RoleName
+	})
+
+	return allowed, skipped
+}
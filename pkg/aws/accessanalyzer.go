@@ -0,0 +1,260 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/accessanalyzer"
+	"github.com/aws/aws-sdk-go-v2/service/accessanalyzer/types"
+)
+
+// PolicyKind identifies what a validated policy document is attached as
+type PolicyKind string
+
+// Policy kinds validated by Access Analyzer
+const (
+	PolicyKindInline PolicyKind = "inline"
+	PolicyKindTrust  PolicyKind = "trust"
+)
+
+// Access Analyzer validation finding types, from most to least severe
+const (
+	FindingTypeError           = "ERROR"
+	FindingTypeSecurityWarning = "SECURITY_WARNING"
+	FindingTypeWarning         = "WARNING"
+	FindingTypeSuggestion      = "SUGGESTION"
+)
+
+// ValidationFinding is a problem Access Analyzer found in a policy document
+type ValidationFinding struct {
+	FindingType   string
+	IssueCode     string
+	Details       string
+	LearnMoreLink string `json:",omitempty"`
+
+	// Locations are paths to the offending parts of the document, such as
+	// Statement[0].Action[1]
+	Locations []string `json:",omitempty"`
+}
+
+// Access Analyzer unused-access finding types for roles
+const (
+	FindingTypeUnusedRole       = "UnusedIAMRole"
+	FindingTypeUnusedPermission = "UnusedPermission"
+)
+
+// UnusedAccessFinding is an active Access Analyzer unused-access finding for
+// an IAM role
+type UnusedAccessFinding struct {
+	ID          string
+	RoleArn     string
+	FindingType string
+}
+
+// UnusedAccess summarizes the Access Analyzer unused-access findings of a
+// role. A role without findings has a zero UnusedAccess, meaning the
+// analyzer considers it used.
+type UnusedAccess struct {
+	UnusedRole        bool
+	UnusedPermissions int      `json:",omitempty"`
+	FindingIDs        []string `json:",omitempty"`
+}
+
+// AnalyzerClient defines the interface for IAM Access Analyzer operations
+type AnalyzerClient interface {
+	// ValidatePolicy checks a policy document against IAM policy grammar and
+	// best practices
+	ValidatePolicy(ctx context.Context, document string, kind PolicyKind) ([]ValidationFinding, error)
+
+	// FindUnusedAccessAnalyzer returns the ARN of an active unused-access
+	// analyzer, or an empty string if there is none
+	FindUnusedAccessAnalyzer(ctx context.Context) (string, error)
+
+	// ListUnusedAccessFindings returns the active unused-access findings of
+	// an analyzer for IAM roles
+	ListUnusedAccessFindings(ctx context.Context, analyzerArn string) ([]UnusedAccessFinding, error)
+}
+
+// AttachUnusedAccess sets UnusedAccess on every role from the findings,
+// matched by role ARN
+func AttachUnusedAccess(roles []Role, findings []UnusedAccessFinding) {
+	byArn := make(map[string]*UnusedAccess, len(roles))
+	for i := range roles {
+		roles[i].UnusedAccess = &UnusedAccess{}
+		byArn[roles[i].Arn] = roles[i].UnusedAccess
+	}
+
+	for _, finding := range findings {
+		access, ok := byArn[finding.RoleArn]
+		if !ok {
+			continue
+		}
+		switch finding.FindingType {
+		case FindingTypeUnusedRole:
+			access.UnusedRole = true
+		case FindingTypeUnusedPermission:
+			access.UnusedPermissions++
+		}
+		access.FindingIDs = append(access.FindingIDs, finding.ID)
+	}
+}
+
+// For testing
+var testAnalyzerClient AnalyzerClient
+
+// SetTestAnalyzerClient sets a test Access Analyzer client for unit testing
+func SetTestAnalyzerClient(client AnalyzerClient) {
+	testAnalyzerClient = client
+}
+
+// ClearTestAnalyzerClient clears the test Access Analyzer client after tests
+func ClearTestAnalyzerClient() {
+	testAnalyzerClient = nil
+}
+
+// AccessAnalyzerClient implements the AnalyzerClient interface
+type AccessAnalyzerClient struct {
+	client *accessanalyzer.Client
+}
+
+// NewAnalyzerClient creates a new Access Analyzer client with the specified
+// profile and region. A non-empty endpoint overrides the service endpoint,
+// for example to use a local stand-in.
+func NewAnalyzerClient(ctx context.Context, profile, region, endpoint string) (AnalyzerClient, error) {
+	// If we're in test mode, return the test client
+	if testAnalyzerClient != nil {
+		return testAnalyzerClient, nil
+	}
+
+	cfg, err := loadConfig(ctx, profile, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccessAnalyzerClient{
+		client: accessanalyzer.NewFromConfig(cfg, func(o *accessanalyzer.Options) {
+			if endpoint != "" {
+				o.BaseEndpoint = aws.String(endpoint)
+			}
+		}),
+	}, nil
+}
+
+// ValidatePolicy validates a policy document. Inline policies are validated
+// as identity policies and trust policies as role trust resource policies.
+func (c *AccessAnalyzerClient) ValidatePolicy(ctx context.Context, document string, kind PolicyKind) ([]ValidationFinding, error) {
+	input := &accessanalyzer.ValidatePolicyInput{
+		PolicyDocument: aws.String(document),
+		PolicyType:     types.PolicyTypeIdentityPolicy,
+	}
+	if kind == PolicyKindTrust {
+		input.PolicyType = types.PolicyTypeResourcePolicy
+		input.ValidatePolicyResourceType = types.ValidatePolicyResourceTypeRoleTrust
+	}
+
+	var findings []ValidationFinding
+	paginator := accessanalyzer.NewValidatePolicyPaginator(c.client, input)
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate policy: %w", err)
+		}
+
+		for _, f := range output.Findings {
+			finding := ValidationFinding{
+				FindingType:   string(f.FindingType),
+				IssueCode:     aws.ToString(f.IssueCode),
+				Details:       aws.ToString(f.FindingDetails),
+				LearnMoreLink: aws.ToString(f.LearnMoreLink),
+			}
+			for _, location := range f.Locations {
+				finding.Locations = append(finding.Locations, formatLocationPath(location.Path))
+			}
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings, nil
+}
+
+// formatLocationPath renders a path into a policy document, such as
+// Statement[0].Condition.StringEquals
+func formatLocationPath(path []types.PathElement) string {
+	var b strings.Builder
+	for _, element := range path {
+		switch e := element.(type) {
+		case *types.PathElementMemberIndex:
+			fmt.Fprintf(&b, "[%d]", e.Value)
+		case *types.PathElementMemberKey:
+			appendPathSegment(&b, e.Value)
+		case *types.PathElementMemberValue:
+			appendPathSegment(&b, e.Value)
+		case *types.PathElementMemberSubstring:
+			start := aws.ToInt32(e.Value.Start)
+			fmt.Fprintf(&b, "[%d:%d]", start, start+aws.ToInt32(e.Value.Length))
+		}
+	}
+	return b.String()
+}
+
+func appendPathSegment(b *strings.Builder, segment string) {
+	if b.Len() > 0 {
+		b.WriteByte('.')
+	}
+	b.WriteString(segment)
+}
+
+// FindUnusedAccessAnalyzer returns the first active account or organization
+// unused-access analyzer
+func (c *AccessAnalyzerClient) FindUnusedAccessAnalyzer(ctx context.Context) (string, error) {
+	for _, analyzerType := range []types.Type{types.TypeAccountUnusedAccess, types.TypeOrganizationUnusedAccess} {
+		paginator := accessanalyzer.NewListAnalyzersPaginator(c.client, &accessanalyzer.ListAnalyzersInput{
+			Type: analyzerType,
+		})
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return "", fmt.Errorf("failed to list analyzers: %w", err)
+			}
+
+			for _, analyzer := range output.Analyzers {
+				if analyzer.Status == types.AnalyzerStatusActive {
+					return aws.ToString(analyzer.Arn), nil
+				}
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// ListUnusedAccessFindings returns the active findings of an unused-access
+// analyzer for IAM roles
+func (c *AccessAnalyzerClient) ListUnusedAccessFindings(ctx context.Context, analyzerArn string) ([]UnusedAccessFinding, error) {
+	var findings []UnusedAccessFinding
+	paginator := accessanalyzer.NewListFindingsV2Paginator(c.client, &accessanalyzer.ListFindingsV2Input{
+		AnalyzerArn: aws.String(analyzerArn),
+		Filter: map[string]types.Criterion{
+			"resourceType": {Eq: []string{string(types.ResourceTypeAwsIamRole)}},
+			"status":       {Eq: []string{string(types.FindingStatusActive)}},
+		},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list findings: %w", err)
+		}
+
+		for _, f := range output.Findings {
+			findings = append(findings, UnusedAccessFinding{
+				ID:          aws.ToString(f.Id),
+				RoleArn:     aws.ToString(f.Resource),
+				FindingType: string(f.FindingType),
+			})
+		}
+	}
+
+	return findings, nil
+}
@@ -2,7 +2,9 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"sync"
 	"time"
@@ -10,6 +12,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/schollz/progressbar/v3"
 )
 
@@ -29,6 +33,7 @@ func ClearTestClient() {
 // AWSclient implements the IAMClient interface
 type AWSClient struct {
 	iamClient *iam.Client
+	stsClient *sts.Client
 }
 
 // NewAWSClient creates a new AWS client with the specified profile and region
@@ -38,6 +43,19 @@ func NewAWSClient(ctx context.Context, profile, region string) (IAMClient, error
 		return testClient, nil
 	}
 
+	cfg, err := loadConfig(ctx, profile, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AWSClient{
+		iamClient: iam.NewFromConfig(cfg),
+		stsClient: sts.NewFromConfig(cfg),
+	}, nil
+}
+
+// loadConfig loads the AWS configuration for a profile and region
+func loadConfig(ctx context.Context, profile, region string) (aws.Config, error) {
 	var cfg aws.Config
 	var err error
 
@@ -53,12 +71,10 @@ func NewAWSClient(ctx context.Context, profile, region string) (IAMClient, error
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		return aws.Config{}, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
-	return &AWSClient{
-		iamClient: iam.NewFromConfig(cfg),
-	}, nil
+	return cfg, nil
 }
 
 // ListRoles returns all IAM roles
@@ -79,6 +95,7 @@ func (c *AWSClient) ListRoles(ctx context.Context) ([]Role, error) {
 			role := Role{
 				Name:       *r.RoleName,
 				Arn:        *r.Arn,
+				Path:       aws.ToString(r.Path),
 				CreateDate: *r.CreateDate,
 			}
 
@@ -86,6 +103,16 @@ func (c *AWSClient) ListRoles(ctx context.Context) ([]Role, error) {
 				role.Description = *r.Description
 			}
 
+			if r.MaxSessionDuration != nil {
+				role.MaxSessionDuration = *r.MaxSessionDuration
+			}
+
+			trustPolicy, err := decodeDocument(r.AssumeRolePolicyDocument)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode trust policy for role %s: %w", *r.RoleName, err)
+			}
+			role.TrustPolicy = trustPolicy
+
 			// We'll get last used info separately
 			roles = append(roles, role)
 		}
@@ -233,6 +260,82 @@ func (c *AWSClient) DeleteRole(ctx context.Context, roleName string) error {
 	return nil
 }
 
+// CreateRole creates a role with the path, description, trust policy,
+// maximum session duration, permissions boundary and tags of role
+func (c *AWSClient) CreateRole(ctx context.Context, role Role) error {
+	input := &iam.CreateRoleInput{
+		RoleName:                 aws.String(role.Name),
+		AssumeRolePolicyDocument: aws.String(role.TrustPolicy),
+	}
+	if role.Path != "" {
+		input.Path = aws.String(role.Path)
+	}
+	if role.Description != "" {
+		input.Description = aws.String(role.Description)
+	}
+	if role.MaxSessionDuration != 0 {
+		input.MaxSessionDuration = aws.Int32(role.MaxSessionDuration)
+	}
+	if role.PermissionsBoundary != "" {
+		input.PermissionsBoundary = aws.String(role.PermissionsBoundary)
+	}
+	for key, value := range role.Tags {
+		input.Tags = append(input.Tags, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+
+	if _, err := c.iamClient.CreateRole(ctx, input); err != nil {
+		return fmt.Errorf("failed to create role %s: %w", role.Name, err)
+	}
+
+	return nil
+}
+
+// CreateInstanceProfile creates an instance profile
+func (c *AWSClient) CreateInstanceProfile(ctx context.Context, name, path string) error {
+	input := &iam.CreateInstanceProfileInput{InstanceProfileName: aws.String(name)}
+	if path != "" {
+		input.Path = aws.String(path)
+	}
+	if _, err := c.iamClient.CreateInstanceProfile(ctx, input); err != nil {
+		return fmt.Errorf("failed to create instance profile %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// AddRoleToInstanceProfile adds a role to an instance profile
+func (c *AWSClient) AddRoleToInstanceProfile(ctx context.Context, profileName, roleName string) error {
+	_, err := c.iamClient.AddRoleToInstanceProfile(ctx, &iam.AddRoleToInstanceProfileInput{
+		InstanceProfileName: aws.String(profileName),
+		RoleName:            aws.String(roleName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add role %s to instance profile %s: %w", roleName, profileName, err)
+	}
+
+	return nil
+}
+
+// ListRoleTags returns the tags of a role
+func (c *AWSClient) ListRoleTags(ctx context.Context, roleName string) (map[string]string, error) {
+	paginator := iam.NewListRoleTagsPaginator(c.iamClient, &iam.ListRoleTagsInput{
+		RoleName: aws.String(roleName),
+	})
+
+	tags := make(map[string]string)
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags of role %s: %w", roleName, err)
+		}
+		for _, tag := range output.Tags {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+
+	return tags, nil
+}
+
 // DetachRolePolicies detaches all managed policies from a role
 func (c *AWSClient) DetachRolePolicies(ctx context.Context, roleName string) error {
 	paginator := iam.NewListAttachedRolePoliciesPaginator(c.iamClient, &iam.ListAttachedRolePoliciesInput{
@@ -259,6 +362,32 @@ func (c *AWSClient) DetachRolePolicies(ctx context.Context, roleName string) err
 	return nil
 }
 
+// AttachRolePolicy attaches a managed policy to a role
+func (c *AWSClient) AttachRolePolicy(ctx context.Context, roleName, policyArn string) error {
+	_, err := c.iamClient.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
+		RoleName:  aws.String(roleName),
+		PolicyArn: aws.String(policyArn),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to attach policy %s to role %s: %w", policyArn, roleName, err)
+	}
+
+	return nil
+}
+
+// DetachRolePolicy detaches a single managed policy from a role
+func (c *AWSClient) DetachRolePolicy(ctx context.Context, roleName, policyArn string) error {
+	_, err := c.iamClient.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
+		RoleName:  aws.String(roleName),
+		PolicyArn: aws.String(policyArn),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to detach policy %s from role %s: %w", policyArn, roleName, err)
+	}
+
+	return nil
+}
+
 // DeleteInlinePolicies deletes all inline policies from a role
 func (c *AWSClient) DeleteInlinePolicies(ctx context.Context, roleName string) error {
 	paginator := iam.NewListRolePoliciesPaginator(c.iamClient, &iam.ListRolePoliciesInput{
@@ -284,3 +413,354 @@ func (c *AWSClient) DeleteInlinePolicies(ctx context.Context, roleName string) e
 
 	return nil
 }
+
+// UpdateAssumeRolePolicy replaces the trust policy of a role
+func (c *AWSClient) UpdateAssumeRolePolicy(ctx context.Context, roleName, document string) error {
+	_, err := c.iamClient.UpdateAssumeRolePolicy(ctx, &iam.UpdateAssumeRolePolicyInput{
+		RoleName:       aws.String(roleName),
+		PolicyDocument: aws.String(document),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update trust policy for role %s: %w", roleName, err)
+	}
+
+	return nil
+}
+
+// GetRolePolicy returns the document of an inline policy of a role, or an
+// empty string if the role has no such policy
+func (c *AWSClient) GetRolePolicy(ctx context.Context, roleName, policyName string) (string, error) {
+	output, err := c.iamClient.GetRolePolicy(ctx, &iam.GetRolePolicyInput{
+		RoleName:   aws.String(roleName),
+		PolicyName: aws.String(policyName),
+	})
+	var notFound *types.NoSuchEntityException
+	if errors.As(err, &notFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get inline policy %s of role %s: %w", policyName, roleName, err)
+	}
+
+	return decodeDocument(output.PolicyDocument)
+}
+
+// PutRolePolicy creates or replaces an inline policy of a role
+func (c *AWSClient) PutRolePolicy(ctx context.Context, roleName, policyName, document string) error {
+	_, err := c.iamClient.PutRolePolicy(ctx, &iam.PutRolePolicyInput{
+		RoleName:       aws.String(roleName),
+		PolicyName:     aws.String(policyName),
+		PolicyDocument: aws.String(document),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put inline policy %s on role %s: %w", policyName, roleName, err)
+	}
+
+	return nil
+}
+
+// DeleteRolePolicy deletes a single inline policy from a role
+func (c *AWSClient) DeleteRolePolicy(ctx context.Context, roleName, policyName string) error {
+	_, err := c.iamClient.DeleteRolePolicy(ctx, &iam.DeleteRolePolicyInput{
+		RoleName:   aws.String(roleName),
+		PolicyName: aws.String(policyName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete inline policy %s from role %s: %w", policyName, roleName, err)
+	}
+
+	return nil
+}
+
+// PutRolePermissionsBoundary sets the permissions boundary of a role
+func (c *AWSClient) PutRolePermissionsBoundary(ctx context.Context, roleName, boundaryArn string) error {
+	_, err := c.iamClient.PutRolePermissionsBoundary(ctx, &iam.PutRolePermissionsBoundaryInput{
+		RoleName:            aws.String(roleName),
+		PermissionsBoundary: aws.String(boundaryArn),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set permissions boundary of role %s: %w", roleName, err)
+	}
+
+	return nil
+}
+
+// DeleteRolePermissionsBoundary removes the permissions boundary of a role
+func (c *AWSClient) DeleteRolePermissionsBoundary(ctx context.Context, roleName string) error {
+	_, err := c.iamClient.DeleteRolePermissionsBoundary(ctx, &iam.DeleteRolePermissionsBoundaryInput{
+		RoleName: aws.String(roleName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove permissions boundary of role %s: %w", roleName, err)
+	}
+
+	return nil
+}
+
+// GetPolicy returns a managed policy with the document of its default
+// version, or nil if it does not exist
+func (c *AWSClient) GetPolicy(ctx context.Context, policyArn string) (*Policy, error) {
+	output, err := c.iamClient.GetPolicy(ctx, &iam.GetPolicyInput{PolicyArn: aws.String(policyArn)})
+	var notFound *types.NoSuchEntityException
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy %s: %w", policyArn, err)
+	}
+
+	version, err := c.iamClient.GetPolicyVersion(ctx, &iam.GetPolicyVersionInput{
+		PolicyArn: aws.String(policyArn),
+		VersionId: output.Policy.DefaultVersionId,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default version of policy %s: %w", policyArn, err)
+	}
+
+	document, err := decodeDocument(version.PolicyVersion.Document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode policy %s: %w", policyArn, err)
+	}
+
+	return &Policy{
+		Name:     aws.ToString(output.Policy.PolicyName),
+		Arn:      policyArn,
+		Document: document,
+	}, nil
+}
+
+// CreatePolicy creates a customer managed policy and returns its ARN
+func (c *AWSClient) CreatePolicy(ctx context.Context, name, path, document string) (string, error) {
+	input := &iam.CreatePolicyInput{
+		PolicyName:     aws.String(name),
+		PolicyDocument: aws.String(document),
+	}
+	if path != "" {
+		input.Path = aws.String(path)
+	}
+
+	output, err := c.iamClient.CreatePolicy(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to create policy %s: %w", name, err)
+	}
+
+	return aws.ToString(output.Policy.Arn), nil
+}
+
+// GetAccountID returns the ID of the account the client works in
+func (c *AWSClient) GetAccountID(ctx context.Context) (string, error) {
+	output, err := c.stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get caller identity: %w", err)
+	}
+
+	return aws.ToString(output.Account), nil
+}
+
+// ListOpenIDConnectProviders returns the ARNs of all OIDC providers in the account
+func (c *AWSClient) ListOpenIDConnectProviders(ctx context.Context) ([]string, error) {
+	output, err := c.iamClient.ListOpenIDConnectProviders(ctx, &iam.ListOpenIDConnectProvidersInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list OIDC providers: %w", err)
+	}
+
+	arns := make([]string, 0, len(output.OpenIDConnectProviderList))
+	for _, provider := range output.OpenIDConnectProviderList {
+		arns = append(arns, aws.ToString(provider.Arn))
+	}
+
+	return arns, nil
+}
+
+// GenerateServiceLastAccessedDetails starts an action-level service last
+// accessed report
+func (c *AWSClient) GenerateServiceLastAccessedDetails(ctx context.Context, arn string) (string, error) {
+	output, err := c.iamClient.GenerateServiceLastAccessedDetails(ctx, &iam.GenerateServiceLastAccessedDetailsInput{
+		Arn:         aws.String(arn),
+		Granularity: types.AccessAdvisorUsageGranularityTypeActionLevel,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate service last accessed details for %s: %w", arn, err)
+	}
+
+	return aws.ToString(output.JobId), nil
+}
+
+// GetServiceLastAccessedDetails returns the status of a service last
+// accessed report and, once completed, all of its pages
+func (c *AWSClient) GetServiceLastAccessedDetails(ctx context.Context, jobID string) (*ServiceLastAccessedJob, error) {
+	job := &ServiceLastAccessedJob{}
+	input := &iam.GetServiceLastAccessedDetailsInput{JobId: aws.String(jobID)}
+	for {
+		output, err := c.iamClient.GetServiceLastAccessedDetails(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service last accessed details for job %s: %w", jobID, err)
+		}
+
+		job.Status = string(output.JobStatus)
+		if output.Error != nil {
+			job.Error = aws.ToString(output.Error.Message)
+		}
+		if output.JobStatus != types.JobStatusTypeCompleted {
+			return job, nil
+		}
+
+		for _, s := range output.ServicesLastAccessed {
+			service := ServiceLastAccessed{
+				ServiceNamespace: aws.ToString(s.ServiceNamespace),
+				ServiceName:      aws.ToString(s.ServiceName),
+				LastAccessed:     s.LastAuthenticated,
+			}
+			for _, action := range s.TrackedActionsLastAccessed {
+				service.Actions = append(service.Actions, ActionLastAccessed{
+					ActionName:   aws.ToString(action.ActionName),
+					LastAccessed: action.LastAccessedTime,
+				})
+			}
+			job.Services = append(job.Services, service)
+		}
+
+		if !output.IsTruncated {
+			return job, nil
+		}
+		input.Marker = output.Marker
+	}
+}
+
+// GetInventory returns all roles with their policies, and the documents of
+// the managed policies attached to them
+func (c *AWSClient) GetInventory(ctx context.Context) (*Inventory, error) {
+	inventory := &Inventory{
+		Roles:    make([]Role, 0, 100),
+		Policies: make(map[string]Policy),
+	}
+
+	paginator := iam.NewGetAccountAuthorizationDetailsPaginator(c.iamClient, &iam.GetAccountAuthorizationDetailsInput{
+		Filter: []types.EntityType{
+			types.EntityTypeRole,
+			types.EntityTypeLocalManagedPolicy,
+			types.EntityTypeAWSManagedPolicy,
+		},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get account authorization details: %w", err)
+		}
+
+		for _, r := range output.RoleDetailList {
+			role, err := roleFromDetail(r)
+			if err != nil {
+				return nil, err
+			}
+			inventory.Roles = append(inventory.Roles, role)
+		}
+
+		for _, p := range output.Policies {
+			managed := Policy{
+				Name: aws.ToString(p.PolicyName),
+				Arn:  aws.ToString(p.Arn),
+			}
+			for _, version := range p.PolicyVersionList {
+				if !version.IsDefaultVersion {
+					continue
+				}
+				document, err := decodeDocument(version.Document)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode policy %s: %w", managed.Arn, err)
+				}
+				managed.Document = document
+			}
+			inventory.Policies[managed.Arn] = managed
+		}
+	}
+
+	// Authorization details omit the description and session duration
+	attributes := make(map[string]types.Role, len(inventory.Roles))
+	listPaginator := iam.NewListRolesPaginator(c.iamClient, &iam.ListRolesInput{})
+	for listPaginator.HasMorePages() {
+		output, err := listPaginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list roles: %w", err)
+		}
+		for _, r := range output.Roles {
+			attributes[aws.ToString(r.Arn)] = r
+		}
+	}
+
+	for i, role := range inventory.Roles {
+		if r, ok := attributes[role.Arn]; ok {
+			inventory.Roles[i].Description = aws.ToString(r.Description)
+			inventory.Roles[i].MaxSessionDuration = aws.ToInt32(r.MaxSessionDuration)
+		}
+	}
+
+	return inventory, nil
+}
+
+// roleFromDetail converts the authorization details of a role to our Role type
+func roleFromDetail(r types.RoleDetail) (Role, error) {
+	role := Role{
+		Name:       aws.ToString(r.RoleName),
+		Arn:        aws.ToString(r.Arn),
+		Path:       aws.ToString(r.Path),
+		CreateDate: aws.ToTime(r.CreateDate),
+	}
+
+	if r.RoleLastUsed != nil {
+		role.LastUsed = r.RoleLastUsed.LastUsedDate
+	}
+
+	trustPolicy, err := decodeDocument(r.AssumeRolePolicyDocument)
+	if err != nil {
+		return role, fmt.Errorf("failed to decode trust policy for role %s: %w", role.Name, err)
+	}
+	role.TrustPolicy = trustPolicy
+
+	for _, inline := range r.RolePolicyList {
+		document, err := decodeDocument(inline.PolicyDocument)
+		if err != nil {
+			return role, fmt.Errorf("failed to decode inline policy %s for role %s: %w", aws.ToString(inline.PolicyName), role.Name, err)
+		}
+		role.InlinePolicies = append(role.InlinePolicies, Policy{
+			Name:     aws.ToString(inline.PolicyName),
+			IsInline: true,
+			Document: document,
+		})
+	}
+
+	for _, attached := range r.AttachedManagedPolicies {
+		role.AttachedPolicies = append(role.AttachedPolicies, Policy{
+			Name: aws.ToString(attached.PolicyName),
+			Arn:  aws.ToString(attached.PolicyArn),
+		})
+	}
+
+	if r.PermissionsBoundary != nil {
+		role.PermissionsBoundary = aws.ToString(r.PermissionsBoundary.PermissionsBoundaryArn)
+	}
+
+	for _, profile := range r.InstanceProfileList {
+		role.InstanceProfiles = append(role.InstanceProfiles, InstanceProfile{
+			Name: aws.ToString(profile.InstanceProfileName),
+			Path: aws.ToString(profile.Path),
+		})
+	}
+
+	if len(r.Tags) > 0 {
+		role.Tags = make(map[string]string, len(r.Tags))
+		for _, tag := range r.Tags {
+			role.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+
+	return role, nil
+}
+
+// decodeDocument decodes a URL-encoded policy document returned by the IAM API
+func decodeDocument(document *string) (string, error) {
+	if document == nil {
+		return "", nil
+	}
+	return url.PathUnescape(*document)
+}
@@ -0,0 +1,156 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// Tags CloudFormation sets on the resources it creates
+const (
+	StackNameTag = "aws:cloudformation:stack-name"
+	StackIDTag   = "aws:cloudformation:stack-id"
+)
+
+// StackOwnership records the CloudFormation stack that created a role
+type StackOwnership struct {
+	StackName string
+	StackID   string `json:",omitempty"`
+
+	// StackStatus is the status of the stack, empty if it no longer exists
+	// or could not be looked up
+	StackStatus string `json:",omitempty"`
+
+	// Orphaned is set when the stack has been deleted, so nothing manages
+	// the role any more
+	Orphaned bool `json:",omitempty"`
+}
+
+// String describes the ownership for output
+func (s StackOwnership) String() string {
+	if s.Orphaned {
+		return "orphaned from stack " + s.StackName
+	}
+	return "managed by stack " + s.StackName
+}
+
+// StackClient defines the interface for CloudFormation operations
+type StackClient interface {
+	// GetStackStatus returns the status of a stack given its name or ID, or
+	// an empty string if the stack does not exist
+	GetStackStatus(ctx context.Context, stack string) (string, error)
+}
+
+// For testing
+var testStackClient StackClient
+
+// SetTestStackClient sets a test CloudFormation client for unit testing
+func SetTestStackClient(client StackClient) {
+	testStackClient = client
+}
+
+// ClearTestStackClient clears the test CloudFormation client after tests
+func ClearTestStackClient() {
+	testStackClient = nil
+}
+
+// CloudFormationClient implements the StackClient interface
+type CloudFormationClient struct {
+	client *cloudformation.Client
+}
+
+// NewStackClient creates a new CloudFormation client with the specified
+// profile and region
+func NewStackClient(ctx context.Context, profile, region string) (StackClient, error) {
+	// If we're in test mode, return the test client
+	if testStackClient != nil {
+		return testStackClient, nil
+	}
+
+	cfg, err := loadConfig(ctx, profile, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CloudFormationClient{client: cloudformation.NewFromConfig(cfg)}, nil
+}
+
+// GetStackStatus returns the status of a stack. Roles are global but
+// stacks are regional, so a stack given by ID is looked up in the region
+// of its ARN. Deleted stacks are only returned when looked up by ID, and
+// are reported as not existing.
+func (c *CloudFormationClient) GetStackStatus(ctx context.Context, stack string) (string, error) {
+	output, err := c.client.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{StackName: aws.String(stack)}, func(o *cloudformation.Options) {
+		if region := stackRegion(stack); region != "" {
+			o.Region = region
+		}
+	})
+	if hasErrorCode(err, "ValidationError") && strings.Contains(err.Error(), "does not exist") {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to describe stack %s: %w", stack, err)
+	}
+
+	if len(output.Stacks) == 0 || output.Stacks[0].StackStatus == types.StackStatusDeleteComplete {
+		return "", nil
+	}
+	return string(output.Stacks[0].StackStatus), nil
+}
+
+// AttachStackOwnership sets Stack on every role tagged by CloudFormation,
+// looking up each stack once. Roles whose stack cannot be looked up are
+// treated as managed, and the lookup errors are returned keyed by stack.
+func AttachStackOwnership(ctx context.Context, client StackClient, roles []Role) map[string]error {
+	type lookup struct {
+		status string
+		err    error
+	}
+	lookups := make(map[string]lookup)
+	failures := make(map[string]error)
+
+	for i := range roles {
+		name := roles[i].Tags[StackNameTag]
+		if name == "" {
+			continue
+		}
+		ownership := &StackOwnership{StackName: name, StackID: roles[i].Tags[StackIDTag]}
+
+		stack := name
+		if ownership.StackID != "" {
+			stack = ownership.StackID
+		}
+		result, ok := lookups[stack]
+		if !ok {
+			result.status, result.err = client.GetStackStatus(ctx, stack)
+			lookups[stack] = result
+		}
+
+		if result.err != nil {
+			failures[name] = result.err
+		} else {
+			ownership.StackStatus = result.status
+			ownership.Orphaned = result.status == ""
+		}
+		roles[i].Stack = ownership
+	}
+
+	return failures
+}
+
+// stackRegion returns the region of a stack ID, or an empty string for a
+// stack name
+func stackRegion(stack string) string {
+	if !strings.HasPrefix(stack, "arn:") {
+		return ""
+	}
+	parts := strings.SplitN(stack, ":", 5)
+	if len(parts) < 5 {
+		return ""
+	}
+	return parts[3]
+}
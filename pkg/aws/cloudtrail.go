@@ -0,0 +1,364 @@
+package aws
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+)
+
+// EventHistoryDays is how far back CloudTrail event history goes
+const EventHistoryDays = 90
+
+// trailRegion is the region CloudTrail records IAM events in
+const trailRegion = "us-east-1"
+
+// Sources a role can be created from
+const (
+	CreatorSourceConsole        = "console"
+	CreatorSourceCloudFormation = "cloudformation"
+	CreatorSourceTerraform      = "terraform"
+	CreatorSourcePulumi         = "pulumi"
+	CreatorSourceCLI            = "aws-cli"
+	CreatorSourceSDK            = "sdk"
+	CreatorSourceService        = "service"
+	CreatorSourceOther          = "other"
+)
+
+// creatorSources maps markers in the user agent of a CreateRole event to
+// its source, in order. Terraform and the CLI come before the SDKs they
+// embed in their user agent.
+var creatorSources = []struct {
+	source  string
+	markers []string
+}{
+	{CreatorSourceCloudFormation, []string{"cloudformation.amazonaws.com"}},
+	{CreatorSourceTerraform, []string{"terraform", "hashicorp"}},
+	{CreatorSourcePulumi, []string{"pulumi"}},
+	{CreatorSourceConsole, []string{"console.amazonaws.com", "signin.amazonaws.com"}},
+	{CreatorSourceCLI, []string{"aws-cli/"}},
+	{CreatorSourceSDK, []string{"aws-sdk-", "boto3", "botocore"}},
+}
+
+// CreateRoleEvent is a successful CreateRole call recorded by CloudTrail
+type CreateRoleEvent struct {
+	RoleName  string
+	RoleArn   string
+	EventID   string
+	EventTime time.Time
+
+	// Principal is the ARN of the identity that made the call, or the
+	// service that made it on its own behalf
+	Principal string
+	UserAgent string
+	InvokedBy string
+
+	// FromConsole is set when the call used console session credentials
+	FromConsole bool
+}
+
+// Source classifies how the role was created, from the calling service
+// and user agent
+func (e CreateRoleEvent) Source() string {
+	if e.FromConsole {
+		return CreatorSourceConsole
+	}
+
+	agent := strings.ToLower(e.InvokedBy + " " + e.UserAgent)
+	for _, candidate := range creatorSources {
+		for _, marker := range candidate.markers {
+			if strings.Contains(agent, marker) {
+				return candidate.source
+			}
+		}
+	}
+	if strings.HasSuffix(e.InvokedBy, ".amazonaws.com") {
+		return CreatorSourceService
+	}
+	return CreatorSourceOther
+}
+
+// RoleCreator records who created a role, from its CreateRole event
+type RoleCreator struct {
+	Principal string     `json:",omitempty"`
+	Source    string     `json:",omitempty"`
+	UserAgent string     `json:",omitempty"`
+	EventID   string     `json:",omitempty"`
+	EventTime *time.Time `json:",omitempty"`
+
+	// NotFound explains why no CreateRole event was found for the role
+	NotFound string `json:",omitempty"`
+}
+
+// String describes the creator for output
+func (c RoleCreator) String() string {
+	if c.NotFound != "" {
+		return "not found (" + c.NotFound + ")"
+	}
+	return fmt.Sprintf("%s (%s)", c.Principal, c.Source)
+}
+
+// TrailClient defines the interface for CloudTrail operations
+type TrailClient interface {
+	// LookupCreateRoleEvents returns the CreateRole events of the event
+	// history since the given time
+	LookupCreateRoleEvents(ctx context.Context, since time.Time) ([]CreateRoleEvent, error)
+}
+
+// For testing
+var testTrailClient TrailClient
+
+// SetTestTrailClient sets a test CloudTrail client for unit testing
+func SetTestTrailClient(client TrailClient) {
+	testTrailClient = client
+}
+
+// ClearTestTrailClient clears the test CloudTrail client after tests
+func ClearTestTrailClient() {
+	testTrailClient = nil
+}
+
+// CloudTrailClient implements the TrailClient interface
+type CloudTrailClient struct {
+	client *cloudtrail.Client
+}
+
+// NewTrailClient creates a new CloudTrail client with the specified
+// profile. IAM is a global service whose events CloudTrail records in
+// us-east-1, so the client always uses that region.
+func NewTrailClient(ctx context.Context, profile string) (TrailClient, error) {
+	// If we're in test mode, return the test client
+	if testTrailClient != nil {
+		return testTrailClient, nil
+	}
+
+	cfg, err := loadConfig(ctx, profile, trailRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CloudTrailClient{client: cloudtrail.NewFromConfig(cfg)}, nil
+}
+
+// LookupCreateRoleEvents returns the CreateRole events of the event history
+// since the given time
+func (c *CloudTrailClient) LookupCreateRoleEvents(ctx context.Context, since time.Time) ([]CreateRoleEvent, error) {
+	var events []CreateRoleEvent
+	paginator := cloudtrail.NewLookupEventsPaginator(c.client, &cloudtrail.LookupEventsInput{
+		LookupAttributes: []types.LookupAttribute{{
+			AttributeKey:   types.LookupAttributeKeyEventName,
+			AttributeValue: aws.String("CreateRole"),
+		}},
+		StartTime: aws.Time(since),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up CreateRole events: %w", err)
+		}
+		for _, event := range output.Events {
+			parsed, ok, err := ParseCreateRoleEvent([]byte(aws.ToString(event.CloudTrailEvent)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse event %s: %w", aws.ToString(event.EventId), err)
+			}
+			if ok {
+				events = append(events, parsed)
+			}
+		}
+	}
+	return events, nil
+}
+
+// trailRecord is the part of a CloudTrail record hawkling reads
+type trailRecord struct {
+	EventTime                    time.Time `json:"eventTime"`
+	EventID                      string    `json:"eventID"`
+	EventSource                  string    `json:"eventSource"`
+	EventName                    string    `json:"eventName"`
+	ErrorCode                    string    `json:"errorCode"`
+	UserAgent                    string    `json:"userAgent"`
+	SessionCredentialFromConsole string    `json:"sessionCredentialFromConsole"`
+	UserIdentity                 struct {
+		Type      string `json:"type"`
+		Arn       string `json:"arn"`
+		InvokedBy string `json:"invokedBy"`
+	} `json:"userIdentity"`
+	RequestParameters struct {
+		RoleName string `json:"roleName"`
+	} `json:"requestParameters"`
+	ResponseElements struct {
+		Role struct {
+			Arn string `json:"arn"`
+		} `json:"role"`
+	} `json:"responseElements"`
+}
+
+// ParseCreateRoleEvent parses a CloudTrail record. It reports false for
+// records that are not a successful IAM CreateRole call.
+func ParseCreateRoleEvent(record []byte) (CreateRoleEvent, bool, error) {
+	// Read the event name first, since the parameters of other events
+	// have other shapes
+	var header struct {
+		EventSource string `json:"eventSource"`
+		EventName   string `json:"eventName"`
+		ErrorCode   string `json:"errorCode"`
+	}
+	if err := json.Unmarshal(record, &header); err != nil {
+		return CreateRoleEvent{}, false, err
+	}
+	if header.EventSource != "iam.amazonaws.com" || header.EventName != "CreateRole" || header.ErrorCode != "" {
+		return CreateRoleEvent{}, false, nil
+	}
+
+	var parsed trailRecord
+	if err := json.Unmarshal(record, &parsed); err != nil {
+		return CreateRoleEvent{}, false, err
+	}
+
+	principal := parsed.UserIdentity.Arn
+	if principal == "" {
+		principal = parsed.UserIdentity.InvokedBy
+	}
+	return CreateRoleEvent{
+		RoleName:    parsed.RequestParameters.RoleName,
+		RoleArn:     parsed.ResponseElements.Role.Arn,
+		EventID:     parsed.EventID,
+		EventTime:   parsed.EventTime,
+		Principal:   principal,
+		UserAgent:   parsed.UserAgent,
+		InvokedBy:   parsed.UserIdentity.InvokedBy,
+		FromConsole: parsed.SessionCredentialFromConsole == "true",
+	}, true, nil
+}
+
+// LoadCreateRoleEvents reads the CreateRole events of CloudTrail log files,
+// as delivered to S3. The path is a log file or a directory searched
+// recursively for *.json and *.json.gz files.
+func LoadCreateRoleEvents(path string) ([]CreateRoleEvent, error) {
+	var files []string
+	err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && (strings.HasSuffix(file, ".json") || strings.HasSuffix(file, ".json.gz")) {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CloudTrail logs %s: %w", path, err)
+	}
+
+	var events []CreateRoleEvent
+	for _, file := range files {
+		found, err := loadLogFile(file)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, found...)
+	}
+	return events, nil
+}
+
+// loadLogFile reads the CreateRole events of one CloudTrail log file
+func loadLogFile(path string) ([]CreateRoleEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CloudTrail log %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CloudTrail log %s: %w", path, err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	var log struct {
+		Records []json.RawMessage
+	}
+	if err := json.NewDecoder(reader).Decode(&log); err != nil {
+		return nil, fmt.Errorf("failed to parse CloudTrail log %s: %w", path, err)
+	}
+
+	var events []CreateRoleEvent
+	for _, record := range log.Records {
+		event, ok, err := ParseCreateRoleEvent(record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CloudTrail log %s: %w", path, err)
+		}
+		if ok {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// creatorSlack allows for the CreateRole event being recorded slightly
+// apart from the creation date IAM reports
+const creatorSlack = 5 * time.Minute
+
+// AttachCreators sets Creator on every role from its CreateRole event,
+// matched by ARN or else by name. When the creation date of the role is
+// known, only events within creatorSlack of it match, so an earlier role of
+// the same name is not taken for its creator. Roles without an event get a
+// Creator saying why; since is the start of the events read, zero when the
+// events are not limited in time.
+func AttachCreators(roles []Role, events []CreateRoleEvent, since time.Time) {
+	for i := range roles {
+		role := &roles[i]
+
+		var latest *CreateRoleEvent
+		otherRole := false
+		for j := range events {
+			event := &events[j]
+			if event.RoleArn != role.Arn && (event.RoleArn != "" || event.RoleName != role.Name) {
+				continue
+			}
+			if !role.CreateDate.IsZero() {
+				if gap := event.EventTime.Sub(role.CreateDate); gap > creatorSlack || gap < -creatorSlack {
+					otherRole = true
+					continue
+				}
+			}
+			if latest == nil || event.EventTime.After(latest.EventTime) {
+				latest = event
+			}
+		}
+
+		if latest == nil {
+			reason := "no CreateRole event found"
+			if otherRole {
+				reason = fmt.Sprintf("no CreateRole event within %s of its creation date, only events of another role of the same name", creatorSlack)
+			}
+			if !since.IsZero() && !role.CreateDate.IsZero() && role.CreateDate.Before(since) {
+				reason = fmt.Sprintf("created before the CloudTrail event history, which starts %s", since.Format("2006-01-02"))
+			}
+			role.Creator = &RoleCreator{NotFound: reason}
+			continue
+		}
+
+		eventTime := latest.EventTime
+		role.Creator = &RoleCreator{
+			Principal: latest.Principal,
+			Source:    latest.Source(),
+			UserAgent: latest.UserAgent,
+			EventID:   latest.EventID,
+			EventTime: &eventTime,
+		}
+	}
+}
@@ -1,10 +1,21 @@
 package aws
 
+import (
+	"path"
+	"strings"
+)
+
 // FilterOptions contains various filtering criteria
 type FilterOptions struct {
-	Days       int
-	OnlyUsed   bool
-	OnlyUnused bool
+	Days        int
+	OnlyUsed    bool
+	OnlyUnused  bool
+	PathPrefix  string
+	NamePattern string
+
+	// RequireAnalyzerAgreement selects a role as unused only if Access
+	// Analyzer also reports it as unused
+	RequireAnalyzerAgreement bool
 }
 
 // FilterRoles filters roles based on specified options
@@ -15,6 +26,9 @@ type FilterOptions struct {
 // - Days>0: Show roles not used in the specified days
 // - Days>0 + OnlyUsed: Show roles that have been used at least once but not in the specified days
 // - Days>0 + OnlyUnused: Show roles that have never been used (days parameter doesn't affect these)
+// - PathPrefix: Show only roles whose path starts with the prefix
+// - NamePattern: Show only roles whose name matches the glob pattern
+// - RequireAnalyzerAgreement: Show unused roles only if Access Analyzer also reports them unused
 func FilterRoles(roles []Role, options FilterOptions) []Role {
 	// If both filters are enabled, return empty list (logical conflict)
 	if options.OnlyUsed && options.OnlyUnused {
@@ -39,6 +53,21 @@ func FilterRoles(roles []Role, options FilterOptions) []Role {
 			continue
 		}
 
+		if options.RequireAnalyzerAgreement && (options.Days > 0 || options.OnlyUnused) &&
+			(role.UnusedAccess == nil || !role.UnusedAccess.UnusedRole) {
+			continue
+		}
+
+		if options.PathPrefix != "" && !strings.HasPrefix(role.Path, options.PathPrefix) {
+			continue
+		}
+
+		if options.NamePattern != "" {
+			if matched, _ := path.Match(options.NamePattern, role.Name); !matched {
+				continue
+			}
+		}
+
 		filteredRoles = append(filteredRoles, role)
 	}
 
@@ -9,6 +9,10 @@ import (
 type IAMClient interface {
 	RoleManager
 	PolicyManager
+	ProviderManager
+	InventoryManager
+	AccessAdvisor
+	AccountManager
 }
 
 // RoleManager handles IAM role operations
@@ -21,6 +25,19 @@ type RoleManager interface {
 
 	// DeleteRole deletes an IAM role
 	DeleteRole(ctx context.Context, roleName string) error
+
+	// CreateRole creates a role with the path, description, trust policy,
+	// maximum session duration, permissions boundary and tags of role
+	CreateRole(ctx context.Context, role Role) error
+
+	// CreateInstanceProfile creates an instance profile
+	CreateInstanceProfile(ctx context.Context, name, path string) error
+
+	// AddRoleToInstanceProfile adds a role to an instance profile
+	AddRoleToInstanceProfile(ctx context.Context, profileName, roleName string) error
+
+	// ListRoleTags returns the tags of a role
+	ListRoleTags(ctx context.Context, roleName string) (map[string]string, error)
 }
 
 // PolicyManager handles IAM policy operations
@@ -30,6 +47,102 @@ type PolicyManager interface {
 
 	// DeleteInlinePolicies deletes all inline policies from a role
 	DeleteInlinePolicies(ctx context.Context, roleName string) error
+
+	// AttachRolePolicy attaches a managed policy to a role
+	AttachRolePolicy(ctx context.Context, roleName, policyArn string) error
+
+	// DetachRolePolicy detaches a single managed policy from a role
+	DetachRolePolicy(ctx context.Context, roleName, policyArn string) error
+
+	// UpdateAssumeRolePolicy replaces the trust policy of a role
+	UpdateAssumeRolePolicy(ctx context.Context, roleName, document string) error
+
+	// GetRolePolicy returns the document of an inline policy of a role, or
+	// an empty string if the role has no such policy
+	GetRolePolicy(ctx context.Context, roleName, policyName string) (string, error)
+
+	// PutRolePolicy creates or replaces an inline policy of a role
+	PutRolePolicy(ctx context.Context, roleName, policyName, document string) error
+
+	// DeleteRolePolicy deletes a single inline policy from a role
+	DeleteRolePolicy(ctx context.Context, roleName, policyName string) error
+
+	// PutRolePermissionsBoundary sets the permissions boundary of a role
+	PutRolePermissionsBoundary(ctx context.Context, roleName, boundaryArn string) error
+
+	// DeleteRolePermissionsBoundary removes the permissions boundary of a role
+	DeleteRolePermissionsBoundary(ctx context.Context, roleName string) error
+
+	// GetPolicy returns a managed policy with the document of its default
+	// version, or nil if it does not exist
+	GetPolicy(ctx context.Context, policyArn string) (*Policy, error)
+
+	// CreatePolicy creates a customer managed policy and returns its ARN
+	CreatePolicy(ctx context.Context, name, path, document string) (string, error)
+}
+
+// ProviderManager handles IAM identity provider operations
+type ProviderManager interface {
+	// ListOpenIDConnectProviders returns the ARNs of all OIDC providers in the account
+	ListOpenIDConnectProviders(ctx context.Context) ([]string, error)
+}
+
+// InventoryManager handles bulk retrieval of authorization details
+type InventoryManager interface {
+	// GetInventory returns all roles with their policies, and the documents
+	// of the managed policies attached to them
+	GetInventory(ctx context.Context) (*Inventory, error)
+}
+
+// AccessAdvisor handles IAM service last accessed reports
+type AccessAdvisor interface {
+	// GenerateServiceLastAccessedDetails starts an action-level service last
+	// accessed report for an IAM entity and returns its job ID
+	GenerateServiceLastAccessedDetails(ctx context.Context, arn string) (string, error)
+
+	// GetServiceLastAccessedDetails returns the status of a report job and,
+	// once it has completed, the services the entity is granted
+	GetServiceLastAccessedDetails(ctx context.Context, jobID string) (*ServiceLastAccessedJob, error)
+}
+
+// AccountManager handles the identity of the account
+type AccountManager interface {
+	// GetAccountID returns the ID of the account the client works in
+	GetAccountID(ctx context.Context) (string, error)
+}
+
+// Service last accessed job statuses
+const (
+	JobStatusInProgress = "IN_PROGRESS"
+	JobStatusCompleted  = "COMPLETED"
+	JobStatusFailed     = "FAILED"
+)
+
+// ServiceLastAccessedJob is the status and result of a service last
+// accessed report job
+type ServiceLastAccessedJob struct {
+	Status   string
+	Error    string                `json:",omitempty"`
+	Services []ServiceLastAccessed `json:",omitempty"`
+}
+
+// ServiceLastAccessed reports when an entity last accessed a service it is
+// granted. LastAccessed is nil if the service was never accessed within
+// the tracking period.
+type ServiceLastAccessed struct {
+	ServiceNamespace string
+	ServiceName      string
+	LastAccessed     *time.Time
+
+	// Actions are the tracked actions of the service, for the services where
+	// AWS reports action-level data
+	Actions []ActionLastAccessed `json:",omitempty"`
+}
+
+// ActionLastAccessed reports when an entity last used a tracked action
+type ActionLastAccessed struct {
+	ActionName   string
+	LastAccessed *time.Time
 }
 
 // Policy represents an AWS IAM policy
@@ -37,15 +150,52 @@ type Policy struct {
 	Name     string
 	Arn      string
 	IsInline bool
+	Document string `json:",omitempty"`
+}
+
+// InstanceProfile is an instance profile that passes a role to EC2
+type InstanceProfile struct {
+	Name string
+	Path string
 }
 
 // Role represents an AWS IAM role
 type Role struct {
 	Name        string
 	Arn         string
+	Path        string
 	Description string
 	CreateDate  time.Time
 	LastUsed    *time.Time
+	TrustPolicy string `json:",omitempty"`
+	// MaxSessionDuration is the maximum session duration in seconds
+	MaxSessionDuration int32 `json:",omitempty"`
+
+	// Authorization details, populated from an Inventory
+	InlinePolicies      []Policy          `json:",omitempty"`
+	AttachedPolicies    []Policy          `json:",omitempty"`
+	PermissionsBoundary string            `json:",omitempty"`
+	Tags                map[string]string `json:",omitempty"`
+	InstanceProfiles    []InstanceProfile `json:",omitempty"`
+
+	// Risk assessment, populated when roles are scored
+	RiskScore   int      `json:",omitempty"`
+	RiskFactors []string `json:",omitempty"`
+
+	// UnusedAccess is set when Access Analyzer unused-access findings were read
+	UnusedAccess *UnusedAccess `json:",omitempty"`
+
+	// Stack is set for roles created by CloudFormation once their stack
+	// has been looked up
+	Stack *StackOwnership `json:",omitempty"`
+
+	// TerraformAddress is the address of the resource that declares the
+	// role, set when Terraform state was read
+	TerraformAddress string `json:",omitempty"`
+
+	// Creator is set when CloudTrail was searched for the role's CreateRole
+	// event, and says so when none was found
+	Creator *RoleCreator `json:",omitempty"`
 }
 
 // IsUnused checks if a role is unused for the specified number of days
@@ -0,0 +1,116 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"hawkling/pkg/policy"
+)
+
+// Inventory holds the authorization details of all roles in an account,
+// so that policies can be analyzed offline
+type Inventory struct {
+	Roles []Role
+	// Policies holds the managed policies attached to roles, keyed by ARN
+	Policies map[string]Policy
+}
+
+// LoadInventory reads an inventory saved with SaveInventory
+func LoadInventory(path string) (*Inventory, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inventory %s: %w", path, err)
+	}
+
+	var inventory Inventory
+	if err := json.Unmarshal(content, &inventory); err != nil {
+		return nil, fmt.Errorf("failed to parse inventory %s: %w", path, err)
+	}
+
+	return &inventory, nil
+}
+
+// SaveInventory writes an inventory as JSON
+func SaveInventory(path string, inventory *Inventory) error {
+	content, err := json.MarshalIndent(inventory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode inventory: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(content, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write inventory %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// FindRole returns the role with the given name or ARN, or nil if not found
+func (inv *Inventory) FindRole(nameOrArn string) *Role {
+	for i, role := range inv.Roles {
+		if role.Name == nameOrArn || role.Arn == nameOrArn {
+			return &inv.Roles[i]
+		}
+	}
+	return nil
+}
+
+// PolicySet returns the parsed identity policies and permissions boundary
+// of a role
+func (inv *Inventory) PolicySet(role Role) (policy.PolicySet, error) {
+	var set policy.PolicySet
+
+	for _, inline := range role.InlinePolicies {
+		doc, err := policy.Parse(inline.Document)
+		if err != nil {
+			return set, fmt.Errorf("inline policy %s of role %s: %w", inline.Name, role.Name, err)
+		}
+		set.Identity = append(set.Identity, policy.Source{Name: inline.Name, Document: doc})
+	}
+
+	for _, attached := range role.AttachedPolicies {
+		doc, err := inv.managedDocument(attached.Arn)
+		if err != nil {
+			return set, fmt.Errorf("role %s: %w", role.Name, err)
+		}
+		set.Identity = append(set.Identity, policy.Source{Name: attached.Name, Document: doc})
+	}
+
+	if role.PermissionsBoundary != "" {
+		doc, err := inv.managedDocument(role.PermissionsBoundary)
+		if err != nil {
+			return set, fmt.Errorf("permissions boundary of role %s: %w", role.Name, err)
+		}
+		set.Boundary = &policy.Source{Name: inv.Policies[role.PermissionsBoundary].Name, Document: doc}
+	}
+
+	return set, nil
+}
+
+// managedDocument returns the parsed document of a managed policy
+func (inv *Inventory) managedDocument(arn string) (*policy.Document, error) {
+	managed, ok := inv.Policies[arn]
+	if !ok {
+		return nil, fmt.Errorf("managed policy %s not found in inventory", arn)
+	}
+
+	doc, err := policy.Parse(managed.Document)
+	if err != nil {
+		return nil, fmt.Errorf("managed policy %s: %w", arn, err)
+	}
+	return doc, nil
+}
+
+// AttachTags copies the tags of the inventory's roles onto the given roles,
+// matched by ARN, since listing roles does not return their tags
+func (inv *Inventory) AttachTags(roles []Role) {
+	tags := make(map[string]map[string]string, len(inv.Roles))
+	for _, role := range inv.Roles {
+		tags[role.Arn] = role.Tags
+	}
+	for i := range roles {
+		if roles[i].Tags == nil {
+			roles[i].Tags = tags[roles[i].Arn]
+		}
+	}
+}
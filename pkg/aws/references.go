@@ -0,0 +1,174 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/codebuild"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+)
+
+// Reference is a resource that uses a role, so deleting the role would
+// break it
+type Reference struct {
+	RoleArn  string
+	Service  string
+	Resource string
+	Usage    string
+	// Region is the region of the resource, when scanned by region
+	Region string `json:",omitempty"`
+}
+
+// String describes the reference for output
+func (r Reference) String() string {
+	if r.Region != "" {
+		return fmt.Sprintf("%s %s in %s (%s)", r.Service, r.Resource, r.Region, r.Usage)
+	}
+	return fmt.Sprintf("%s %s (%s)", r.Service, r.Resource, r.Usage)
+}
+
+// ReferenceScanner finds the resources of one service that use roles.
+// Scanners list the resources of a region once, so a single scan covers
+// every role being deleted.
+type ReferenceScanner interface {
+	// Service names the scanned service, such as "lambda"
+	Service() string
+
+	// ScanReferences returns every resource of the service that uses a role
+	ScanReferences(ctx context.Context) ([]Reference, error)
+}
+
+// For testing
+var (
+	testReferenceScanners    []ReferenceScanner
+	useTestReferenceScanners bool
+)
+
+// SetTestReferenceScanners sets the reference scanners used instead of the
+// AWS ones for unit testing. No scanners disables reference checks.
+func SetTestReferenceScanners(scanners ...ReferenceScanner) {
+	testReferenceScanners = scanners
+	useTestReferenceScanners = true
+}
+
+// ClearTestReferenceScanners clears the test reference scanners after tests
+func ClearTestReferenceScanners() {
+	testReferenceScanners = nil
+	useTestReferenceScanners = false
+}
+
+// NewReferenceScanners creates the reference scanners of every supported
+// service in each of the given regions, with the specified profile. Roles
+// are global, so without regions every region enabled in the account is
+// scanned.
+func NewReferenceScanners(ctx context.Context, profile, region string, regions []string) ([]ReferenceScanner, error) {
+	// If we're in test mode, return the test scanners
+	if useTestReferenceScanners {
+		return selectRegions(testReferenceScanners, regions), nil
+	}
+
+	cfg, err := loadConfig(ctx, profile, region)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(regions) == 0 {
+		regions, err = ListEnabledRegions(ctx, ec2.NewFromConfig(cfg))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	iamClient := iam.NewFromConfig(cfg)
+	var scanners []ReferenceScanner
+	for _, name := range regions {
+		regional := cfg.Copy()
+		regional.Region = name
+		for _, scanner := range []ReferenceScanner{
+			NewLambdaScanner(lambda.NewFromConfig(regional)),
+			NewECSScanner(ecs.NewFromConfig(regional)),
+			NewEC2Scanner(ec2.NewFromConfig(regional), iamClient),
+			NewStepFunctionsScanner(sfn.NewFromConfig(regional)),
+			NewCodeBuildScanner(codebuild.NewFromConfig(regional)),
+			NewEventBridgeScanner(eventbridge.NewFromConfig(regional)),
+			NewGlueScanner(glue.NewFromConfig(regional), iamClient),
+		} {
+			scanners = append(scanners, RegionScanner{ReferenceScanner: scanner, Region: name})
+		}
+	}
+	return scanners, nil
+}
+
+// ScanReferences runs the scanners concurrently and returns the references
+// to the given roles, keyed by role ARN. Scanners that fail are returned
+// with their error, keyed by service, with the errors of every failed
+// region of a service combined.
+func ScanReferences(ctx context.Context, scanners []ReferenceScanner, roleArns []string) (map[string][]Reference, map[string]error) {
+	wanted := make(map[string]bool, len(roleArns))
+	for _, arn := range roleArns {
+		wanted[arn] = true
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	references := make(map[string][]Reference)
+	failures := make(map[string]error)
+
+	for _, scanner := range scanners {
+		wg.Add(1)
+		go func(scanner ReferenceScanner) {
+			defer wg.Done()
+
+			found, err := scanner.ScanReferences(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if previous := failures[scanner.Service()]; previous != nil {
+					err = fmt.Errorf("%v; %w", previous, err)
+				}
+				failures[scanner.Service()] = err
+				return
+			}
+			for _, reference := range found {
+				if wanted[reference.RoleArn] {
+					references[reference.RoleArn] = append(references[reference.RoleArn], reference)
+				}
+			}
+		}(scanner)
+	}
+	wg.Wait()
+
+	for arn := range references {
+		sort.Slice(references[arn], func(i, j int) bool {
+			return references[arn][i].String() < references[arn][j].String()
+		})
+	}
+
+	return references, failures
+}
+
+// JoinScanFailures combines the errors of failed scanners into one
+// message, in service order
+func JoinScanFailures(failures map[string]error) string {
+	services := make([]string, 0, len(failures))
+	for service := range failures {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	messages := make([]string, 0, len(services))
+	for _, service := range services {
+		messages = append(messages, fmt.Sprintf("%s: %v", service, failures[service]))
+	}
+	return strings.Join(messages, "; ")
+}
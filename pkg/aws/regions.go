@@ -0,0 +1,92 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// RegionsAPI is the part of the EC2 API used to list regions
+type RegionsAPI interface {
+	DescribeRegions(ctx context.Context, params *ec2.DescribeRegionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error)
+}
+
+// ListEnabledRegions returns the regions enabled in the account, in order.
+// Regions the account has not opted in to are left out.
+func ListEnabledRegions(ctx context.Context, client RegionsAPI) ([]string, error) {
+	output, err := client.DescribeRegions(ctx, &ec2.DescribeRegionsInput{AllRegions: aws.Bool(false)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe regions: %w", err)
+	}
+
+	regions := make([]string, 0, len(output.Regions))
+	for _, region := range output.Regions {
+		if name := aws.ToString(region.RegionName); name != "" {
+			regions = append(regions, name)
+		}
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
+
+// RegionScanner scans the resources of one region with a service scanner.
+// Roles are global, so a role is only unused if no region uses it.
+type RegionScanner struct {
+	ReferenceScanner
+	Region string
+}
+
+// ScanReferences returns the references of the region, and names the
+// region in errors
+func (s RegionScanner) ScanReferences(ctx context.Context) ([]Reference, error) {
+	references, err := s.ReferenceScanner.ScanReferences(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Region, err)
+	}
+	for i := range references {
+		if references[i].Region == "" {
+			references[i].Region = s.Region
+		}
+	}
+	return references, nil
+}
+
+// ScannedRegions returns the regions the scanners cover, in order
+func ScannedRegions(scanners []ReferenceScanner) []string {
+	seen := make(map[string]bool)
+	var regions []string
+	for _, scanner := range scanners {
+		regional, ok := scanner.(RegionScanner)
+		if !ok || seen[regional.Region] {
+			continue
+		}
+		seen[regional.Region] = true
+		regions = append(regions, regional.Region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// selectRegions keeps the scanners of the given regions, and scanners not
+// bound to a region. No regions keeps every scanner.
+func selectRegions(scanners []ReferenceScanner, regions []string) []ReferenceScanner {
+	if len(regions) == 0 {
+		return scanners
+	}
+
+	wanted := make(map[string]bool, len(regions))
+	for _, region := range regions {
+		wanted[region] = true
+	}
+	selected := make([]ReferenceScanner, 0, len(scanners))
+	for _, scanner := range scanners {
+		if regional, ok := scanner.(RegionScanner); ok && !wanted[regional.Region] {
+			continue
+		}
+		selected = append(selected, scanner)
+	}
+	return selected
+}
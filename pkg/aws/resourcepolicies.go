@@ -0,0 +1,369 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
+)
+
+// roleArnPattern matches role ARNs anywhere in a policy document, whether
+// as a principal or in a condition such as aws:PrincipalArn
+var roleArnPattern = regexp.MustCompile(`arn:aws[a-z-]*:iam::\d{12}:role/[\w+=,.@/-]+`)
+
+// For testing
+var (
+	testResourcePolicyScanners    []ReferenceScanner
+	useTestResourcePolicyScanners bool
+)
+
+// SetTestResourcePolicyScanners sets the resource policy scanners used
+// instead of the AWS ones for unit testing. No scanners disables the scan.
+func SetTestResourcePolicyScanners(scanners ...ReferenceScanner) {
+	testResourcePolicyScanners = scanners
+	useTestResourcePolicyScanners = true
+}
+
+// ClearTestResourcePolicyScanners clears the test resource policy scanners
+// after tests
+func ClearTestResourcePolicyScanners() {
+	testResourcePolicyScanners = nil
+	useTestResourcePolicyScanners = false
+}
+
+// NewResourcePolicyScanners creates scanners that find role ARNs in the
+// resource-based policies of S3, KMS, SQS, SNS, Secrets Manager and ECR.
+// Unlike the compute references, these grants do not keep a role in use,
+// but they stop working if the role is deleted and recreated. Buckets are
+// listed once for the account; the other services are scanned in each of
+// the given regions, or every enabled region without regions.
+func NewResourcePolicyScanners(ctx context.Context, profile, region string, regions []string) ([]ReferenceScanner, error) {
+	// If we're in test mode, return the test scanners
+	if useTestResourcePolicyScanners {
+		return selectRegions(testResourcePolicyScanners, regions), nil
+	}
+
+	cfg, err := loadConfig(ctx, profile, region)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(regions) == 0 {
+		regions, err = ListEnabledRegions(ctx, ec2.NewFromConfig(cfg))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	scanners := []ReferenceScanner{NewS3PolicyScanner(s3.NewFromConfig(cfg))}
+	for _, name := range regions {
+		regional := cfg.Copy()
+		regional.Region = name
+		for _, scanner := range []ReferenceScanner{
+			NewKMSPolicyScanner(kms.NewFromConfig(regional)),
+			NewSQSPolicyScanner(sqs.NewFromConfig(regional)),
+			NewSNSPolicyScanner(sns.NewFromConfig(regional)),
+			NewSecretsManagerPolicyScanner(secretsmanager.NewFromConfig(regional)),
+			NewECRPolicyScanner(ecr.NewFromConfig(regional)),
+		} {
+			scanners = append(scanners, RegionScanner{ReferenceScanner: scanner, Region: name})
+		}
+	}
+	return scanners, nil
+}
+
+// S3PolicyAPI is the part of the S3 API used to read bucket policies
+type S3PolicyAPI interface {
+	s3.ListBucketsAPIClient
+	GetBucketPolicy(ctx context.Context, params *s3.GetBucketPolicyInput, optFns ...func(*s3.Options)) (*s3.GetBucketPolicyOutput, error)
+}
+
+// S3PolicyScanner finds role ARNs in bucket policies
+type S3PolicyScanner struct {
+	client S3PolicyAPI
+}
+
+// NewS3PolicyScanner creates an S3 bucket policy scanner
+func NewS3PolicyScanner(client S3PolicyAPI) *S3PolicyScanner {
+	return &S3PolicyScanner{client: client}
+}
+
+// Service returns the scanned service
+func (s *S3PolicyScanner) Service() string {
+	return "s3"
+}
+
+// ScanReferences returns the roles named in every bucket policy. Each
+// policy is read in the bucket's own region.
+func (s *S3PolicyScanner) ScanReferences(ctx context.Context) ([]Reference, error) {
+	var references []Reference
+	paginator := s3.NewListBucketsPaginator(s.client, &s3.ListBucketsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list buckets: %w", err)
+		}
+		for _, bucket := range output.Buckets {
+			name := aws.ToString(bucket.Name)
+			policy, err := s.client.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: bucket.Name}, func(o *s3.Options) {
+				if bucket.BucketRegion != nil {
+					o.Region = *bucket.BucketRegion
+				}
+			})
+			if hasErrorCode(err, "NoSuchBucketPolicy") {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to get policy of bucket %s: %w", name, err)
+			}
+			references = appendPolicyReferences(references, aws.ToString(policy.Policy), s.Service(), "arn:aws:s3:::"+name, "bucket policy")
+		}
+	}
+	return references, nil
+}
+
+// KMSPolicyAPI is the part of the KMS API used to read key policies
+type KMSPolicyAPI interface {
+	kms.ListKeysAPIClient
+	GetKeyPolicy(ctx context.Context, params *kms.GetKeyPolicyInput, optFns ...func(*kms.Options)) (*kms.GetKeyPolicyOutput, error)
+}
+
+// KMSPolicyScanner finds role ARNs in key policies
+type KMSPolicyScanner struct {
+	client KMSPolicyAPI
+}
+
+// NewKMSPolicyScanner creates a KMS key policy scanner
+func NewKMSPolicyScanner(client KMSPolicyAPI) *KMSPolicyScanner {
+	return &KMSPolicyScanner{client: client}
+}
+
+// Service returns the scanned service
+func (s *KMSPolicyScanner) Service() string {
+	return "kms"
+}
+
+// ScanReferences returns the roles named in the default policy of every key
+func (s *KMSPolicyScanner) ScanReferences(ctx context.Context) ([]Reference, error) {
+	var references []Reference
+	paginator := kms.NewListKeysPaginator(s.client, &kms.ListKeysInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list keys: %w", err)
+		}
+		for _, key := range output.Keys {
+			policy, err := s.client.GetKeyPolicy(ctx, &kms.GetKeyPolicyInput{KeyId: key.KeyId, PolicyName: aws.String("default")})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get policy of key %s: %w", aws.ToString(key.KeyId), err)
+			}
+			references = appendPolicyReferences(references, aws.ToString(policy.Policy), s.Service(), aws.ToString(key.KeyArn), "key policy")
+		}
+	}
+	return references, nil
+}
+
+// SQSPolicyAPI is the part of the SQS API used to read queue policies
+type SQSPolicyAPI interface {
+	sqs.ListQueuesAPIClient
+	GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error)
+}
+
+// SQSPolicyScanner finds role ARNs in queue policies
+type SQSPolicyScanner struct {
+	client SQSPolicyAPI
+}
+
+// NewSQSPolicyScanner creates an SQS queue policy scanner
+func NewSQSPolicyScanner(client SQSPolicyAPI) *SQSPolicyScanner {
+	return &SQSPolicyScanner{client: client}
+}
+
+// Service returns the scanned service
+func (s *SQSPolicyScanner) Service() string {
+	return "sqs"
+}
+
+// ScanReferences returns the roles named in every queue policy
+func (s *SQSPolicyScanner) ScanReferences(ctx context.Context) ([]Reference, error) {
+	var references []Reference
+	paginator := sqs.NewListQueuesPaginator(s.client, &sqs.ListQueuesInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list queues: %w", err)
+		}
+		for _, url := range output.QueueUrls {
+			attributes, err := s.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+				QueueUrl:       aws.String(url),
+				AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNamePolicy},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get policy of queue %s: %w", url, err)
+			}
+			references = appendPolicyReferences(references, attributes.Attributes[string(sqstypes.QueueAttributeNamePolicy)], s.Service(), url, "queue policy")
+		}
+	}
+	return references, nil
+}
+
+// SNSPolicyAPI is the part of the SNS API used to read topic policies
+type SNSPolicyAPI interface {
+	sns.ListTopicsAPIClient
+	GetTopicAttributes(ctx context.Context, params *sns.GetTopicAttributesInput, optFns ...func(*sns.Options)) (*sns.GetTopicAttributesOutput, error)
+}
+
+// SNSPolicyScanner finds role ARNs in topic policies
+type SNSPolicyScanner struct {
+	client SNSPolicyAPI
+}
+
+// NewSNSPolicyScanner creates an SNS topic policy scanner
+func NewSNSPolicyScanner(client SNSPolicyAPI) *SNSPolicyScanner {
+	return &SNSPolicyScanner{client: client}
+}
+
+// Service returns the scanned service
+func (s *SNSPolicyScanner) Service() string {
+	return "sns"
+}
+
+// ScanReferences returns the roles named in every topic policy
+func (s *SNSPolicyScanner) ScanReferences(ctx context.Context) ([]Reference, error) {
+	var references []Reference
+	paginator := sns.NewListTopicsPaginator(s.client, &sns.ListTopicsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list topics: %w", err)
+		}
+		for _, topic := range output.Topics {
+			attributes, err := s.client.GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{TopicArn: topic.TopicArn})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get policy of topic %s: %w", aws.ToString(topic.TopicArn), err)
+			}
+			references = appendPolicyReferences(references, attributes.Attributes["Policy"], s.Service(), aws.ToString(topic.TopicArn), "topic policy")
+		}
+	}
+	return references, nil
+}
+
+// SecretsManagerPolicyAPI is the part of the Secrets Manager API used to
+// read secret resource policies
+type SecretsManagerPolicyAPI interface {
+	secretsmanager.ListSecretsAPIClient
+	GetResourcePolicy(ctx context.Context, params *secretsmanager.GetResourcePolicyInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetResourcePolicyOutput, error)
+}
+
+// SecretsManagerPolicyScanner finds role ARNs in secret resource policies
+type SecretsManagerPolicyScanner struct {
+	client SecretsManagerPolicyAPI
+}
+
+// NewSecretsManagerPolicyScanner creates a Secrets Manager resource policy
+// scanner
+func NewSecretsManagerPolicyScanner(client SecretsManagerPolicyAPI) *SecretsManagerPolicyScanner {
+	return &SecretsManagerPolicyScanner{client: client}
+}
+
+// Service returns the scanned service
+func (s *SecretsManagerPolicyScanner) Service() string {
+	return "secretsmanager"
+}
+
+// ScanReferences returns the roles named in every secret resource policy
+func (s *SecretsManagerPolicyScanner) ScanReferences(ctx context.Context) ([]Reference, error) {
+	var references []Reference
+	paginator := secretsmanager.NewListSecretsPaginator(s.client, &secretsmanager.ListSecretsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secrets: %w", err)
+		}
+		for _, secret := range output.SecretList {
+			policy, err := s.client.GetResourcePolicy(ctx, &secretsmanager.GetResourcePolicyInput{SecretId: secret.ARN})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get policy of secret %s: %w", aws.ToString(secret.Name), err)
+			}
+			references = appendPolicyReferences(references, aws.ToString(policy.ResourcePolicy), s.Service(), aws.ToString(secret.ARN), "secret policy")
+		}
+	}
+	return references, nil
+}
+
+// ECRPolicyAPI is the part of the ECR API used to read repository policies
+type ECRPolicyAPI interface {
+	ecr.DescribeRepositoriesAPIClient
+	GetRepositoryPolicy(ctx context.Context, params *ecr.GetRepositoryPolicyInput, optFns ...func(*ecr.Options)) (*ecr.GetRepositoryPolicyOutput, error)
+}
+
+// ECRPolicyScanner finds role ARNs in repository policies
+type ECRPolicyScanner struct {
+	client ECRPolicyAPI
+}
+
+// NewECRPolicyScanner creates an ECR repository policy scanner
+func NewECRPolicyScanner(client ECRPolicyAPI) *ECRPolicyScanner {
+	return &ECRPolicyScanner{client: client}
+}
+
+// Service returns the scanned service
+func (s *ECRPolicyScanner) Service() string {
+	return "ecr"
+}
+
+// ScanReferences returns the roles named in every repository policy
+func (s *ECRPolicyScanner) ScanReferences(ctx context.Context) ([]Reference, error) {
+	var references []Reference
+	paginator := ecr.NewDescribeRepositoriesPaginator(s.client, &ecr.DescribeRepositoriesInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe repositories: %w", err)
+		}
+		for _, repository := range output.Repositories {
+			policy, err := s.client.GetRepositoryPolicy(ctx, &ecr.GetRepositoryPolicyInput{RepositoryName: repository.RepositoryName})
+			var notFound *ecrtypes.RepositoryPolicyNotFoundException
+			if errors.As(err, &notFound) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to get policy of repository %s: %w", aws.ToString(repository.RepositoryName), err)
+			}
+			references = appendPolicyReferences(references, aws.ToString(policy.PolicyText), s.Service(), aws.ToString(repository.RepositoryArn), "repository policy")
+		}
+	}
+	return references, nil
+}
+
+// appendPolicyReferences appends a reference for every distinct role ARN
+// named in a policy document
+func appendPolicyReferences(references []Reference, document, service, resource, usage string) []Reference {
+	seen := make(map[string]bool)
+	for _, roleArn := range roleArnPattern.FindAllString(document, -1) {
+		if seen[roleArn] {
+			continue
+		}
+		seen[roleArn] = true
+		references = appendReference(references, roleArn, service, resource, usage)
+	}
+	return references
+}
+
+// hasErrorCode reports whether err is an AWS API error with the given code
+func hasErrorCode(err error, code string) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == code
+}
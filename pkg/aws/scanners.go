@@ -0,0 +1,437 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/codebuild"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+)
+
+// codeBuildBatchSize is the most projects BatchGetProjects accepts
+const codeBuildBatchSize = 100
+
+// LambdaAPI is the part of the Lambda API used to find execution roles
+type LambdaAPI interface {
+	lambda.ListFunctionsAPIClient
+}
+
+// LambdaScanner finds Lambda functions by their execution role
+type LambdaScanner struct {
+	client LambdaAPI
+}
+
+// NewLambdaScanner creates a Lambda reference scanner
+func NewLambdaScanner(client LambdaAPI) *LambdaScanner {
+	return &LambdaScanner{client: client}
+}
+
+// Service returns the scanned service
+func (s *LambdaScanner) Service() string {
+	return "lambda"
+}
+
+// ScanReferences returns the execution role of every function
+func (s *LambdaScanner) ScanReferences(ctx context.Context) ([]Reference, error) {
+	var references []Reference
+	paginator := lambda.NewListFunctionsPaginator(s.client, &lambda.ListFunctionsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list functions: %w", err)
+		}
+		for _, function := range output.Functions {
+			references = appendReference(references, aws.ToString(function.Role), s.Service(), aws.ToString(function.FunctionArn), "execution role")
+		}
+	}
+	return references, nil
+}
+
+// ECSAPI is the part of the ECS API used to find task definition roles
+type ECSAPI interface {
+	ecs.ListTaskDefinitionsAPIClient
+	DescribeTaskDefinition(ctx context.Context, params *ecs.DescribeTaskDefinitionInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTaskDefinitionOutput, error)
+}
+
+// ECSScanner finds ECS task definitions by their task and execution roles
+type ECSScanner struct {
+	client ECSAPI
+}
+
+// NewECSScanner creates an ECS reference scanner
+func NewECSScanner(client ECSAPI) *ECSScanner {
+	return &ECSScanner{client: client}
+}
+
+// Service returns the scanned service
+func (s *ECSScanner) Service() string {
+	return "ecs"
+}
+
+// ScanReferences returns the task and execution roles of every active task
+// definition
+func (s *ECSScanner) ScanReferences(ctx context.Context) ([]Reference, error) {
+	var references []Reference
+	paginator := ecs.NewListTaskDefinitionsPaginator(s.client, &ecs.ListTaskDefinitionsInput{
+		Status: ecstypes.TaskDefinitionStatusActive,
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list task definitions: %w", err)
+		}
+		for _, arn := range output.TaskDefinitionArns {
+			described, err := s.client.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+				TaskDefinition: aws.String(arn),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe task definition %s: %w", arn, err)
+			}
+			if described.TaskDefinition == nil {
+				continue
+			}
+			references = appendReference(references, aws.ToString(described.TaskDefinition.TaskRoleArn), s.Service(), arn, "task role")
+			references = appendReference(references, aws.ToString(described.TaskDefinition.ExecutionRoleArn), s.Service(), arn, "execution role")
+		}
+	}
+	return references, nil
+}
+
+// EC2API is the part of the EC2 API used to find instance profiles
+type EC2API interface {
+	ec2.DescribeInstancesAPIClient
+}
+
+// InstanceProfileAPI is the part of the IAM API used to resolve instance
+// profiles to their roles
+type InstanceProfileAPI interface {
+	GetInstanceProfile(ctx context.Context, params *iam.GetInstanceProfileInput, optFns ...func(*iam.Options)) (*iam.GetInstanceProfileOutput, error)
+}
+
+// EC2Scanner finds running instances by the role of their instance profile
+type EC2Scanner struct {
+	client   EC2API
+	profiles InstanceProfileAPI
+}
+
+// NewEC2Scanner creates an EC2 reference scanner
+func NewEC2Scanner(client EC2API, profiles InstanceProfileAPI) *EC2Scanner {
+	return &EC2Scanner{client: client, profiles: profiles}
+}
+
+// Service returns the scanned service
+func (s *EC2Scanner) Service() string {
+	return "ec2"
+}
+
+// ScanReferences returns the roles of the instance profiles of every running
+// instance. Each instance profile is resolved once.
+func (s *EC2Scanner) ScanReferences(ctx context.Context) ([]Reference, error) {
+	var references []Reference
+	profileRoles := make(map[string][]string)
+
+	paginator := ec2.NewDescribeInstancesPaginator(s.client, &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{{Name: aws.String("instance-state-name"), Values: []string{"running"}}},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe instances: %w", err)
+		}
+		for _, reservation := range output.Reservations {
+			for _, instance := range reservation.Instances {
+				if instance.IamInstanceProfile == nil {
+					continue
+				}
+				profileArn := aws.ToString(instance.IamInstanceProfile.Arn)
+				profileName := profileArn[strings.LastIndex(profileArn, "/")+1:]
+
+				roles, ok := profileRoles[profileArn]
+				if !ok {
+					profile, err := s.profiles.GetInstanceProfile(ctx, &iam.GetInstanceProfileInput{
+						InstanceProfileName: aws.String(profileName),
+					})
+					if err != nil {
+						return nil, fmt.Errorf("failed to get instance profile %s: %w", profileName, err)
+					}
+					for _, role := range profile.InstanceProfile.Roles {
+						roles = append(roles, aws.ToString(role.Arn))
+					}
+					profileRoles[profileArn] = roles
+				}
+
+				for _, roleArn := range roles {
+					references = appendReference(references, roleArn, s.Service(), aws.ToString(instance.InstanceId), "instance profile "+profileName)
+				}
+			}
+		}
+	}
+	return references, nil
+}
+
+// StepFunctionsAPI is the part of the Step Functions API used to find
+// state machine roles
+type StepFunctionsAPI interface {
+	sfn.ListStateMachinesAPIClient
+	DescribeStateMachine(ctx context.Context, params *sfn.DescribeStateMachineInput, optFns ...func(*sfn.Options)) (*sfn.DescribeStateMachineOutput, error)
+}
+
+// StepFunctionsScanner finds state machines by their execution role
+type StepFunctionsScanner struct {
+	client StepFunctionsAPI
+}
+
+// NewStepFunctionsScanner creates a Step Functions reference scanner
+func NewStepFunctionsScanner(client StepFunctionsAPI) *StepFunctionsScanner {
+	return &StepFunctionsScanner{client: client}
+}
+
+// Service returns the scanned service
+func (s *StepFunctionsScanner) Service() string {
+	return "states"
+}
+
+// ScanReferences returns the execution role of every state machine
+func (s *StepFunctionsScanner) ScanReferences(ctx context.Context) ([]Reference, error) {
+	var references []Reference
+	paginator := sfn.NewListStateMachinesPaginator(s.client, &sfn.ListStateMachinesInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list state machines: %w", err)
+		}
+		for _, machine := range output.StateMachines {
+			described, err := s.client.DescribeStateMachine(ctx, &sfn.DescribeStateMachineInput{
+				StateMachineArn: machine.StateMachineArn,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe state machine %s: %w", aws.ToString(machine.Name), err)
+			}
+			references = appendReference(references, aws.ToString(described.RoleArn), s.Service(), aws.ToString(machine.StateMachineArn), "execution role")
+		}
+	}
+	return references, nil
+}
+
+// CodeBuildAPI is the part of the CodeBuild API used to find project roles
+type CodeBuildAPI interface {
+	codebuild.ListProjectsAPIClient
+	BatchGetProjects(ctx context.Context, params *codebuild.BatchGetProjectsInput, optFns ...func(*codebuild.Options)) (*codebuild.BatchGetProjectsOutput, error)
+}
+
+// CodeBuildScanner finds CodeBuild projects by their service role
+type CodeBuildScanner struct {
+	client CodeBuildAPI
+}
+
+// NewCodeBuildScanner creates a CodeBuild reference scanner
+func NewCodeBuildScanner(client CodeBuildAPI) *CodeBuildScanner {
+	return &CodeBuildScanner{client: client}
+}
+
+// Service returns the scanned service
+func (s *CodeBuildScanner) Service() string {
+	return "codebuild"
+}
+
+// ScanReferences returns the service role of every project
+func (s *CodeBuildScanner) ScanReferences(ctx context.Context) ([]Reference, error) {
+	var names []string
+	paginator := codebuild.NewListProjectsPaginator(s.client, &codebuild.ListProjectsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list projects: %w", err)
+		}
+		names = append(names, output.Projects...)
+	}
+
+	var references []Reference
+	for start := 0; start < len(names); start += codeBuildBatchSize {
+		end := min(start+codeBuildBatchSize, len(names))
+		output, err := s.client.BatchGetProjects(ctx, &codebuild.BatchGetProjectsInput{Names: names[start:end]})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get projects: %w", err)
+		}
+		for _, project := range output.Projects {
+			references = appendReference(references, aws.ToString(project.ServiceRole), s.Service(), aws.ToString(project.Arn), "service role")
+		}
+	}
+	return references, nil
+}
+
+// EventBridgeAPI is the part of the EventBridge API used to find rule and
+// target roles
+type EventBridgeAPI interface {
+	ListEventBuses(ctx context.Context, params *eventbridge.ListEventBusesInput, optFns ...func(*eventbridge.Options)) (*eventbridge.ListEventBusesOutput, error)
+	ListRules(ctx context.Context, params *eventbridge.ListRulesInput, optFns ...func(*eventbridge.Options)) (*eventbridge.ListRulesOutput, error)
+	ListTargetsByRule(ctx context.Context, params *eventbridge.ListTargetsByRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.ListTargetsByRuleOutput, error)
+}
+
+// EventBridgeScanner finds EventBridge rules and rule targets by their role
+type EventBridgeScanner struct {
+	client EventBridgeAPI
+}
+
+// NewEventBridgeScanner creates an EventBridge reference scanner
+func NewEventBridgeScanner(client EventBridgeAPI) *EventBridgeScanner {
+	return &EventBridgeScanner{client: client}
+}
+
+// Service returns the scanned service
+func (s *EventBridgeScanner) Service() string {
+	return "events"
+}
+
+// ScanReferences returns the roles of the rules on every event bus and of
+// their targets. The EventBridge API has no paginators, so the list calls
+// follow NextToken themselves.
+func (s *EventBridgeScanner) ScanReferences(ctx context.Context) ([]Reference, error) {
+	var buses []string
+	busInput := &eventbridge.ListEventBusesInput{}
+	for {
+		output, err := s.client.ListEventBuses(ctx, busInput)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list event buses: %w", err)
+		}
+		for _, bus := range output.EventBuses {
+			buses = append(buses, aws.ToString(bus.Name))
+		}
+		if output.NextToken == nil {
+			break
+		}
+		busInput.NextToken = output.NextToken
+	}
+
+	var references []Reference
+	for _, bus := range buses {
+		ruleInput := &eventbridge.ListRulesInput{EventBusName: aws.String(bus)}
+		for {
+			output, err := s.client.ListRules(ctx, ruleInput)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list rules of event bus %s: %w", bus, err)
+			}
+			for _, rule := range output.Rules {
+				ruleArn := aws.ToString(rule.Arn)
+				references = appendReference(references, aws.ToString(rule.RoleArn), s.Service(), ruleArn, "rule role")
+
+				targetInput := &eventbridge.ListTargetsByRuleInput{Rule: rule.Name, EventBusName: aws.String(bus)}
+				for {
+					targets, err := s.client.ListTargetsByRule(ctx, targetInput)
+					if err != nil {
+						return nil, fmt.Errorf("failed to list targets of rule %s: %w", aws.ToString(rule.Name), err)
+					}
+					for _, target := range targets.Targets {
+						references = appendReference(references, aws.ToString(target.RoleArn), s.Service(), ruleArn, "role of target "+aws.ToString(target.Id))
+					}
+					if targets.NextToken == nil {
+						break
+					}
+					targetInput.NextToken = targets.NextToken
+				}
+			}
+			if output.NextToken == nil {
+				break
+			}
+			ruleInput.NextToken = output.NextToken
+		}
+	}
+	return references, nil
+}
+
+// GlueAPI is the part of the Glue API used to find job and crawler roles
+type GlueAPI interface {
+	glue.GetJobsAPIClient
+	glue.GetCrawlersAPIClient
+}
+
+// RoleAPI is the part of the IAM API used to resolve role names to ARNs
+type RoleAPI interface {
+	GetRole(ctx context.Context, params *iam.GetRoleInput, optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error)
+}
+
+// GlueScanner finds Glue jobs and crawlers by their role
+type GlueScanner struct {
+	client GlueAPI
+	roles  RoleAPI
+}
+
+// NewGlueScanner creates a Glue reference scanner
+func NewGlueScanner(client GlueAPI, roles RoleAPI) *GlueScanner {
+	return &GlueScanner{client: client, roles: roles}
+}
+
+// Service returns the scanned service
+func (s *GlueScanner) Service() string {
+	return "glue"
+}
+
+// ScanReferences returns the role of every job and crawler. Glue accepts
+// a role name instead of an ARN, so each name is resolved once.
+func (s *GlueScanner) ScanReferences(ctx context.Context) ([]Reference, error) {
+	var references []Reference
+	roleArns := make(map[string]string)
+	add := func(role, resource, usage string) error {
+		if role != "" && !strings.HasPrefix(role, "arn:") {
+			if _, ok := roleArns[role]; !ok {
+				output, err := s.roles.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(role)})
+				if err != nil && !hasErrorCode(err, "NoSuchEntity") {
+					return fmt.Errorf("failed to get role %s: %w", role, err)
+				}
+				if err == nil && output.Role != nil {
+					roleArns[role] = aws.ToString(output.Role.Arn)
+				} else {
+					roleArns[role] = ""
+				}
+			}
+			role = roleArns[role]
+		}
+		references = appendReference(references, role, s.Service(), resource, usage)
+		return nil
+	}
+
+	jobs := glue.NewGetJobsPaginator(s.client, &glue.GetJobsInput{})
+	for jobs.HasMorePages() {
+		output, err := jobs.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get jobs: %w", err)
+		}
+		for _, job := range output.Jobs {
+			if err := add(aws.ToString(job.Role), "job/"+aws.ToString(job.Name), "job role"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	crawlers := glue.NewGetCrawlersPaginator(s.client, &glue.GetCrawlersInput{})
+	for crawlers.HasMorePages() {
+		output, err := crawlers.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get crawlers: %w", err)
+		}
+		for _, crawler := range output.Crawlers {
+			if err := add(aws.ToString(crawler.Role), "crawler/"+aws.ToString(crawler.Name), "crawler role"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return references, nil
+}
+
+// appendReference appends a reference if the resource uses a role
+func appendReference(references []Reference, roleArn, service, resource, usage string) []Reference {
+	if roleArn == "" {
+		return references
+	}
+	return append(references, Reference{RoleArn: roleArn, Service: service, Resource: resource, Usage: usage})
+}
@@ -0,0 +1,49 @@
+package aws
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Role sort orders
+const (
+	SortByName     = "name"
+	SortByLastUsed = "last-used"
+	SortByRisk     = "risk"
+)
+
+// SortRoles sorts roles in place. SortByLastUsed puts never used roles
+// first, then the least recently used. SortByRisk puts the highest risk
+// score first. Ties are broken by name.
+func SortRoles(roles []Role, by string) error {
+	var less func(a, b Role) bool
+	switch by {
+	case SortByName:
+		less = func(a, b Role) bool { return false }
+	case SortByLastUsed:
+		less = func(a, b Role) bool {
+			switch {
+			case a.LastUsed == nil || b.LastUsed == nil:
+				return a.LastUsed == nil && b.LastUsed != nil
+			default:
+				return a.LastUsed.Before(*b.LastUsed)
+			}
+		}
+	case SortByRisk:
+		less = func(a, b Role) bool { return a.RiskScore > b.RiskScore }
+	default:
+		return fmt.Errorf("unsupported sort order: %s", by)
+	}
+
+	sort.SliceStable(roles, func(i, j int) bool {
+		if less(roles[i], roles[j]) {
+			return true
+		}
+		if less(roles[j], roles[i]) {
+			return false
+		}
+		return roles[i].Name < roles[j].Name
+	})
+
+	return nil
+}
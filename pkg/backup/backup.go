@@ -0,0 +1,59 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultDir is the default directory for backups
+const DefaultDir = "hawkling-backups"
+
+// Save writes content to a timestamped file under dir/kind and returns its path
+func Save(dir, kind, name string, content []byte) (string, error) {
+	kindDir := filepath.Join(dir, kind)
+	if err := os.MkdirAll(kindDir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory %s: %w", kindDir, err)
+	}
+
+	fileName := fmt.Sprintf("%s-%s.json", sanitize(name), time.Now().UTC().Format("20060102T150405.000Z"))
+	path := filepath.Join(kindDir, fileName)
+
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write backup %s: %w", path, err)
+	}
+
+	return path, nil
+}
+
+// SaveJSON writes v as indented JSON to a timestamped file under dir/kind
+func SaveJSON(dir, kind, name string, v interface{}) (string, error) {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode backup: %w", err)
+	}
+
+	return Save(dir, kind, name, append(content, '\n'))
+}
+
+// LoadJSON reads a backup written by SaveJSON into v
+func LoadJSON(path string, v interface{}) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read backup %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("failed to parse backup %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// sanitize makes a name safe to use in a file name
+func sanitize(name string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(name)
+}
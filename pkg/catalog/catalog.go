@@ -0,0 +1,91 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+//go:embed deprecated_policies.json
+var builtinCatalog []byte
+
+// DeprecatedPolicy is a deprecated AWS managed policy and its successor
+type DeprecatedPolicy struct {
+	Arn string
+	// Replacement is the ARN of the successor policy, or empty if AWS
+	// provides no drop-in replacement
+	Replacement string
+	Note        string `json:",omitempty"`
+}
+
+// Catalog lists deprecated AWS managed policies
+type Catalog struct {
+	Updated  string
+	Policies []DeprecatedPolicy
+}
+
+// Builtin returns the catalog shipped with hawkling
+func Builtin() (*Catalog, error) {
+	var catalog Catalog
+	if err := json.Unmarshal(builtinCatalog, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse built-in catalog: %w", err)
+	}
+	return &catalog, nil
+}
+
+// Load returns the built-in catalog updated with the entries of a local
+// catalog file. Entries in the file override built-in entries with the
+// same ARN. An empty path returns the built-in catalog.
+func Load(path string) (*Catalog, error) {
+	catalog, err := Builtin()
+	if err != nil || path == "" {
+		return catalog, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog %s: %w", path, err)
+	}
+
+	var local Catalog
+	if err := json.Unmarshal(content, &local); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog %s: %w", path, err)
+	}
+
+	catalog.merge(&local)
+	return catalog, nil
+}
+
+// Lookup returns the catalog entry for a policy ARN
+func (c *Catalog) Lookup(arn string) (DeprecatedPolicy, bool) {
+	for _, entry := range c.Policies {
+		if entry.Arn == arn {
+			return entry, true
+		}
+	}
+	return DeprecatedPolicy{}, false
+}
+
+// merge adds or replaces entries from other, keeping entries sorted by ARN
+func (c *Catalog) merge(other *Catalog) {
+	entries := make(map[string]DeprecatedPolicy, len(c.Policies)+len(other.Policies))
+	for _, entry := range c.Policies {
+		entries[entry.Arn] = entry
+	}
+	for _, entry := range other.Policies {
+		entries[entry.Arn] = entry
+	}
+	if other.Updated > c.Updated {
+		c.Updated = other.Updated
+	}
+
+	c.Policies = make([]DeprecatedPolicy, 0, len(entries))
+	for _, entry := range entries {
+		c.Policies = append(c.Policies, entry)
+	}
+	sort.Slice(c.Policies, func(i, j int) bool {
+		return c.Policies[i].Arn < c.Policies[j].Arn
+	})
+}
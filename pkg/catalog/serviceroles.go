@@ -0,0 +1,140 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+//go:embed service_roles.json
+var builtinServiceRoles []byte
+
+// ServiceRolePattern describes the roles a service console creates for a
+// resource. Pattern is a regular expression matched against the whole role
+// name; a group named owner captures the name of the owning resource when
+// the convention includes it.
+type ServiceRolePattern struct {
+	Name string
+	// Service is the reference scanner service that finds the owning
+	// resources, such as "lambda"
+	Service string
+	// Owner is the kind of resource that owns the role, such as "function"
+	Owner   string
+	Pattern string
+	// Path is the path the console creates the role with
+	Path string
+	Note string `json:",omitempty"`
+
+	compiled *regexp.Regexp
+}
+
+// ServiceRoleCatalog lists naming conventions of auto-generated service
+// roles. Patterns are tried in order, so more specific ones come first.
+type ServiceRoleCatalog struct {
+	Updated  string
+	Patterns []ServiceRolePattern
+}
+
+// ServiceRoleMatch is a role that follows a naming convention
+type ServiceRoleMatch struct {
+	Pattern ServiceRolePattern
+	// OwnerName is the name of the owning resource taken from the role
+	// name, empty if the convention does not include it
+	OwnerName string
+	// PathMatches is set when the role also has the path of the convention
+	PathMatches bool
+}
+
+// BuiltinServiceRoles returns the service role catalog shipped with hawkling
+func BuiltinServiceRoles() (*ServiceRoleCatalog, error) {
+	catalog, err := parseServiceRoles(builtinServiceRoles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse built-in service role catalog: %w", err)
+	}
+	return catalog, nil
+}
+
+// LoadServiceRoles returns the built-in service role catalog updated with
+// the patterns of a local catalog file. Patterns in the file replace
+// built-in patterns with the same name, and new ones are tried before the
+// built-in patterns. An empty path returns the built-in catalog.
+func LoadServiceRoles(path string) (*ServiceRoleCatalog, error) {
+	catalog, err := BuiltinServiceRoles()
+	if err != nil || path == "" {
+		return catalog, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service role catalog %s: %w", path, err)
+	}
+
+	local, err := parseServiceRoles(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse service role catalog %s: %w", path, err)
+	}
+
+	catalog.merge(local)
+	return catalog, nil
+}
+
+// Match returns the first pattern the role name follows
+func (c *ServiceRoleCatalog) Match(roleName, rolePath string) (ServiceRoleMatch, bool) {
+	for _, pattern := range c.Patterns {
+		groups := pattern.compiled.FindStringSubmatch(roleName)
+		if groups == nil {
+			continue
+		}
+
+		match := ServiceRoleMatch{Pattern: pattern, PathMatches: pattern.Path == "" || pattern.Path == rolePath}
+		if i := pattern.compiled.SubexpIndex("owner"); i >= 0 {
+			match.OwnerName = groups[i]
+		}
+		return match, true
+	}
+	return ServiceRoleMatch{}, false
+}
+
+// parseServiceRoles parses a catalog and compiles its patterns
+func parseServiceRoles(content []byte) (*ServiceRoleCatalog, error) {
+	var catalog ServiceRoleCatalog
+	if err := json.Unmarshal(content, &catalog); err != nil {
+		return nil, err
+	}
+
+	for i := range catalog.Patterns {
+		pattern := &catalog.Patterns[i]
+		if pattern.Name == "" || pattern.Service == "" || pattern.Pattern == "" {
+			return nil, fmt.Errorf("pattern %d needs a Name, Service and Pattern", i+1)
+		}
+		compiled, err := regexp.Compile("^(?:" + pattern.Pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern.Name, err)
+		}
+		pattern.compiled = compiled
+	}
+	return &catalog, nil
+}
+
+// merge puts the patterns of other first, replacing patterns with the same
+// name
+func (c *ServiceRoleCatalog) merge(other *ServiceRoleCatalog) {
+	replaced := make(map[string]bool, len(other.Patterns))
+	for _, pattern := range other.Patterns {
+		replaced[pattern.Name] = true
+	}
+
+	patterns := append([]ServiceRolePattern{}, other.Patterns...)
+	for _, pattern := range c.Patterns {
+		if !replaced[pattern.Name] {
+			patterns = append(patterns, pattern)
+		}
+	}
+	c.Patterns = patterns
+
+	if other.Updated > c.Updated {
+		c.Updated = other.Updated
+	}
+}
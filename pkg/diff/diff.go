@@ -0,0 +1,157 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change
+const contextLines = 3
+
+// opKind identifies a line in an edit script
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// op is a single line of an edit script
+type op struct {
+	kind opKind
+	line string
+	a, b int // line indexes in a and b before this op
+}
+
+// Unified returns a unified diff between a and b labelled with the given
+// names. It returns an empty string when the inputs are equal.
+func Unified(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	ops := editScript(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	for start := 0; start < len(ops); {
+		// Find the next change
+		for start < len(ops) && ops[start].kind == opEqual {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// Extend the hunk while changes are close together
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == opEqual {
+				next++
+			}
+			if next == len(ops) || next-end > 2*contextLines {
+				break
+			}
+			end = next
+		}
+
+		hunkStart := max(start-contextLines, 0)
+		hunkEnd := min(end+contextLines, len(ops))
+		writeHunk(&sb, ops[hunkStart:hunkEnd])
+
+		start = hunkEnd
+	}
+
+	return sb.String()
+}
+
+// writeHunk writes one hunk with its header
+func writeHunk(sb *strings.Builder, ops []op) {
+	aCount, bCount := 0, 0
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			aCount++
+			bCount++
+		case opDelete:
+			aCount++
+		case opInsert:
+			bCount++
+		}
+	}
+
+	aStart, bStart := ops[0].a+1, ops[0].b+1
+	if aCount == 0 {
+		aStart--
+	}
+	if bCount == 0 {
+		bStart--
+	}
+
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			sb.WriteString(" ")
+		case opDelete:
+			sb.WriteString("-")
+		case opInsert:
+			sb.WriteString("+")
+		}
+		sb.WriteString(o.line)
+		sb.WriteString("\n")
+	}
+}
+
+// editScript computes a line edit script from a to b using the longest
+// common subsequence
+func editScript(a, b []string) []op {
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{kind: opEqual, line: a[i], a: i, b: j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{kind: opDelete, line: a[i], a: i, b: j})
+			i++
+		default:
+			ops = append(ops, op{kind: opInsert, line: b[j], a: i, b: j})
+			j++
+		}
+	}
+
+	return ops
+}
+
+// splitLines splits text into lines, ignoring a trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
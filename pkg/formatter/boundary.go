@@ -0,0 +1,42 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"hawkling/pkg/audit"
+)
+
+// FormatBoundaryViolations formats boundary compliance results according to the specified format
+func FormatBoundaryViolations(violations []audit.BoundaryViolation, format Format) error {
+	switch format {
+	case TableFormat:
+		return formatBoundaryViolationsAsTable(violations)
+	case JSONFormat:
+		return writeJSON(violations)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// formatBoundaryViolationsAsTable prints one row per non-compliant role
+func formatBoundaryViolationsAsTable(violations []audit.BoundaryViolation) error {
+	if len(violations) == 0 {
+		fmt.Println("All roles use their required permissions boundary")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ROLE\tPATH\tSTATUS\tCURRENT\tREQUIRED")
+	for _, violation := range violations {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			violation.RoleName,
+			violation.Path,
+			violation.Status,
+			orDash(violation.Current),
+			violation.Required,
+		)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,47 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"hawkling/pkg/audit"
+)
+
+// FormatDeprecatedAttachments formats deprecated policy attachments according to the specified format
+func FormatDeprecatedAttachments(attachments []audit.DeprecatedAttachment, format Format) error {
+	switch format {
+	case TableFormat:
+		return formatDeprecatedAttachmentsAsTable(attachments)
+	case JSONFormat:
+		return writeJSON(attachments)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// formatDeprecatedAttachmentsAsTable prints one row per attachment
+func formatDeprecatedAttachmentsAsTable(attachments []audit.DeprecatedAttachment) error {
+	if len(attachments) == 0 {
+		fmt.Println("No roles attach deprecated AWS managed policies")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ROLE\tLAST USED\tUNUSED\tPOLICY\tREPLACEMENT")
+	for _, attachment := range attachments {
+		unused := "-"
+		if attachment.Unused {
+			unused = "yes"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			attachment.RoleName,
+			formatLastUsed(attachment.LastUsed),
+			unused,
+			attachment.PolicyName,
+			orDash(policyName(attachment.Replacement)),
+		)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,30 @@
+package formatter
+
+import (
+	"fmt"
+
+	"hawkling/pkg/audit"
+)
+
+// FormatRoleDiff formats a comparison of two roles according to the specified format
+func FormatRoleDiff(roleDiff *audit.RoleDiff, format Format) error {
+	switch format {
+	case TableFormat:
+		return formatRoleDiffAsText(roleDiff)
+	case JSONFormat:
+		return writeJSON(roleDiff)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// formatRoleDiffAsText prints the unified diff of the two roles
+func formatRoleDiffAsText(roleDiff *audit.RoleDiff) error {
+	if roleDiff.Equal {
+		fmt.Printf("No differences between %s and %s\n", roleDiff.From, roleDiff.To)
+		return nil
+	}
+
+	fmt.Print(roleDiff.Unified)
+	return nil
+}
@@ -0,0 +1,58 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"hawkling/pkg/audit"
+)
+
+// FormatDuplicateGroups formats groups of duplicate roles according to the specified format
+func FormatDuplicateGroups(groups []audit.DuplicateGroup, format Format) error {
+	switch format {
+	case TableFormat:
+		return formatDuplicateGroupsAsTable(groups)
+	case JSONFormat:
+		return writeJSON(groups)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// formatDuplicateGroupsAsTable prints one row per role, numbering the groups
+// and marking the suggested survivor of each. Roles of similar groups show
+// their identical set.
+func formatDuplicateGroupsAsTable(groups []audit.DuplicateGroup) error {
+	if len(groups) == 0 {
+		fmt.Println("No duplicate roles found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tKIND\tROLE\tCREATED\tLAST USED\tUNUSED\tSUGGESTION")
+	for i, group := range groups {
+		for _, role := range group.Roles {
+			suggestion := "prune"
+			if role.RoleName == group.Survivor {
+				suggestion = "keep"
+			}
+
+			kind := group.Kind
+			if role.Set > 0 {
+				kind = fmt.Sprintf("%s, set %d", group.Kind, role.Set)
+			}
+
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%t\t%s\n",
+				i+1,
+				kind,
+				role.RoleName,
+				role.CreateDate.Format("2006-01-02"),
+				formatLastUsed(role.LastUsed),
+				role.Unused,
+				suggestion,
+			)
+		}
+	}
+	return w.Flush()
+}
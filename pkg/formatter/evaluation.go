@@ -0,0 +1,85 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"hawkling/pkg/policy"
+)
+
+// evaluationOutput is the JSON form of a policy evaluation
+type evaluationOutput struct {
+	Role     string
+	Action   string
+	Resource string `json:",omitempty"`
+	policy.Result
+}
+
+// FormatEvaluation formats the result of evaluating a request for a role
+func FormatEvaluation(roleName string, request policy.Request, result policy.Result, format Format) error {
+	switch format {
+	case TableFormat:
+		return formatEvaluationAsText(roleName, request, result)
+	case JSONFormat:
+		return writeJSON(evaluationOutput{
+			Role:     roleName,
+			Action:   request.Action,
+			Resource: request.Resource,
+			Result:   result,
+		})
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// formatEvaluationAsText prints the decision followed by the matching statements
+func formatEvaluationAsText(roleName string, request policy.Request, result policy.Result) error {
+	resource := request.Resource
+	if resource == "" {
+		resource = "(any)"
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Role:\t%s\n", roleName)
+	fmt.Fprintf(w, "Action:\t%s\n", request.Action)
+	fmt.Fprintf(w, "Resource:\t%s\n", resource)
+	fmt.Fprintf(w, "Decision:\t%s\n", result.Decision)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if result.DeniedByBoundary {
+		fmt.Println("\nAllowed by an identity policy but not by the permissions boundary")
+	}
+
+	if len(result.Unresolved) > 0 {
+		fmt.Printf("\nCondition keys not in the request context: %s\n", strings.Join(result.Unresolved, ", "))
+	}
+	if result.Conditional {
+		fmt.Println("The request would be allowed if these keys have values the conditions accept")
+	}
+
+	if len(result.Matches) == 0 {
+		fmt.Println("\nNo matching statements")
+		return nil
+	}
+
+	fmt.Println("\nMatching statements:")
+	for _, match := range result.Matches {
+		label := fmt.Sprintf("%s #%d", match.Source, match.Index+1)
+		if match.Sid != "" {
+			label += " (" + match.Sid + ")"
+		}
+
+		statement, err := json.Marshal(match.Statement)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  [%s] %s %s\n    %s\n", match.SourceType, match.Effect, label, statement)
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"hawkling/pkg/aws"
+)
+
+const (
+	// TerraformFormat outputs roles as Terraform HCL with import blocks
+	TerraformFormat Format = "terraform"
+
+	// CloudFormationFormat outputs roles as a CloudFormation YAML template
+	CloudFormationFormat Format = "cloudformation"
+)
+
+// FormatRoleExport renders roles, with their inline and attached policies,
+// as infrastructure code that adopts them
+func FormatRoleExport(roles []aws.Role, format Format) error {
+	switch format {
+	case TerraformFormat:
+		fmt.Print(RolesTerraform(roles))
+		return nil
+	case CloudFormationFormat:
+		fmt.Print(RolesCloudFormation(roles))
+		return nil
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// RolesTerraform renders roles as aws_iam_role, aws_iam_role_policy and
+// aws_iam_role_policy_attachment resources, each with an import block so
+// that terraform plan adopts the existing role instead of creating it.
+// Names that map to the same resource name, such as svc.a and svc_a, get a
+// numeric suffix.
+func RolesTerraform(roles []aws.Role) string {
+	var b strings.Builder
+	roleNames := make(map[string]bool, len(roles))
+	policyNames := make(map[string]bool)
+	attachmentNames := make(map[string]bool)
+	for i, role := range roles {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		resourceName := uniqueTerraformName(roleNames, TerraformResourceName(role.Name))
+
+		attributes := [][2]string{{"name", terraformString(role.Name)}}
+		if role.Path != "" {
+			attributes = append(attributes, [2]string{"path", terraformString(role.Path)})
+		}
+		if role.Description != "" {
+			attributes = append(attributes, [2]string{"description", terraformString(role.Description)})
+		}
+		if role.MaxSessionDuration != 0 {
+			attributes = append(attributes, [2]string{"max_session_duration", fmt.Sprint(role.MaxSessionDuration)})
+		}
+		if role.PermissionsBoundary != "" {
+			attributes = append(attributes, [2]string{"permissions_boundary", terraformString(role.PermissionsBoundary)})
+		}
+
+		fmt.Fprintf(&b, "resource \"aws_iam_role\" %q {\n", resourceName)
+		writeTerraformAttributes(&b, attributes)
+		writeTerraformHeredoc(&b, "assume_role_policy", role.TrustPolicy)
+		if tags := userTags(role.Tags); len(tags) > 0 {
+			width := 0
+			for _, key := range tags {
+				width = max(width, len(terraformString(key)))
+			}
+			b.WriteString("  tags = {\n")
+			for _, key := range tags {
+				fmt.Fprintf(&b, "    %-*s = %s\n", width, terraformString(key), terraformString(role.Tags[key]))
+			}
+			b.WriteString("  }\n")
+		}
+		b.WriteString("}\n")
+		writeTerraformImport(&b, "aws_iam_role."+resourceName, role.Name)
+
+		for _, inline := range role.InlinePolicies {
+			policyResource := uniqueTerraformName(policyNames, TerraformResourceName(role.Name+"_"+inline.Name))
+			b.WriteString("\n")
+			fmt.Fprintf(&b, "resource \"aws_iam_role_policy\" %q {\n", policyResource)
+			fmt.Fprintf(&b, "  name = %s\n", terraformString(inline.Name))
+			fmt.Fprintf(&b, "  role = aws_iam_role.%s.name\n", resourceName)
+			writeTerraformHeredoc(&b, "policy", inline.Document)
+			b.WriteString("}\n")
+			writeTerraformImport(&b, "aws_iam_role_policy."+policyResource, role.Name+":"+inline.Name)
+		}
+
+		for _, attached := range role.AttachedPolicies {
+			attachmentResource := uniqueTerraformName(attachmentNames, TerraformResourceName(role.Name+"_"+attached.Name))
+			b.WriteString("\n")
+			fmt.Fprintf(&b, "resource \"aws_iam_role_policy_attachment\" %q {\n", attachmentResource)
+			fmt.Fprintf(&b, "  role       = aws_iam_role.%s.name\n", resourceName)
+			fmt.Fprintf(&b, "  policy_arn = %s\n", terraformString(attached.Arn))
+			b.WriteString("}\n")
+			writeTerraformImport(&b, "aws_iam_role_policy_attachment."+attachmentResource, role.Name+"/"+attached.Arn)
+		}
+	}
+	return b.String()
+}
+
+// uniqueTerraformName returns name, or name with the first suffix not yet
+// used by a resource of the same type, and marks it used
+func uniqueTerraformName(used map[string]bool, name string) string {
+	unique := name
+	for suffix := 2; used[unique]; suffix++ {
+		unique = fmt.Sprintf("%s_%d", name, suffix)
+	}
+	used[unique] = true
+	return unique
+}
+
+// writeTerraformAttributes writes attributes with their equals signs
+// aligned, as terraform fmt does
+func writeTerraformAttributes(b *strings.Builder, attributes [][2]string) {
+	width := 0
+	for _, attribute := range attributes {
+		width = max(width, len(attribute[0]))
+	}
+	for _, attribute := range attributes {
+		fmt.Fprintf(b, "  %-*s = %s\n", width, attribute[0], attribute[1])
+	}
+}
+
+// writeTerraformHeredoc writes a policy document attribute as an indented
+// heredoc
+func writeTerraformHeredoc(b *strings.Builder, attribute, document string) {
+	fmt.Fprintf(b, "  %s = <<-POLICY\n", attribute)
+	for _, line := range strings.Split(terraformEscaper.Replace(indentDocument(document)), "\n") {
+		fmt.Fprintf(b, "    %s\n", line)
+	}
+	b.WriteString("  POLICY\n")
+}
+
+// writeTerraformImport writes an import block for an existing resource
+func writeTerraformImport(b *strings.Builder, address, id string) {
+	b.WriteString("\nimport {\n")
+	fmt.Fprintf(b, "  to = %s\n", address)
+	fmt.Fprintf(b, "  id = %s\n", terraformString(id))
+	b.WriteString("}\n")
+}
+
+// terraformString quotes a string for HCL, escaping template sequences
+func terraformString(s string) string {
+	return quoteString(terraformEscaper.Replace(s))
+}
+
+// invalidLogicalID matches characters not allowed in CloudFormation
+// logical IDs
+var invalidLogicalID = regexp.MustCompile(`[^A-Za-z0-9]`)
+
+// RolesCloudFormation renders roles as a CloudFormation template with one
+// AWS::IAM::Role resource each. Resources are retained on deletion, which
+// CloudFormation requires to import existing resources.
+func RolesCloudFormation(roles []aws.Role) string {
+	var b strings.Builder
+	b.WriteString("AWSTemplateFormatVersion: \"2010-09-09\"\n")
+	b.WriteString("Description: IAM roles exported by hawkling\n")
+	b.WriteString("Resources:\n")
+
+	used := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		logicalID := "Role" + invalidLogicalID.ReplaceAllString(role.Name, "")
+		for suffix := 2; used[logicalID]; suffix++ {
+			logicalID = fmt.Sprintf("Role%s%d", invalidLogicalID.ReplaceAllString(role.Name, ""), suffix)
+		}
+		used[logicalID] = true
+
+		fmt.Fprintf(&b, "  %s:\n", logicalID)
+		b.WriteString("    Type: AWS::IAM::Role\n")
+		b.WriteString("    DeletionPolicy: Retain\n")
+		b.WriteString("    UpdateReplacePolicy: Retain\n")
+		b.WriteString("    Properties:\n")
+		fmt.Fprintf(&b, "      RoleName: %s\n", yamlString(role.Name))
+		if role.Path != "" {
+			fmt.Fprintf(&b, "      Path: %s\n", yamlString(role.Path))
+		}
+		if role.Description != "" {
+			fmt.Fprintf(&b, "      Description: %s\n", yamlString(role.Description))
+		}
+		if role.MaxSessionDuration != 0 {
+			fmt.Fprintf(&b, "      MaxSessionDuration: %d\n", role.MaxSessionDuration)
+		}
+		if role.PermissionsBoundary != "" {
+			fmt.Fprintf(&b, "      PermissionsBoundary: %s\n", yamlString(role.PermissionsBoundary))
+		}
+		writeYAMLDocument(&b, "      AssumeRolePolicyDocument: ", role.TrustPolicy)
+
+		if len(role.AttachedPolicies) > 0 {
+			b.WriteString("      ManagedPolicyArns:\n")
+			for _, attached := range role.AttachedPolicies {
+				fmt.Fprintf(&b, "        - %s\n", yamlString(attached.Arn))
+			}
+		}
+		if len(role.InlinePolicies) > 0 {
+			b.WriteString("      Policies:\n")
+			for _, inline := range role.InlinePolicies {
+				fmt.Fprintf(&b, "        - PolicyName: %s\n", yamlString(inline.Name))
+				writeYAMLDocument(&b, "          PolicyDocument: ", inline.Document)
+			}
+		}
+		if tags := userTags(role.Tags); len(tags) > 0 {
+			b.WriteString("      Tags:\n")
+			for _, key := range tags {
+				fmt.Fprintf(&b, "        - Key: %s\n", yamlString(key))
+				fmt.Fprintf(&b, "          Value: %s\n", yamlString(role.Tags[key]))
+			}
+		}
+	}
+	return b.String()
+}
+
+// writeYAMLDocument writes a policy document as indented JSON, which YAML
+// reads as a flow mapping, so the document is kept exactly
+func writeYAMLDocument(b *strings.Builder, key, document string) {
+	indent := strings.Repeat(" ", len(key)-len(strings.TrimLeft(key, " "))+2)
+	lines := strings.Split(indentDocument(document), "\n")
+	fmt.Fprintf(b, "%s%s\n", key, lines[0])
+	for _, line := range lines[1:] {
+		fmt.Fprintf(b, "%s%s\n", indent, line)
+	}
+}
+
+// yamlString quotes a string for YAML; JSON strings are valid YAML
+func yamlString(s string) string {
+	return quoteString(s)
+}
+
+// quoteString quotes a string with JSON escapes, which HCL and YAML share,
+// leaving HTML characters such as & readable
+func quoteString(s string) string {
+	var quoted bytes.Buffer
+	encoder := json.NewEncoder(&quoted)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+	return strings.TrimSuffix(quoted.String(), "\n")
+}
+
+// indentDocument pretty-prints a policy document without reordering it,
+// or returns it unchanged if it is not valid JSON
+func indentDocument(document string) string {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, []byte(document), "", "  "); err != nil {
+		return strings.TrimRight(document, "\n")
+	}
+	return indented.String()
+}
+
+// userTags returns the tag keys a template can set in order, leaving out
+// the aws: tags that AWS sets itself
+func userTags(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		if !strings.HasPrefix(key, "aws:") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -21,11 +22,12 @@ const (
 	JSONFormat Format = "json"
 )
 
-// FormatRoles formats the roles according to the specified format
-func FormatRoles(roles []aws.Role, format Format, showAllInfo bool) error {
+// FormatRoles formats the roles according to the specified format. showRisk
+// adds the risk score and its factors to the table.
+func FormatRoles(roles []aws.Role, format Format, showAllInfo, showRisk bool) error {
 	switch format {
 	case TableFormat:
-		return formatRolesAsTable(roles, showAllInfo)
+		return formatRolesAsTable(roles, showAllInfo, showRisk)
 	case JSONFormat:
 		return FormatRolesAsJSON(roles)
 	default:
@@ -34,14 +36,29 @@ func FormatRoles(roles []aws.Role, format Format, showAllInfo bool) error {
 }
 
 // formatRolesAsTable prints roles in tabular format
-func formatRolesAsTable(roles []aws.Role, showAllInfo bool) error {
+func formatRolesAsTable(roles []aws.Role, showAllInfo, showRisk bool) error {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 
+	header := "NAME\tLAST USED\tDESCRIPTION"
 	if showAllInfo {
-		fmt.Fprintln(w, "NAME\tARN\tCREATED\tLAST USED\tDESCRIPTION")
-	} else {
-		fmt.Fprintln(w, "NAME\tLAST USED\tDESCRIPTION")
+		header = "NAME\tARN\tCREATED\tLAST USED\tDESCRIPTION"
 	}
+	if showRisk {
+		header += "\tRISK\tFACTORS"
+	}
+	showStack := anyRole(roles, func(role aws.Role) bool { return role.Stack != nil })
+	if showStack {
+		header += "\tSTACK"
+	}
+	showTerraform := anyRole(roles, func(role aws.Role) bool { return role.TerraformAddress != "" })
+	if showTerraform {
+		header += "\tTERRAFORM"
+	}
+	showCreator := anyRole(roles, func(role aws.Role) bool { return role.Creator != nil })
+	if showCreator {
+		header += "\tCREATOR"
+	}
+	fmt.Fprintln(w, header)
 
 	for _, role := range roles {
 		lastUsed := "Never"
@@ -50,7 +67,7 @@ func formatRolesAsTable(roles []aws.Role, showAllInfo bool) error {
 		}
 
 		if showAllInfo {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s",
 				role.Name,
 				role.Arn,
 				role.CreateDate.Format(time.RFC3339),
@@ -58,26 +75,72 @@ func formatRolesAsTable(roles []aws.Role, showAllInfo bool) error {
 				TruncateString(role.Description, 50),
 			)
 		} else {
-			fmt.Fprintf(w, "%s\t%s\t%s\n",
+			fmt.Fprintf(w, "%s\t%s\t%s",
 				role.Name,
 				lastUsed,
 				TruncateString(role.Description, 50),
 			)
 		}
+
+		if showRisk {
+			fmt.Fprintf(w, "\t%d\t%s", role.RiskScore, orDash(strings.Join(role.RiskFactors, "; ")))
+		}
+		if showStack {
+			stack := "-"
+			if role.Stack != nil {
+				stack = role.Stack.String()
+			}
+			fmt.Fprintf(w, "\t%s", stack)
+		}
+		if showTerraform {
+			fmt.Fprintf(w, "\t%s", orDash(role.TerraformAddress))
+		}
+		if showCreator {
+			creator := "-"
+			if role.Creator != nil {
+				creator = role.Creator.String()
+			}
+			fmt.Fprintf(w, "\t%s", creator)
+		}
+		fmt.Fprintln(w)
 	}
 
 	return w.Flush()
 }
 
+// anyRole reports whether any role satisfies the predicate, so columns
+// that only some lookups fill are shown only when they have values
+func anyRole(roles []aws.Role, predicate func(aws.Role) bool) bool {
+	for _, role := range roles {
+		if predicate(role) {
+			return true
+		}
+	}
+	return false
+}
+
 // FormatRolesAsJSON prints roles in JSON format
 func FormatRolesAsJSON(roles []aws.Role) error {
+	return writeJSON(roles)
+}
+
+// writeJSON prints v as indented JSON
+func writeJSON(v interface{}) error {
 	// Create an encoder that writes directly to stdout to avoid allocating
 	// a large string in memory for the entire JSON output
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
 	encoder.SetEscapeHTML(false)
 
-	return encoder.Encode(roles)
+	return encoder.Encode(v)
+}
+
+// formatLastUsed formats a last used timestamp, or "Never" if unset
+func formatLastUsed(lastUsed *time.Time) string {
+	if lastUsed == nil {
+		return "Never"
+	}
+	return lastUsed.Format(time.RFC3339)
 }
 
 // TruncateString truncates a string if it's longer than the specified length
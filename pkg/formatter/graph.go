@@ -0,0 +1,150 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"hawkling/pkg/audit"
+)
+
+const (
+	// DOTFormat outputs a role graph in Graphviz DOT
+	DOTFormat Format = "dot"
+
+	// MermaidFormat outputs a role graph as a Mermaid flowchart
+	MermaidFormat Format = "mermaid"
+)
+
+// nodeShapes gives each kind of node a distinct DOT shape
+var nodeShapes = map[string]string{
+	audit.NodeRole:      "box",
+	audit.NodeService:   "ellipse",
+	audit.NodeAccount:   "house",
+	audit.NodeFederated: "hexagon",
+	audit.NodePrincipal: "ellipse",
+	audit.NodePublic:    "doubleoctagon",
+}
+
+// FormatRoleGraph formats a role graph according to the specified format
+func FormatRoleGraph(graph *audit.RoleGraph, format Format) error {
+	switch format {
+	case DOTFormat:
+		fmt.Print(RoleGraphDOT(graph))
+		return nil
+	case MermaidFormat:
+		fmt.Print(RoleGraphMermaid(graph))
+		return nil
+	case TableFormat:
+		return formatRoleGraphAsTable(graph)
+	case JSONFormat:
+		return writeJSON(graph)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// RoleGraphDOT renders a role graph in Graphviz DOT. Unused roles are
+// dashed and grey, edges only granted by identity policies are dashed and
+// conditional trust is dotted.
+func RoleGraphDOT(graph *audit.RoleGraph) string {
+	var b strings.Builder
+	b.WriteString("digraph roles {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [fontname=\"Helvetica\"];\n")
+
+	for _, node := range graph.Nodes {
+		attributes := fmt.Sprintf("label=%q, shape=%s", node.Label, nodeShapes[node.Kind])
+		if node.Unused {
+			attributes += ", style=dashed, color=grey50, fontcolor=grey50"
+		}
+		fmt.Fprintf(&b, "  %q [%s];\n", node.ID, attributes)
+	}
+
+	for _, edge := range graph.Edges {
+		var attributes []string
+		if !edge.Trust {
+			attributes = append(attributes, "style=dashed", "label=\"identity only\"")
+		} else if edge.Conditional {
+			attributes = append(attributes, "style=dotted", "label=\"conditional\"")
+		}
+		if len(attributes) > 0 {
+			fmt.Fprintf(&b, "  %q -> %q [%s];\n", edge.From, edge.To, strings.Join(attributes, ", "))
+		} else {
+			fmt.Fprintf(&b, "  %q -> %q;\n", edge.From, edge.To)
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// RoleGraphMermaid renders a role graph as a Mermaid flowchart. Mermaid
+// node IDs cannot hold ARNs, so nodes are numbered.
+func RoleGraphMermaid(graph *audit.RoleGraph) string {
+	ids := make(map[string]string, len(graph.Nodes))
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	b.WriteString("  classDef unused stroke-dasharray: 5 5,color:#888,stroke:#888\n")
+
+	for i, node := range graph.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[node.ID] = id
+
+		label := strings.ReplaceAll(node.Label, `"`, "#quot;")
+		switch node.Kind {
+		case audit.NodeRole:
+			fmt.Fprintf(&b, "  %s[\"%s\"]\n", id, label)
+		case audit.NodeAccount:
+			fmt.Fprintf(&b, "  %s[/\"%s\"\\]\n", id, label)
+		case audit.NodePublic:
+			fmt.Fprintf(&b, "  %s{{\"%s\"}}\n", id, label)
+		default:
+			fmt.Fprintf(&b, "  %s([\"%s\"])\n", id, label)
+		}
+		if node.Unused {
+			fmt.Fprintf(&b, "  class %s unused\n", id)
+		}
+	}
+
+	for _, edge := range graph.Edges {
+		switch {
+		case !edge.Trust:
+			fmt.Fprintf(&b, "  %s -.->|identity only| %s\n", ids[edge.From], ids[edge.To])
+		case edge.Conditional:
+			fmt.Fprintf(&b, "  %s -.->|conditional| %s\n", ids[edge.From], ids[edge.To])
+		default:
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[edge.From], ids[edge.To])
+		}
+	}
+
+	return b.String()
+}
+
+// formatRoleGraphAsTable prints one row per edge
+func formatRoleGraphAsTable(graph *audit.RoleGraph) error {
+	if len(graph.Edges) == 0 {
+		fmt.Println("No assume-role edges found")
+		return nil
+	}
+
+	labels := make(map[string]string, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		labels[node.ID] = node.Label
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FROM\tTO\tTRUST\tIDENTITY\tCONDITIONAL")
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(w, "%s\t%s\t%t\t%t\t%t\n",
+			labels[edge.From],
+			labels[edge.To],
+			edge.Trust,
+			edge.Identity,
+			edge.Conditional,
+		)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,74 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"hawkling/pkg/lint"
+)
+
+// FormatLintFindings formats lint findings according to the specified format
+func FormatLintFindings(linter *lint.Linter, findings []lint.Finding, format Format) error {
+	switch format {
+	case TableFormat:
+		return formatLintFindingsAsTable(findings)
+	case JSONFormat:
+		return writeJSON(findings)
+	case SARIFFormat:
+		return writeJSON(sarifFromFindings(linter, findings))
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// formatLintFindingsAsTable prints one row per finding
+func formatLintFindingsAsTable(findings []lint.Finding) error {
+	if len(findings) == 0 {
+		fmt.Println("No lint findings")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SEVERITY\tRULE\tROLE\tMESSAGE")
+	for _, finding := range findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", finding.Severity, finding.RuleID, finding.RoleName, finding.Message)
+	}
+	return w.Flush()
+}
+
+// sarifFromFindings builds a SARIF log describing the enabled rules and findings
+func sarifFromFindings(linter *lint.Linter, findings []lint.Finding) sarifLog {
+	var rules []sarifRule
+	for _, rule := range linter.EnabledRules() {
+		rules = append(rules, sarifRule{
+			ID:                   rule.ID(),
+			ShortDescription:     sarifMessage{Text: rule.Description()},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(linter.Severity(rule))},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(findings))
+	for _, finding := range findings {
+		results = append(results, sarifResult{
+			RuleID:    finding.RuleID,
+			Level:     sarifLevel(finding.Severity),
+			Message:   sarifMessage{Text: finding.RoleName + ": " + finding.Message},
+			Locations: roleLocation(finding.RoleName, finding.RoleArn),
+		})
+	}
+
+	return newSARIFLog(rules, results)
+}
+
+// sarifLevel maps a severity to a SARIF result level
+func sarifLevel(severity lint.Severity) string {
+	switch severity {
+	case lint.SeverityCritical, lint.SeverityHigh:
+		return "error"
+	case lint.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
@@ -0,0 +1,67 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"hawkling/pkg/audit"
+)
+
+// FormatOIDCGroups formats OIDC trust audit results according to the specified format
+func FormatOIDCGroups(groups []audit.OIDCProviderGroup, format Format) error {
+	switch format {
+	case TableFormat:
+		return formatOIDCGroupsAsTable(groups)
+	case JSONFormat:
+		return writeJSON(groups)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// formatOIDCGroupsAsTable prints one table per OIDC provider
+func formatOIDCGroupsAsTable(groups []audit.OIDCProviderGroup) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	for i, group := range groups {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		status := ""
+		if !group.Exists {
+			status = " [PROVIDER MISSING]"
+		}
+		fmt.Fprintf(w, "PROVIDER: %s (%s)%s\n", group.Provider, group.Kind, status)
+		fmt.Fprintln(w, "ROLE\tLAST USED\tSUBJECTS\tISSUES")
+
+		for _, trust := range group.Roles {
+			subjects := trust.Subjects
+			if len(trust.ServiceAccounts) > 0 {
+				subjects = make([]string, 0, len(trust.ServiceAccounts))
+				for _, sa := range trust.ServiceAccounts {
+					subjects = append(subjects, sa.String())
+				}
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				trust.RoleName,
+				formatLastUsed(trust.LastUsed),
+				orDash(strings.Join(subjects, ", ")),
+				orDash(strings.Join(trust.Issues, "; ")),
+			)
+		}
+	}
+
+	return w.Flush()
+}
+
+// orDash returns "-" for empty values
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,55 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"hawkling/pkg/audit"
+)
+
+// FormatEscalationFindings formats privilege escalation findings according to the specified format
+func FormatEscalationFindings(findings []audit.EscalationFinding, format Format) error {
+	switch format {
+	case TableFormat:
+		return formatEscalationFindingsAsTable(findings)
+	case JSONFormat:
+		return writeJSON(findings)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// formatEscalationFindingsAsTable prints one row per escalation path
+func formatEscalationFindingsAsTable(findings []audit.EscalationFinding) error {
+	if len(findings) == 0 {
+		fmt.Println("No privilege escalation paths found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ROLE\tLAST USED\tUNUSED\tPATTERN\tPATH")
+
+	for _, finding := range findings {
+		unused := "-"
+		if finding.Unused {
+			unused = "yes"
+		}
+
+		separator := " + "
+		if finding.Pattern == audit.PatternAssumeRoleChain {
+			separator = " -> "
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			finding.RoleName,
+			formatLastUsed(finding.LastUsed),
+			unused,
+			finding.Pattern,
+			strings.Join(finding.Path, separator),
+		)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,54 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"hawkling/pkg/audit"
+)
+
+// FormatRightsizeReports formats service last accessed reports according to the specified format
+func FormatRightsizeReports(reports []audit.RightsizeReport, format Format) error {
+	switch format {
+	case TableFormat:
+		return formatRightsizeReportsAsTable(reports)
+	case JSONFormat:
+		return writeJSON(reports)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// formatRightsizeReportsAsTable prints one row per unused service. Services
+// that were accessed but have unused tracked actions list those actions.
+func formatRightsizeReportsAsTable(reports []audit.RightsizeReport) error {
+	unused := 0
+	for _, report := range reports {
+		unused += len(report.UnusedServices)
+	}
+	if unused == 0 {
+		fmt.Println("No unused services found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ROLE\tSERVICE\tLAST ACCESSED\tUNUSED ACTIONS")
+	for _, report := range reports {
+		for _, service := range report.UnusedServices {
+			actions := "all"
+			if !service.Unused {
+				actions = strings.Join(service.UnusedActions, ", ")
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				report.RoleName,
+				service.ServiceNamespace,
+				formatLastUsed(service.LastAccessed),
+				actions,
+			)
+		}
+	}
+	return w.Flush()
+}
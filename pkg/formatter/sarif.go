@@ -0,0 +1,86 @@
+package formatter
+
+// SARIFFormat outputs findings as a SARIF 2.1.0 log
+const SARIFFormat Format = "sarif"
+
+// sarifSchema is the JSON schema of SARIF 2.1.0 logs
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// SARIF log structure, limited to the properties hawkling fills in
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	HelpURI              string             `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName,omitempty"`
+	Kind               string `json:"kind"`
+}
+
+// newSARIFLog builds a SARIF log of a single hawkling run
+func newSARIFLog(rules []sarifRule, results []sarifResult) sarifLog {
+	driver := sarifDriver{
+		Name:           "hawkling",
+		InformationURI: "https://github.com/watany-dev/hawkling",
+		Rules:          rules,
+	}
+	return sarifLog{
+		Version: "2.1.0",
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+}
+
+// roleLocation is the SARIF location of a role
+func roleLocation(roleName, roleArn string) []sarifLocation {
+	return []sarifLocation{{
+		LogicalLocations: []sarifLogicalLocation{{
+			Name:               roleName,
+			FullyQualifiedName: roleArn,
+			Kind:               "resource",
+		}},
+	}}
+}
@@ -0,0 +1,53 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"hawkling/pkg/audit"
+)
+
+// FormatServiceRoleFindings formats auto-generated service roles and
+// whether their owning resource still uses them
+func FormatServiceRoleFindings(findings []audit.ServiceRoleFinding, format Format) error {
+	switch format {
+	case TableFormat:
+		return formatServiceRoleFindingsAsTable(findings)
+	case JSONFormat:
+		return writeJSON(findings)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// formatServiceRoleFindingsAsTable prints one row per service role
+func formatServiceRoleFindingsAsTable(findings []audit.ServiceRoleFinding) error {
+	if len(findings) == 0 {
+		fmt.Println("No auto-generated service roles found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ROLE\tSERVICE\tOWNER\tLAST USED\tSTATUS\tCONFIDENCE\tDETAILS")
+
+	for _, finding := range findings {
+		details := finding.Reason
+		if len(finding.UsedBy) > 0 {
+			details = strings.Join(finding.UsedBy, ", ")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			finding.RoleName,
+			finding.Service,
+			orDash(finding.Owner),
+			formatLastUsed(finding.LastUsed),
+			finding.Status,
+			orDash(finding.Confidence),
+			details,
+		)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,42 @@
+package formatter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// invalidTerraformName matches characters not allowed in Terraform
+// resource names
+var invalidTerraformName = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// TerraformResourceName turns an IAM name into a valid Terraform resource
+// name
+func TerraformResourceName(name string) string {
+	resourceName := invalidTerraformName.ReplaceAllString(name, "_")
+	if resourceName == "" || (resourceName[0] >= '0' && resourceName[0] <= '9') || resourceName[0] == '-' {
+		resourceName = "_" + resourceName
+	}
+	return resourceName
+}
+
+// terraformEscaper escapes template sequences in heredoc strings, so policy
+// variables such as ${aws:username} are kept literally
+var terraformEscaper = strings.NewReplacer("${", "$${", "%{", "%%{")
+
+// TerraformRolePolicy renders an aws_iam_role_policy resource with the
+// policy document as a heredoc
+func TerraformRolePolicy(roleName, policyName, document string) string {
+	document = terraformEscaper.Replace(document)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "resource \"aws_iam_role_policy\" %q {\n", TerraformResourceName(roleName+"_"+policyName))
+	fmt.Fprintf(&b, "  name   = %q\n", policyName)
+	fmt.Fprintf(&b, "  role   = %q\n", roleName)
+	fmt.Fprintf(&b, "  policy = <<-POLICY\n")
+	for _, line := range strings.Split(strings.TrimRight(document, "\n"), "\n") {
+		fmt.Fprintf(&b, "    %s\n", line)
+	}
+	fmt.Fprintf(&b, "  POLICY\n}\n")
+	return b.String()
+}
@@ -0,0 +1,112 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+)
+
+// FormatRoleValidations formats Access Analyzer validation findings according to the specified format
+func FormatRoleValidations(validations []audit.RoleValidation, format Format) error {
+	switch format {
+	case TableFormat:
+		return formatRoleValidationsAsTable(validations)
+	case JSONFormat:
+		return writeJSON(validations)
+	case SARIFFormat:
+		return writeJSON(sarifFromValidations(validations))
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// formatRoleValidationsAsTable prints one row per finding followed by the
+// number of findings of each type per role
+func formatRoleValidationsAsTable(validations []audit.RoleValidation) error {
+	if len(validations) == 0 {
+		fmt.Println("No validation findings")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ROLE\tTYPE\tPOLICY\tLOCATION\tISSUE\tDETAILS")
+	for _, validation := range validations {
+		for _, finding := range validation.Findings {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				validation.RoleName,
+				finding.FindingType,
+				finding.PolicyName,
+				orDash(finding.Location),
+				finding.IssueCode,
+				finding.Details,
+			)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ROLE\tERRORS\tSECURITY WARNINGS\tWARNINGS\tSUGGESTIONS")
+	for _, validation := range validations {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n",
+			validation.RoleName,
+			validation.Errors,
+			validation.SecurityWarnings,
+			validation.Warnings,
+			validation.Suggestions,
+		)
+	}
+	return w.Flush()
+}
+
+// sarifFromValidations builds a SARIF log with a rule per Access Analyzer
+// issue code and a result per finding
+func sarifFromValidations(validations []audit.RoleValidation) sarifLog {
+	var rules []sarifRule
+	seen := make(map[string]bool)
+	results := []sarifResult{}
+	for _, validation := range validations {
+		for _, finding := range validation.Findings {
+			level := validationLevel(finding.FindingType)
+			if !seen[finding.IssueCode] {
+				seen[finding.IssueCode] = true
+				rules = append(rules, sarifRule{
+					ID:                   finding.IssueCode,
+					ShortDescription:     sarifMessage{Text: finding.IssueCode},
+					HelpURI:              finding.LearnMoreLink,
+					DefaultConfiguration: sarifConfiguration{Level: level},
+				})
+			}
+
+			where := finding.PolicyName
+			if finding.Location != "" {
+				where += " " + finding.Location
+			}
+			results = append(results, sarifResult{
+				RuleID:    finding.IssueCode,
+				Level:     level,
+				Message:   sarifMessage{Text: fmt.Sprintf("%s: %s: %s", validation.RoleName, where, finding.Details)},
+				Locations: roleLocation(validation.RoleName, validation.RoleArn),
+			})
+		}
+	}
+
+	return newSARIFLog(rules, results)
+}
+
+// validationLevel maps an Access Analyzer finding type to a SARIF result level
+func validationLevel(findingType string) string {
+	switch findingType {
+	case aws.FindingTypeError, aws.FindingTypeSecurityWarning:
+		return "error"
+	case aws.FindingTypeWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
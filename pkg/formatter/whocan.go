@@ -0,0 +1,82 @@
+package formatter
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/policy"
+)
+
+// FormatRoleAccess formats the roles allowed to perform a request
+func FormatRoleAccess(roles []audit.RoleAccess, format Format) error {
+	switch format {
+	case TableFormat:
+		return formatRoleAccessAsTable(roles)
+	case JSONFormat:
+		return writeJSON(roles)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// formatRoleAccessAsTable prints allowed roles with the policies granting access
+func formatRoleAccessAsTable(roles []audit.RoleAccess) error {
+	if len(roles) == 0 {
+		fmt.Println("No roles are allowed to perform this action")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ROLE\tLAST USED\tUNUSED\tACCESS\tBOUNDARY\tGRANTED BY")
+
+	for _, role := range roles {
+		unused := "-"
+		if role.Unused {
+			unused = "yes"
+		}
+
+		access := "allowed"
+		if role.Conditional {
+			access = "conditional on " + strings.Join(role.Unresolved, ", ")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			role.RoleName,
+			formatLastUsed(role.LastUsed),
+			unused,
+			access,
+			orDash(policyName(role.PermissionsBoundary)),
+			strings.Join(grantingPolicies(role), ", "),
+		)
+	}
+
+	return w.Flush()
+}
+
+// grantingPolicies returns the identity policies whose statements allowed
+// the request, or would allow it under their conditions
+func grantingPolicies(role audit.RoleAccess) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, match := range role.Matches {
+		if match.SourceType != policy.SourceIdentity || seen[match.Source] {
+			continue
+		}
+		seen[match.Source] = true
+		names = append(names, match.Source)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// policyName returns the name part of a policy ARN
+func policyName(arn string) string {
+	if i := strings.LastIndex(arn, "/"); i >= 0 {
+		return arn[i+1:]
+	}
+	return arn
+}
@@ -0,0 +1,213 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+)
+
+// Severity is the severity of a lint finding
+type Severity string
+
+// Severities from least to most severe
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+var severityRanks = map[Severity]int{
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// ParseSeverity parses a severity name, ignoring case
+func ParseSeverity(name string) (Severity, error) {
+	severity := Severity(strings.ToLower(name))
+	if _, ok := severityRanks[severity]; !ok {
+		return "", fmt.Errorf("unknown severity %q, expected low, medium, high or critical", name)
+	}
+	return severity, nil
+}
+
+// AtLeast reports whether the severity is at least as severe as other
+func (s Severity) AtLeast(other Severity) bool {
+	return severityRanks[s] >= severityRanks[other]
+}
+
+// Rule checks a role for a single kind of problem. Custom rules can be
+// added to a Linter alongside the built-in rules.
+type Rule interface {
+	// ID returns the identifier used in findings and configuration
+	ID() string
+
+	// Description returns a one-line description of what the rule checks
+	Description() string
+
+	// DefaultSeverity returns the severity used unless configured otherwise
+	DefaultSeverity() Severity
+
+	// Check returns a message for each problem found in the role
+	Check(role aws.Role, config *Config) []string
+}
+
+// RuleConfig overrides the defaults of a rule
+type RuleConfig struct {
+	Enabled  *bool
+	Severity Severity
+}
+
+// Config configures the linter and the parameters of the built-in rules
+type Config struct {
+	Rules map[string]RuleConfig
+
+	// MaxSessionDuration is the longest allowed session duration in seconds
+	MaxSessionDuration int32
+
+	// RequiredTags lists tag keys every role must have
+	RequiredTags []string
+
+	// BoundaryPaths lists role path prefixes under which roles must have a
+	// permissions boundary
+	BoundaryPaths []string
+
+	// RequiredBoundaries names the exact boundary roles under a path must use
+	RequiredBoundaries []audit.BoundaryRequirement
+}
+
+// DefaultConfig returns the configuration used when no file is given
+func DefaultConfig() *Config {
+	return &Config{
+		Rules:              make(map[string]RuleConfig),
+		MaxSessionDuration: 3600,
+	}
+}
+
+// LoadConfig reads a JSON configuration file. Settings missing from the
+// file keep their defaults.
+func LoadConfig(path string) (*Config, error) {
+	config := DefaultConfig()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lint config %s: %w", path, err)
+	}
+	if err := json.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("failed to parse lint config %s: %w", path, err)
+	}
+
+	for id, ruleConfig := range config.Rules {
+		if ruleConfig.Severity == "" {
+			continue
+		}
+		severity, err := ParseSeverity(string(ruleConfig.Severity))
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", id, err)
+		}
+		ruleConfig.Severity = severity
+		config.Rules[id] = ruleConfig
+	}
+
+	return config, nil
+}
+
+// Finding is a problem reported by a rule
+type Finding struct {
+	RuleID   string
+	Severity Severity
+	RoleName string
+	RoleArn  string
+	Message  string
+}
+
+// Linter runs a set of rules against roles
+type Linter struct {
+	Rules  []Rule
+	Config *Config
+}
+
+// NewLinter creates a linter with the built-in rules and any extra rules
+func NewLinter(config *Config, extra ...Rule) *Linter {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Linter{
+		Rules:  append(BuiltinRules(), extra...),
+		Config: config,
+	}
+}
+
+// EnabledRules returns the rules that are not disabled in the configuration
+func (l *Linter) EnabledRules() []Rule {
+	enabled := make([]Rule, 0, len(l.Rules))
+	for _, rule := range l.Rules {
+		if ruleConfig, ok := l.Config.Rules[rule.ID()]; ok && ruleConfig.Enabled != nil && !*ruleConfig.Enabled {
+			continue
+		}
+		enabled = append(enabled, rule)
+	}
+	return enabled
+}
+
+// Severity returns the configured severity of a rule
+func (l *Linter) Severity(rule Rule) Severity {
+	if ruleConfig, ok := l.Config.Rules[rule.ID()]; ok && ruleConfig.Severity != "" {
+		return ruleConfig.Severity
+	}
+	return rule.DefaultSeverity()
+}
+
+// Validate reports configuration entries that do not name a known rule
+func (l *Linter) Validate() error {
+	known := make(map[string]bool, len(l.Rules))
+	for _, rule := range l.Rules {
+		known[rule.ID()] = true
+	}
+	for id := range l.Config.Rules {
+		if !known[id] {
+			return fmt.Errorf("unknown lint rule %q in configuration", id)
+		}
+	}
+	return nil
+}
+
+// Lint runs every enabled rule against every role. Findings are sorted by
+// severity, most severe first, then by role and rule.
+func (l *Linter) Lint(roles []aws.Role) []Finding {
+	var findings []Finding
+	for _, rule := range l.EnabledRules() {
+		severity := l.Severity(rule)
+		for _, role := range roles {
+			for _, message := range rule.Check(role, l.Config) {
+				findings = append(findings, Finding{
+					RuleID:   rule.ID(),
+					Severity: severity,
+					RoleName: role.Name,
+					RoleArn:  role.Arn,
+					Message:  message,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if severityRanks[a.Severity] != severityRanks[b.Severity] {
+			return severityRanks[a.Severity] > severityRanks[b.Severity]
+		}
+		if a.RoleName != b.RoleName {
+			return a.RoleName < b.RoleName
+		}
+		return a.RuleID < b.RuleID
+	})
+
+	return findings
+}
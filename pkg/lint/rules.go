@@ -0,0 +1,204 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/policy"
+)
+
+// Built-in rule IDs
+const (
+	RuleMaxSessionDuration  = "max-session-duration"
+	RuleMissingDescription  = "missing-description"
+	RuleRequiredTags        = "required-tags"
+	RuleBoundaryRequired    = "boundary-required"
+	RuleBoundaryCompliance  = "boundary-compliance"
+	RuleAdministratorAccess = "administrator-access"
+	RuleInlineWildcard      = "inline-wildcard"
+	RuleTrustWithoutMFA     = "trust-without-mfa"
+)
+
+// administratorAccessArn is the AWS managed policy granting full access
+const administratorAccessArn = "arn:aws:iam::aws:policy/AdministratorAccess"
+
+// checkFunc checks a role and returns a message per problem
+type checkFunc func(role aws.Role, config *Config) []string
+
+// builtinRule implements Rule with a check function
+type builtinRule struct {
+	id          string
+	description string
+	severity    Severity
+	check       checkFunc
+}
+
+func (r builtinRule) ID() string                { return r.id }
+func (r builtinRule) Description() string       { return r.description }
+func (r builtinRule) DefaultSeverity() Severity { return r.severity }
+
+func (r builtinRule) Check(role aws.Role, config *Config) []string {
+	return r.check(role, config)
+}
+
+// NewRule creates a rule from a check function, for registering custom rules
+func NewRule(id, description string, severity Severity, check func(role aws.Role, config *Config) []string) Rule {
+	return builtinRule{id: id, description: description, severity: severity, check: check}
+}
+
+// BuiltinRules returns the rules shipped with hawkling
+func BuiltinRules() []Rule {
+	return []Rule{
+		builtinRule{RuleMaxSessionDuration, "Maximum session duration exceeds the configured limit", SeverityMedium, checkMaxSessionDuration},
+		builtinRule{RuleMissingDescription, "Role has no description", SeverityLow, checkMissingDescription},
+		builtinRule{RuleRequiredTags, "Role is missing a required tag", SeverityMedium, checkRequiredTags},
+		builtinRule{RuleBoundaryRequired, "Role under a protected path has no permissions boundary", SeverityHigh, checkBoundaryRequired},
+		builtinRule{RuleBoundaryCompliance, "Role does not use the permissions boundary required for its path", SeverityHigh, checkBoundaryCompliance},
+		builtinRule{RuleAdministratorAccess, "AWS managed AdministratorAccess policy is attached", SeverityHigh, checkAdministratorAccess},
+		builtinRule{RuleInlineWildcard, "Inline policy allows every action on every resource", SeverityCritical, checkInlineWildcard},
+		builtinRule{RuleTrustWithoutMFA, "Role assumable by users does not require MFA", SeverityMedium, checkTrustWithoutMFA},
+	}
+}
+
+func checkMaxSessionDuration(role aws.Role, config *Config) []string {
+	if config.MaxSessionDuration <= 0 || role.MaxSessionDuration <= config.MaxSessionDuration {
+		return nil
+	}
+	return []string{fmt.Sprintf("maximum session duration is %ds, limit is %ds", role.MaxSessionDuration, config.MaxSessionDuration)}
+}
+
+func checkMissingDescription(role aws.Role, config *Config) []string {
+	if strings.TrimSpace(role.Description) != "" {
+		return nil
+	}
+	return []string{"role has no description"}
+}
+
+func checkRequiredTags(role aws.Role, config *Config) []string {
+	var messages []string
+	for _, key := range config.RequiredTags {
+		if _, ok := role.Tags[key]; !ok {
+			messages = append(messages, fmt.Sprintf("missing required tag %s", key))
+		}
+	}
+	return messages
+}
+
+func checkBoundaryRequired(role aws.Role, config *Config) []string {
+	if role.PermissionsBoundary != "" {
+		return nil
+	}
+	for _, prefix := range config.BoundaryPaths {
+		if strings.HasPrefix(role.Path, prefix) {
+			return []string{fmt.Sprintf("roles under path %s must have a permissions boundary", prefix)}
+		}
+	}
+	return nil
+}
+
+func checkBoundaryCompliance(role aws.Role, config *Config) []string {
+	violations := audit.CheckBoundaryCompliance([]aws.Role{role}, config.RequiredBoundaries)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	violation := violations[0]
+	if violation.Status == audit.BoundaryMissing {
+		return []string{fmt.Sprintf("missing required permissions boundary %s", violation.Required)}
+	}
+	return []string{fmt.Sprintf("uses permissions boundary %s instead of %s", violation.Current, violation.Required)}
+}
+
+func checkAdministratorAccess(role aws.Role, config *Config) []string {
+	for _, attached := range role.AttachedPolicies {
+		if attached.Arn == administratorAccessArn {
+			return []string{"AdministratorAccess is attached"}
+		}
+	}
+	return nil
+}
+
+func checkInlineWildcard(role aws.Role, config *Config) []string {
+	var messages []string
+	for _, inline := range role.InlinePolicies {
+		doc, err := policy.Parse(inline.Document)
+		if err != nil {
+			continue
+		}
+		for _, stmt := range doc.Statement {
+			if stmt.Effect != policy.EffectAllow || len(stmt.NotAction) > 0 {
+				continue
+			}
+			if (stmt.Action.Contains("*") || stmt.Action.Contains("*:*")) && stmt.Resource.Contains("*") {
+				messages = append(messages, fmt.Sprintf("inline policy %s allows * on *", inline.Name))
+				break
+			}
+		}
+	}
+	return messages
+}
+
+// checkTrustWithoutMFA flags trust statements that let IAM users assume the
+// role, directly or through their account, without an MFA condition
+func checkTrustWithoutMFA(role aws.Role, config *Config) []string {
+	if role.TrustPolicy == "" {
+		return nil
+	}
+	doc, err := policy.Parse(role.TrustPolicy)
+	if err != nil {
+		return nil
+	}
+
+	var messages []string
+	for _, stmt := range doc.Statement {
+		if stmt.Effect != policy.EffectAllow || stmt.Principal == nil || hasMFACondition(stmt) {
+			continue
+		}
+		for _, principal := range stmt.Principal.Values(policy.PrincipalAWS) {
+			if isHumanPrincipal(principal) {
+				messages = append(messages, fmt.Sprintf("%s can assume the role without MFA", principal))
+			}
+		}
+	}
+	return messages
+}
+
+// hasMFACondition reports whether a statement requires MFA: Bool true on
+// aws:MultiFactorAuthPresent, or a NumericLessThan limit on
+// aws:MultiFactorAuthAge. The IfExists forms pass when the key is missing,
+// as it is without MFA, so they do not count.
+func hasMFACondition(stmt policy.Statement) bool {
+	for _, entry := range stmt.ConditionEntries("aws:MultiFactorAuthPresent") {
+		if entry.Operator == "Bool" && len(entry.Values) > 0 && allTrue(entry.Values) {
+			return true
+		}
+	}
+	for _, entry := range stmt.ConditionEntries("aws:MultiFactorAuthAge") {
+		if entry.Operator == "NumericLessThan" || entry.Operator == "NumericLessThanEquals" {
+			return true
+		}
+	}
+	return false
+}
+
+// allTrue reports whether every value is true, since a condition passes if
+// any of its values does
+func allTrue(values policy.StringList) bool {
+	for _, value := range values {
+		if !strings.EqualFold(value, "true") {
+			return false
+		}
+	}
+	return true
+}
+
+// isHumanPrincipal reports whether an AWS principal may be an IAM user: an
+// account, an account root or a user ARN
+func isHumanPrincipal(principal string) bool {
+	return principal == "*" ||
+		policy.IsAccountID(principal) ||
+		strings.HasSuffix(principal, ":root") ||
+		strings.Contains(principal, ":user/")
+}
@@ -0,0 +1,35 @@
+package policy
+
+import (
+	"regexp"
+	"sort"
+)
+
+// digitRun matches a run of digits, so that account IDs are only matched
+// as whole numbers
+var digitRun = regexp.MustCompile(`[0-9]+`)
+
+// RewriteAccounts replaces the account IDs in a policy document or ARN
+// according to mapping, wherever they appear: in ARNs, as principals and
+// as condition values. Account IDs without a mapping are kept and returned
+// in order.
+func RewriteAccounts(text string, mapping map[string]string) (string, []string) {
+	unmapped := make(map[string]bool)
+	rewritten := digitRun.ReplaceAllStringFunc(text, func(digits string) string {
+		if !IsAccountID(digits) {
+			return digits
+		}
+		if target, ok := mapping[digits]; ok {
+			return target
+		}
+		unmapped[digits] = true
+		return digits
+	})
+
+	accounts := make([]string, 0, len(unmapped))
+	for account := range unmapped {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	return rewritten, accounts
+}
@@ -0,0 +1,94 @@
+package policy
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Canonical returns a copy of the document in a canonical form, so that
+// documents granting the same permissions in a different layout compare
+// equal. Statement IDs and the document ID are dropped, values are sorted
+// and deduplicated, and statements are sorted with duplicates removed.
+func (d *Document) Canonical() *Document {
+	type keyed struct {
+		key  string
+		stmt Statement
+	}
+
+	seen := make(map[string]bool, len(d.Statement))
+	statements := make([]keyed, 0, len(d.Statement))
+	for _, stmt := range d.Statement {
+		stmt = stmt.Canonical()
+		encoded, err := json.Marshal(stmt)
+		if err != nil {
+			continue
+		}
+		key := string(encoded)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		statements = append(statements, keyed{key: key, stmt: stmt})
+	}
+
+	sort.Slice(statements, func(i, j int) bool {
+		return statements[i].key < statements[j].key
+	})
+
+	canonical := &Document{
+		Version:   d.Version,
+		Statement: make(Statements, 0, len(statements)),
+	}
+	for _, s := range statements {
+		canonical.Statement = append(canonical.Statement, s.stmt)
+	}
+	return canonical
+}
+
+// Canonical returns a copy of the statement without its Sid and with every
+// value list sorted and deduplicated
+func (s Statement) Canonical() Statement {
+	canonical := s.Clone()
+	canonical.Sid = ""
+	canonical.Action = canonical.Action.sorted()
+	canonical.NotAction = canonical.NotAction.sorted()
+	canonical.Resource = canonical.Resource.sorted()
+	canonical.NotResource = canonical.NotResource.sorted()
+	canonical.Principal = canonical.Principal.sorted()
+	canonical.NotPrincipal = canonical.NotPrincipal.sorted()
+
+	for _, keys := range canonical.Condition {
+		for key, values := range keys {
+			keys[key] = values.sorted()
+		}
+	}
+
+	return canonical
+}
+
+// sorted sorts and deduplicates the list in place
+func (l StringList) sorted() StringList {
+	if len(l) == 0 {
+		return l
+	}
+
+	sort.Strings(l)
+	unique := l[:1]
+	for _, value := range l[1:] {
+		if value != unique[len(unique)-1] {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// sorted sorts and deduplicates the values of every principal type in place
+func (p *Principal) sorted() *Principal {
+	if p == nil {
+		return nil
+	}
+	for principalType, values := range p.Entries {
+		p.Entries[principalType] = values.sorted()
+	}
+	return p
+}
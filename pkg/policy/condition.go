@@ -0,0 +1,225 @@
+package policy
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// compareFunc compares a request context value with a condition value
+type compareFunc func(contextValue, conditionValue string) bool
+
+// conditionOperator describes a base condition operator
+type conditionOperator struct {
+	compare compareFunc
+	negated bool
+}
+
+// conditionOperators maps the supported base condition operators
+var conditionOperators = map[string]conditionOperator{
+	"StringEquals":              {compare: stringEquals},
+	"StringNotEquals":           {compare: stringEquals, negated: true},
+	"StringEqualsIgnoreCase":    {compare: strings.EqualFold},
+	"StringNotEqualsIgnoreCase": {compare: strings.EqualFold, negated: true},
+	"StringLike":                {compare: wildcardEquals},
+	"StringNotLike":             {compare: wildcardEquals, negated: true},
+	"NumericEquals":             {compare: numericCompare(func(a, b float64) bool { return a == b })},
+	"NumericNotEquals":          {compare: numericCompare(func(a, b float64) bool { return a == b }), negated: true},
+	"NumericLessThan":           {compare: numericCompare(func(a, b float64) bool { return a < b })},
+	"NumericLessThanEquals":     {compare: numericCompare(func(a, b float64) bool { return a <= b })},
+	"NumericGreaterThan":        {compare: numericCompare(func(a, b float64) bool { return a > b })},
+	"NumericGreaterThanEquals":  {compare: numericCompare(func(a, b float64) bool { return a >= b })},
+	"DateEquals":                {compare: dateCompare(func(a, b time.Time) bool { return a.Equal(b) })},
+	"DateNotEquals":             {compare: dateCompare(func(a, b time.Time) bool { return a.Equal(b) }), negated: true},
+	"DateLessThan":              {compare: dateCompare(func(a, b time.Time) bool { return a.Before(b) })},
+	"DateLessThanEquals":        {compare: dateCompare(func(a, b time.Time) bool { return !a.After(b) })},
+	"DateGreaterThan":           {compare: dateCompare(func(a, b time.Time) bool { return a.After(b) })},
+	"DateGreaterThanEquals":     {compare: dateCompare(func(a, b time.Time) bool { return !a.Before(b) })},
+	"Bool":                      {compare: strings.EqualFold},
+	"BinaryEquals":              {compare: stringEquals},
+	"IpAddress":                 {compare: ipAddressEquals},
+	"NotIpAddress":              {compare: ipAddressEquals, negated: true},
+	"ArnEquals":                 {compare: wildcardEquals},
+	"ArnLike":                   {compare: wildcardEquals},
+	"ArnNotEquals":              {compare: wildcardEquals, negated: true},
+	"ArnNotLike":                {compare: wildcardEquals, negated: true},
+}
+
+// conditionOutcome is the result of evaluating the conditions of a statement
+type conditionOutcome int
+
+const (
+	// conditionFailed means a test failed on a key of the request context
+	conditionFailed conditionOutcome = iota
+	// conditionUnresolved means only tests on keys missing from the request
+	// context failed, so the statement applies to some requests
+	conditionUnresolved
+	// conditionMatched means every test held
+	conditionMatched
+)
+
+// evaluateCondition evaluates every condition of a statement against the
+// request context. Keys missing from the context are added to unresolved.
+func evaluateCondition(condition Condition, context map[string][]string, unresolved map[string]bool) conditionOutcome {
+	outcome := conditionMatched
+	for operator, keys := range condition {
+		null := strings.HasPrefix(operator, "Null")
+		for key, values := range keys {
+			contextValues, present := lookupContext(context, key)
+			if !present && !null {
+				unresolved[key] = true
+			}
+			// Keep going after a failed test so every unresolved key is reported
+			if evaluateOperator(operator, values, contextValues, present) {
+				continue
+			}
+			if present || null {
+				outcome = conditionFailed
+			} else if outcome == conditionMatched {
+				outcome = conditionUnresolved
+			}
+		}
+	}
+	return outcome
+}
+
+// evaluateOperator evaluates a single operator on a single condition key
+func evaluateOperator(operator string, values StringList, contextValues []string, present bool) bool {
+	base := operator
+	setOperator := ""
+	if prefix, rest, ok := strings.Cut(base, ":"); ok {
+		setOperator = prefix
+		base = rest
+	}
+	ifExists := strings.HasSuffix(base, "IfExists")
+	base = strings.TrimSuffix(base, "IfExists")
+
+	if base == "Null" {
+		wantMissing := len(values) > 0 && strings.EqualFold(values[0], "true")
+		return wantMissing == !present
+	}
+
+	op, ok := conditionOperators[base]
+	if !ok {
+		// Unsupported operators never match
+		return false
+	}
+
+	if !present {
+		return ifExists || setOperator == "ForAllValues" || op.negated
+	}
+
+	matchesValue := func(contextValue string) bool {
+		for _, value := range values {
+			if op.compare(contextValue, value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch setOperator {
+	case "ForAllValues":
+		// Every context value must satisfy the operator
+		for _, contextValue := range contextValues {
+			if matchesValue(contextValue) == op.negated {
+				return false
+			}
+		}
+		return true
+	case "ForAnyValue":
+		// At least one context value must satisfy the operator
+		for _, contextValue := range contextValues {
+			if matchesValue(contextValue) != op.negated {
+				return true
+			}
+		}
+		return false
+	default:
+		// Positive operators need a match, negated operators need none
+		for _, contextValue := range contextValues {
+			if matchesValue(contextValue) {
+				return !op.negated
+			}
+		}
+		return op.negated
+	}
+}
+
+// lookupContext finds a condition key in the request context, ignoring case
+func lookupContext(context map[string][]string, key string) ([]string, bool) {
+	if values, ok := context[key]; ok {
+		return values, true
+	}
+	for contextKey, values := range context {
+		if strings.EqualFold(contextKey, key) {
+			return values, true
+		}
+	}
+	return nil, false
+}
+
+func stringEquals(a, b string) bool {
+	return a == b
+}
+
+func wildcardEquals(contextValue, pattern string) bool {
+	return MatchWildcard(pattern, contextValue, false)
+}
+
+func numericCompare(cmp func(a, b float64) bool) compareFunc {
+	return func(contextValue, conditionValue string) bool {
+		a, err := strconv.ParseFloat(contextValue, 64)
+		if err != nil {
+			return false
+		}
+		b, err := strconv.ParseFloat(conditionValue, 64)
+		if err != nil {
+			return false
+		}
+		return cmp(a, b)
+	}
+}
+
+func dateCompare(cmp func(a, b time.Time) bool) compareFunc {
+	return func(contextValue, conditionValue string) bool {
+		a, ok := parseDate(contextValue)
+		if !ok {
+			return false
+		}
+		b, ok := parseDate(conditionValue)
+		if !ok {
+			return false
+		}
+		return cmp(a, b)
+	}
+}
+
+// parseDate parses an ISO 8601 date or epoch seconds
+func parseDate(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), true
+	}
+	return time.Time{}, false
+}
+
+func ipAddressEquals(contextValue, conditionValue string) bool {
+	ip := net.ParseIP(contextValue)
+	if ip == nil {
+		return false
+	}
+	if !strings.Contains(conditionValue, "/") {
+		return ip.Equal(net.ParseIP(conditionValue))
+	}
+	_, network, err := net.ParseCIDR(conditionValue)
+	if err != nil {
+		return false
+	}
+	return network.Contains(ip)
+}
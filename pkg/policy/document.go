@@ -0,0 +1,191 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Document represents an IAM policy document
+type Document struct {
+	Version   string     `json:"Version,omitempty"`
+	Id        string     `json:"Id,omitempty"`
+	Statement Statements `json:"Statement"`
+}
+
+// Statement represents a single statement of a policy document
+type Statement struct {
+	Sid          string     `json:"Sid,omitempty"`
+	Effect       string     `json:"Effect"`
+	Principal    *Principal `json:"Principal,omitempty"`
+	NotPrincipal *Principal `json:"NotPrincipal,omitempty"`
+	Action       StringList `json:"Action,omitempty"`
+	NotAction    StringList `json:"NotAction,omitempty"`
+	Resource     StringList `json:"Resource,omitempty"`
+	NotResource  StringList `json:"NotResource,omitempty"`
+	Condition    Condition  `json:"Condition,omitempty"`
+}
+
+// Condition maps condition operators to their condition keys and values
+type Condition map[string]map[string]StringList
+
+const (
+	// EffectAllow is the Allow statement effect
+	EffectAllow = "Allow"
+
+	// EffectDeny is the Deny statement effect
+	EffectDeny = "Deny"
+)
+
+// Parse parses a policy document. URL-encoded documents, as returned by
+// the IAM API, are decoded first.
+func Parse(document string) (*Document, error) {
+	document = strings.TrimSpace(document)
+	if strings.HasPrefix(document, "%7B") || strings.HasPrefix(document, "%7b") {
+		decoded, err := url.PathUnescape(document)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode policy document: %w", err)
+		}
+		document = decoded
+	}
+
+	var doc Document
+	if err := json.Unmarshal([]byte(document), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse policy document: %w", err)
+	}
+
+	return &doc, nil
+}
+
+// JSON returns the document as indented JSON
+func (d *Document) JSON() (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(d); err != nil {
+		return "", fmt.Errorf("failed to encode policy document: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// Clone returns a deep copy of the document
+func (d *Document) Clone() *Document {
+	clone := &Document{
+		Version:   d.Version,
+		Id:        d.Id,
+		Statement: make(Statements, len(d.Statement)),
+	}
+
+	for i, stmt := range d.Statement {
+		clone.Statement[i] = stmt.Clone()
+	}
+
+	return clone
+}
+
+// Clone returns a deep copy of the statement
+func (s Statement) Clone() Statement {
+	clone := s
+	clone.Principal = s.Principal.Clone()
+	clone.NotPrincipal = s.NotPrincipal.Clone()
+	clone.Action = s.Action.Clone()
+	clone.NotAction = s.NotAction.Clone()
+	clone.Resource = s.Resource.Clone()
+	clone.NotResource = s.NotResource.Clone()
+
+	if s.Condition != nil {
+		clone.Condition = make(Condition, len(s.Condition))
+		for operator, keys := range s.Condition {
+			clonedKeys := make(map[string]StringList, len(keys))
+			for key, values := range keys {
+				clonedKeys[key] = values.Clone()
+			}
+			clone.Condition[operator] = clonedKeys
+		}
+	}
+
+	return clone
+}
+
+// Statements is the Statement element of a policy document, which may be
+// written as a single object or as an array
+type Statements []Statement
+
+// UnmarshalJSON accepts either a single statement or an array of statements
+func (s *Statements) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var stmt Statement
+		if err := json.Unmarshal(data, &stmt); err != nil {
+			return err
+		}
+		*s = Statements{stmt}
+		return nil
+	}
+
+	var stmts []Statement
+	if err := json.Unmarshal(data, &stmts); err != nil {
+		return err
+	}
+	if stmts == nil {
+		stmts = []Statement{}
+	}
+	*s = stmts
+	return nil
+}
+
+// MarshalJSON always writes the statements as an array
+func (s Statements) MarshalJSON() ([]byte, error) {
+	if s == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]Statement(s))
+}
+
+// ConditionEntry is a single condition key test of a statement
+type ConditionEntry struct {
+	Operator string
+	Key      string
+	Values   StringList
+}
+
+// ConditionEntries returns the condition tests on key, ordered by operator.
+// Condition keys are matched case-insensitively, as IAM does.
+func (s Statement) ConditionEntries(key string) []ConditionEntry {
+	var entries []ConditionEntry
+	for operator, keys := range s.Condition {
+		for conditionKey, values := range keys {
+			if strings.EqualFold(conditionKey, key) {
+				entries = append(entries, ConditionEntry{Operator: operator, Key: conditionKey, Values: values})
+			}
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Operator < entries[j].Operator
+	})
+	return entries
+}
+
+// IsWildcardMatch reports whether the condition operator matches values
+// with * and ? as wildcards. Negated operators such as StringNotLike match
+// everything else instead, so they are not wildcard matches.
+func (e ConditionEntry) IsWildcardMatch() bool {
+	return strings.Contains(e.Operator, "Like") && !e.IsNegated()
+}
+
+// IsNegated reports whether the condition operator matches every value
+// except the listed ones, such as StringNotEquals or ArnNotLike
+func (e ConditionEntry) IsNegated() bool {
+	operator := e.Operator
+	if _, base, ok := strings.Cut(operator, ":"); ok {
+		operator = base
+	}
+	return strings.Contains(operator, "Not")
+}
@@ -0,0 +1,260 @@
+package policy
+
+import (
+	"sort"
+	"strings"
+)
+
+// Decision is the outcome of evaluating a request
+type Decision string
+
+const (
+	// DecisionAllowed means an identity policy allows the request and no
+	// policy denies it
+	DecisionAllowed Decision = "Allowed"
+
+	// DecisionExplicitDeny means a statement explicitly denies the request
+	DecisionExplicitDeny Decision = "ExplicitDeny"
+
+	// DecisionImplicitDeny means no statement allows the request
+	DecisionImplicitDeny Decision = "ImplicitDeny"
+)
+
+// Source types of the policies taking part in an evaluation
+const (
+	SourceIdentity = "identity"
+	SourceBoundary = "boundary"
+)
+
+// Source is a named policy document taking part in an evaluation
+type Source struct {
+	Name     string
+	Document *Document
+}
+
+// PolicySet holds the policies that apply to a role
+type PolicySet struct {
+	Identity []Source
+	Boundary *Source
+}
+
+// Request is an authorization request to evaluate. An empty Resource
+// matches any resource, answering whether the action is allowed on at
+// least some resource, so only a Deny covering every resource denies it.
+type Request struct {
+	Action   string
+	Resource string
+	Context  map[string][]string
+}
+
+// Match is a statement that applies to a request
+type Match struct {
+	Source     string
+	SourceType string
+	Index      int
+	Sid        string `json:",omitempty"`
+	Effect     string
+	Statement  Statement
+}
+
+// Result is the outcome of evaluating a request against a policy set
+type Result struct {
+	Decision Decision
+	Matches  []Match
+	// Unresolved lists condition keys that were not in the request context
+	Unresolved []string `json:",omitempty"`
+	// DeniedByBoundary is set when an identity policy allows the request
+	// but the permissions boundary does not
+	DeniedByBoundary bool `json:",omitempty"`
+	// Conditional is set when the request is denied only because condition
+	// keys missing from the request context fail the statements that would
+	// allow it. ConditionalMatches lists those statements.
+	Conditional        bool    `json:",omitempty"`
+	ConditionalMatches []Match `json:",omitempty"`
+}
+
+// Allowed reports whether the request is allowed
+func (r Result) Allowed() bool {
+	return r.Decision == DecisionAllowed
+}
+
+// Evaluate evaluates a request against the identity policies and
+// permissions boundary of the set. An explicit deny in any policy wins;
+// otherwise an identity policy must allow the request and, if a
+// boundary is set, the boundary must allow it too.
+func (ps PolicySet) Evaluate(req Request) Result {
+	var result Result
+	unresolved := make(map[string]bool)
+
+	identityAllow := false
+	identityConditional := false
+	explicitDeny := false
+	for _, src := range ps.Identity {
+		matches, conditional := matchSource(src, SourceIdentity, req, unresolved)
+		for _, match := range matches {
+			if match.Effect == EffectDeny {
+				explicitDeny = true
+			} else {
+				identityAllow = true
+			}
+			result.Matches = append(result.Matches, match)
+		}
+		identityConditional = identityConditional || len(conditional) > 0
+		result.ConditionalMatches = append(result.ConditionalMatches, conditional...)
+	}
+
+	boundaryAllow := ps.Boundary == nil
+	boundaryConditional := false
+	if ps.Boundary != nil {
+		matches, conditional := matchSource(*ps.Boundary, SourceBoundary, req, unresolved)
+		for _, match := range matches {
+			if match.Effect == EffectDeny {
+				explicitDeny = true
+			} else {
+				boundaryAllow = true
+			}
+			result.Matches = append(result.Matches, match)
+		}
+		boundaryConditional = len(conditional) > 0
+		result.ConditionalMatches = append(result.ConditionalMatches, conditional...)
+	}
+
+	switch {
+	case explicitDeny:
+		result.Decision = DecisionExplicitDeny
+	case identityAllow && boundaryAllow:
+		result.Decision = DecisionAllowed
+	default:
+		result.Decision = DecisionImplicitDeny
+		result.DeniedByBoundary = identityAllow && !boundaryConditional
+		result.Conditional = (identityAllow || identityConditional) && (boundaryAllow || boundaryConditional)
+	}
+	if !result.Conditional {
+		result.ConditionalMatches = nil
+	}
+
+	for key := range unresolved {
+		result.Unresolved = append(result.Unresolved, key)
+	}
+	sort.Strings(result.Unresolved)
+
+	return result
+}
+
+// matchSource returns the statements of a policy that apply to the request,
+// and the Allow statements that apply only if condition keys missing from
+// the request context have suitable values
+func matchSource(src Source, sourceType string, req Request, unresolved map[string]bool) ([]Match, []Match) {
+	if src.Document == nil {
+		return nil, nil
+	}
+
+	var matches, conditional []Match
+	for i, stmt := range src.Document.Statement {
+		if !stmt.MatchesAction(req.Action) || !stmt.MatchesResource(req.Resource) {
+			continue
+		}
+		// A Deny scoped to some resources leaves the action allowed on others
+		if req.Resource == "" && stmt.Effect == EffectDeny && !stmt.coversEveryResource() {
+			continue
+		}
+
+		match := Match{
+			Source:     src.Name,
+			SourceType: sourceType,
+			Index:      i,
+			Sid:        stmt.Sid,
+			Effect:     stmt.Effect,
+			Statement:  stmt,
+		}
+		switch evaluateCondition(stmt.Condition, req.Context, unresolved) {
+		case conditionMatched:
+			matches = append(matches, match)
+		case conditionUnresolved:
+			if stmt.Effect == EffectAllow {
+				conditional = append(conditional, match)
+			}
+		}
+	}
+	return matches, conditional
+}
+
+// MatchesAction reports whether the Action or NotAction element covers action
+func (s Statement) MatchesAction(action string) bool {
+	if len(s.NotAction) > 0 {
+		return !matchesAny(s.NotAction, action, true)
+	}
+	return matchesAny(s.Action, action, true)
+}
+
+// MatchesResource reports whether the Resource or NotResource element covers
+// resource. An empty resource matches any statement that names a resource.
+func (s Statement) MatchesResource(resource string) bool {
+	if resource == "" {
+		if len(s.NotResource) > 0 {
+			return !s.NotResource.Contains("*")
+		}
+		return true
+	}
+
+	if len(s.NotResource) > 0 {
+		return !matchesAny(s.NotResource, resource, false)
+	}
+	if len(s.Resource) == 0 {
+		return true
+	}
+	return matchesAny(s.Resource, resource, false)
+}
+
+// coversEveryResource reports whether the statement applies to all
+// resources, through a Resource of *
+func (s Statement) coversEveryResource() bool {
+	if len(s.NotResource) > 0 {
+		return false
+	}
+	return len(s.Resource) == 0 || s.Resource.Contains("*")
+}
+
+// matchesAny reports whether value matches any of the wildcard patterns
+func matchesAny(patterns StringList, value string, ignoreCase bool) bool {
+	for _, pattern := range patterns {
+		if MatchWildcard(pattern, value, ignoreCase) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchWildcard matches value against a pattern where * matches any
+// sequence of characters and ? matches a single character
+func MatchWildcard(pattern, value string, ignoreCase bool) bool {
+	if ignoreCase {
+		pattern = strings.ToLower(pattern)
+		value = strings.ToLower(value)
+	}
+
+	p, v := 0, 0
+	starP, starV := -1, 0
+	for v < len(value) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == value[v]):
+			p++
+			v++
+		case p < len(pattern) && pattern[p] == '*':
+			starP = p
+			starV = v
+			p++
+		case starP >= 0:
+			p = starP + 1
+			starV++
+			v = starV
+		default:
+			return false
+		}
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
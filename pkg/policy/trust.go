@@ -0,0 +1,124 @@
+package policy
+
+import (
+	"regexp"
+	"strings"
+)
+
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// IsAccountID reports whether value is a 12-digit AWS account ID
+func IsAccountID(value string) bool {
+	return accountIDPattern.MatchString(value)
+}
+
+// MatchesPrincipal reports whether an AWS principal value is covered by
+// target. An account ID target matches the account itself and every
+// principal ARN in that account. An account root ARN target matches the
+// account in either form. Any other ARN target must match exactly.
+func MatchesPrincipal(principal, target string) bool {
+	if principal == target {
+		return true
+	}
+
+	if IsAccountID(target) {
+		return principal == target || AccountFromARN(principal) == target
+	}
+
+	if strings.HasSuffix(target, ":root") {
+		account := AccountFromARN(target)
+		return account != "" && principal == account
+	}
+
+	return false
+}
+
+// RemovePrincipal removes every AWS principal matching target from the
+// document and returns the removed values. Statements left without any
+// principal are dropped.
+func (d *Document) RemovePrincipal(target string) []string {
+	var removed []string
+	statements := make(Statements, 0, len(d.Statement))
+
+	for _, stmt := range d.Statement {
+		if stmt.Principal == nil || stmt.Principal.Wildcard {
+			statements = append(statements, stmt)
+			continue
+		}
+
+		values := stmt.Principal.Entries[PrincipalAWS]
+		if len(values) == 0 {
+			statements = append(statements, stmt)
+			continue
+		}
+
+		kept := make(StringList, 0, len(values))
+		for _, value := range values {
+			if MatchesPrincipal(value, target) {
+				removed = append(removed, value)
+			} else {
+				kept = append(kept, value)
+			}
+		}
+
+		if len(kept) == len(values) {
+			statements = append(statements, stmt)
+			continue
+		}
+
+		if len(kept) == 0 {
+			delete(stmt.Principal.Entries, PrincipalAWS)
+		} else {
+			stmt.Principal.Entries[PrincipalAWS] = kept
+		}
+
+		if !stmt.Principal.IsEmpty() {
+			statements = append(statements, stmt)
+		}
+	}
+
+	d.Statement = statements
+	return removed
+}
+
+// TrustsPrincipal reports whether an Allow statement names an AWS
+// principal covered by target
+func (d *Document) TrustsPrincipal(target string) bool {
+	for _, stmt := range d.Statement {
+		if stmt.Effect != EffectAllow || stmt.Principal == nil || stmt.Principal.Wildcard {
+			continue
+		}
+		for _, value := range stmt.Principal.Entries[PrincipalAWS] {
+			if MatchesPrincipal(value, target) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasPrincipals reports whether any Allow statement still names a principal
+func (d *Document) HasPrincipals() bool {
+	for _, stmt := range d.Statement {
+		if stmt.Effect == EffectAllow && !stmt.Principal.IsEmpty() {
+			return true
+		}
+	}
+	return false
+}
+
+// DenyAllTrustPolicy returns a trust policy that no principal can assume.
+// It stands in for trust policies left without principals, since IAM does
+// not accept a trust policy without statements.
+func DenyAllTrustPolicy() *Document {
+	return &Document{
+		Version: "2012-10-17",
+		Statement: Statements{
+			{
+				Effect:    EffectDeny,
+				Principal: &Principal{Entries: map[string]StringList{PrincipalAWS: {"*"}}},
+				Action:    StringList{"sts:*"},
+			},
+		},
+	}
+}
@@ -0,0 +1,211 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StringList is a policy element that may be written as a single value or
+// as an array of values. Numeric and boolean values are kept as strings.
+type StringList []string
+
+// UnmarshalJSON accepts a scalar value or an array of scalar values
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+
+		values := make(StringList, 0, len(raw))
+		for _, item := range raw {
+			value, err := scalarString(item)
+			if err != nil {
+				return err
+			}
+			values = append(values, value)
+		}
+		*l = values
+		return nil
+	}
+
+	value, err := scalarString(data)
+	if err != nil {
+		return err
+	}
+	*l = StringList{value}
+	return nil
+}
+
+// MarshalJSON writes a single value as a string and multiple values as an array
+func (l StringList) MarshalJSON() ([]byte, error) {
+	if len(l) == 1 {
+		return json.Marshal(l[0])
+	}
+	return json.Marshal([]string(l))
+}
+
+// Clone returns a copy of the list
+func (l StringList) Clone() StringList {
+	if l == nil {
+		return nil
+	}
+	return append(StringList{}, l...)
+}
+
+// Contains reports whether the list contains value
+func (l StringList) Contains(value string) bool {
+	for _, v := range l {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// scalarString converts a JSON string, number or boolean to a string
+func scalarString(data []byte) (string, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", err
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool, float64:
+		return string(bytes.TrimSpace(data)), nil
+	default:
+		return "", fmt.Errorf("unexpected policy value: %s", string(data))
+	}
+}
+
+// Principal types used in the Principal element
+const (
+	PrincipalAWS           = "AWS"
+	PrincipalService       = "Service"
+	PrincipalFederated     = "Federated"
+	PrincipalCanonicalUser = "CanonicalUser"
+)
+
+// Principal represents the Principal or NotPrincipal element of a statement.
+// The bare "*" form is kept apart from {"AWS": "*"} so that documents
+// round-trip unchanged.
+type Principal struct {
+	Wildcard bool
+	Entries  map[string]StringList
+}
+
+// UnmarshalJSON accepts either "*" or a map of principal types to values
+func (p *Principal) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		if value != "*" {
+			return fmt.Errorf("unexpected principal: %s", value)
+		}
+		*p = Principal{Wildcard: true}
+		return nil
+	}
+
+	var entries map[string]StringList
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	*p = Principal{Entries: entries}
+	return nil
+}
+
+// MarshalJSON writes the principal in the form it was read
+func (p Principal) MarshalJSON() ([]byte, error) {
+	if p.Wildcard {
+		return []byte(`"*"`), nil
+	}
+	if p.Entries == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(p.Entries)
+}
+
+// Clone returns a deep copy of the principal
+func (p *Principal) Clone() *Principal {
+	if p == nil {
+		return nil
+	}
+
+	clone := &Principal{Wildcard: p.Wildcard}
+	if p.Entries != nil {
+		clone.Entries = make(map[string]StringList, len(p.Entries))
+		for principalType, values := range p.Entries {
+			clone.Entries[principalType] = values.Clone()
+		}
+	}
+	return clone
+}
+
+// IsEmpty reports whether the principal names no one
+func (p *Principal) IsEmpty() bool {
+	if p == nil {
+		return true
+	}
+	if p.Wildcard {
+		return false
+	}
+	for _, values := range p.Entries {
+		if len(values) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Values returns the principals of the given type, treating a bare "*" as
+// matching every type
+func (p *Principal) Values(principalType string) []string {
+	if p == nil {
+		return nil
+	}
+	if p.Wildcard {
+		return []string{"*"}
+	}
+	return p.Entries[principalType]
+}
+
+// Types returns the principal types present, in sorted order
+func (p *Principal) Types() []string {
+	if p == nil {
+		return nil
+	}
+	if p.Wildcard {
+		return []string{PrincipalAWS}
+	}
+
+	types := make([]string, 0, len(p.Entries))
+	for principalType, values := range p.Entries {
+		if len(values) > 0 {
+			types = append(types, principalType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// AccountFromARN returns the account ID of an ARN, or an empty string if
+// the value is not an ARN
+func AccountFromARN(arn string) string {
+	if !strings.HasPrefix(arn, "arn:") {
+		return ""
+	}
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 {
+		return ""
+	}
+	return parts[4]
+}
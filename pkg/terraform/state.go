@@ -0,0 +1,185 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"hawkling/pkg/aws"
+	"hawkling/pkg/policy"
+)
+
+// stateVersion is the only Terraform state format read, used since
+// Terraform 0.12
+const stateVersion = 4
+
+// roleResourceType is the Terraform resource type of IAM roles
+const roleResourceType = "aws_iam_role"
+
+// ManagedRole is an IAM role declared in a Terraform state
+type ManagedRole struct {
+	// Address is the resource address, such as module.ci.aws_iam_role.deploy
+	Address string
+	Name    string
+	Arn     string `json:",omitempty"`
+
+	// StateFile is the state the role was found in
+	StateFile string
+}
+
+// state is the part of a v4 state file hawkling reads
+type state struct {
+	Version   int
+	Resources []struct {
+		Module    string
+		Mode      string
+		Type      string
+		Name      string
+		Instances []struct {
+			IndexKey   interface{} `json:"index_key"`
+			Attributes struct {
+				Name string
+				Arn  string
+			}
+		}
+	}
+}
+
+// LoadRoles reads the IAM roles of local Terraform state files. Each path
+// is a state file or a directory searched recursively for *.tfstate files.
+func LoadRoles(paths []string) ([]ManagedRole, error) {
+	var roles []ManagedRole
+	for _, path := range paths {
+		files, err := stateFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			found, err := loadStateRoles(file)
+			if err != nil {
+				return nil, err
+			}
+			roles = append(roles, found...)
+		}
+	}
+	return roles, nil
+}
+
+// stateFiles returns the path itself for a file, or the *.tfstate files
+// below a directory in lexical order
+func stateFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Terraform state %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".tfstate") {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s for Terraform state: %w", path, err)
+	}
+	return files, nil
+}
+
+// loadStateRoles reads the managed aws_iam_role resources of a state file
+func loadStateRoles(path string) ([]ManagedRole, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Terraform state %s: %w", path, err)
+	}
+
+	var parsed state
+	if err := json.Unmarshal(content, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse Terraform state %s: %w", path, err)
+	}
+	if parsed.Version != stateVersion {
+		return nil, fmt.Errorf("unsupported Terraform state version %d in %s, only version %d is supported", parsed.Version, path, stateVersion)
+	}
+
+	var roles []ManagedRole
+	for _, resource := range parsed.Resources {
+		if resource.Mode != "managed" || resource.Type != roleResourceType {
+			continue
+		}
+
+		address := resource.Type + "." + resource.Name
+		if resource.Module != "" {
+			address = resource.Module + "." + address
+		}
+		for _, instance := range resource.Instances {
+			roles = append(roles, ManagedRole{
+				Address:   address + indexSuffix(instance.IndexKey),
+				Name:      instance.Attributes.Name,
+				Arn:       instance.Attributes.Arn,
+				StateFile: path,
+			})
+		}
+	}
+	return roles, nil
+}
+
+// indexSuffix renders the index of a count or for_each instance
+func indexSuffix(key interface{}) string {
+	switch key := key.(type) {
+	case nil:
+		return ""
+	case string:
+		return fmt.Sprintf("[%q]", key)
+	case float64:
+		return fmt.Sprintf("[%d]", int(key))
+	default:
+		return fmt.Sprintf("[%v]", key)
+	}
+}
+
+// AttachAddresses sets TerraformAddress on every role declared in the
+// state, matched by ARN or else by name. It returns the state roles of the
+// same account that do not exist in it, which have drifted from the state.
+func AttachAddresses(roles []aws.Role, managed []ManagedRole) []ManagedRole {
+	byArn := make(map[string]*aws.Role, len(roles))
+	byName := make(map[string]*aws.Role, len(roles))
+	accounts := make(map[string]bool)
+	for i := range roles {
+		byArn[roles[i].Arn] = &roles[i]
+		byName[roles[i].Name] = &roles[i]
+		accounts[policy.AccountFromARN(roles[i].Arn)] = true
+	}
+
+	var drift []ManagedRole
+	for _, declared := range managed {
+		role := byArn[declared.Arn]
+		if role == nil && declared.Arn == "" {
+			role = byName[declared.Name]
+		}
+		if role != nil {
+			role.TerraformAddress = declared.Address
+			continue
+		}
+
+		// State of other accounts is not drift of this one
+		if declared.Arn != "" && len(roles) > 0 && !accounts[policy.AccountFromARN(declared.Arn)] {
+			continue
+		}
+		drift = append(drift, declared)
+	}
+
+	sort.SliceStable(drift, func(i, j int) bool {
+		return drift[i].Address < drift[j].Address
+	})
+	return drift
+}
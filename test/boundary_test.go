@@ -0,0 +1,103 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+)
+
+const (
+	workloadBoundary = "arn:aws:iam::123456789012:policy/WorkloadBoundary"
+	batchBoundary    = "arn:aws:iam::123456789012:policy/BatchBoundary"
+)
+
+func boundaryRoles() []aws.Role {
+	return []aws.Role{
+		{Name: "Compliant", Path: "/workload/", PermissionsBoundary: workloadBoundary},
+		{Name: "Missing", Path: "/workload/api/"},
+		{Name: "Wrong", Path: "/workload/", PermissionsBoundary: batchBoundary},
+		{Name: "Batch", Path: "/workload/batch/", PermissionsBoundary: batchBoundary},
+		{Name: "Unmanaged", Path: "/"},
+	}
+}
+
+func TestCheckBoundaryCompliance(t *testing.T) {
+	requirements := []audit.BoundaryRequirement{
+		{PathPrefix: "/workload/", Boundary: workloadBoundary},
+		{PathPrefix: "/workload/batch/", Boundary: batchBoundary},
+	}
+
+	violations := audit.CheckBoundaryCompliance(boundaryRoles(), requirements)
+	if len(violations) != 2 {
+		t.Fatalf("expected 2 violations, got %+v", violations)
+	}
+	if violations[0].RoleName != "Missing" || violations[0].Status != audit.BoundaryMissing {
+		t.Errorf("expected Missing to be missing its boundary, got %+v", violations[0])
+	}
+	if violations[1].RoleName != "Wrong" || violations[1].Status != audit.BoundaryWrong || violations[1].Current != batchBoundary {
+		t.Errorf("expected Wrong to use the wrong boundary, got %+v", violations[1])
+	}
+}
+
+func TestBoundaryApplyAndRestore(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.Roles = boundaryRoles()
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	config := `{"RequiredBoundaries": [{"PathPrefix": "/workload/", "Boundary": "` + workloadBoundary + `"}]}`
+	if err := os.WriteFile(configPath, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	backupDir := filepath.Join(dir, "backups")
+
+	originalStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	defer func() { os.Stdout = originalStdout }()
+
+	options := commands.BoundaryOptions{Config: configPath, DryRun: true, BackupDir: backupDir}
+	if err := commands.NewBoundaryApplyCommand("", "", options).Execute(context.Background()); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if len(mockClient.Boundaries) != 0 {
+		t.Fatalf("dry run changed boundaries: %v", mockClient.Boundaries)
+	}
+
+	options.DryRun = false
+	options.Force = true
+	if err := commands.NewBoundaryApplyCommand("", "", options).Execute(context.Background()); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+
+	for _, name := range []string{"Missing", "Wrong", "Batch"} {
+		if mockClient.Boundaries[name] != workloadBoundary {
+			t.Errorf("expected %s to get the workload boundary, got %q", name, mockClient.Boundaries[name])
+		}
+	}
+	if _, ok := mockClient.Boundaries["Compliant"]; ok {
+		t.Errorf("compliant role should not be updated")
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(backupDir, "permissions-boundary", "*.json"))
+	if len(backups) != 3 {
+		t.Fatalf("expected 3 backups, got %v", backups)
+	}
+
+	restore := commands.NewBoundaryRestoreCommand("", "", backups, commands.DeleteOptions{Force: true})
+	if err := restore.Execute(context.Background()); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	w.Close()
+
+	if mockClient.Boundaries["Missing"] != "" || mockClient.Boundaries["Wrong"] != batchBoundary {
+		t.Errorf("expected previous boundaries to be restored, got %v", mockClient.Boundaries)
+	}
+}
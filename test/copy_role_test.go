@@ -0,0 +1,235 @@
+package test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/policy"
+)
+
+const (
+	copySourceAccount = "111111111111"
+	copyTargetAccount = "222222222222"
+	copyToolsAccount  = "333333333333"
+)
+
+func copiedRole() aws.Role {
+	return aws.Role{
+		Name:                "deploy",
+		Arn:                 "arn:aws:iam::111111111111:role/ci/deploy",
+		Path:                "/ci/",
+		Description:         "Deploys the app",
+		MaxSessionDuration:  3600,
+		PermissionsBoundary: "arn:aws:iam::111111111111:policy/Boundary",
+		TrustPolicy:         `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::111111111111:root","arn:aws:iam::333333333333:role/runner"]},"Action":"sts:AssumeRole"}]}`,
+		InlinePolicies: []aws.Policy{{Name: "queue", IsInline: true,
+			Document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"sqs:SendMessage","Resource":"arn:aws:sqs:us-east-1:111111111111:jobs"}]}`}},
+		AttachedPolicies: []aws.Policy{
+			{Name: "ReadOnlyAccess", Arn: "arn:aws:iam::aws:policy/ReadOnlyAccess"},
+			{Name: "Deploy", Arn: "arn:aws:iam::111111111111:policy/team/Deploy"},
+			{Name: "Shared", Arn: "arn:aws:iam::111111111111:policy/Shared"},
+			{Name: "Foreign", Arn: "arn:aws:iam::444444444444:policy/Foreign"},
+		},
+		Tags:             map[string]string{"team": "platform", "aws:cloudformation:stack-name": "ci"},
+		InstanceProfiles: []aws.InstanceProfile{{Name: "deploy", Path: "/ci/"}},
+	}
+}
+
+func copyInventory() *aws.Inventory {
+	return &aws.Inventory{
+		Roles: []aws.Role{copiedRole()},
+		Policies: map[string]aws.Policy{
+			"arn:aws:iam::111111111111:policy/Boundary": {Name: "Boundary", Arn: "arn:aws:iam::111111111111:policy/Boundary",
+				Document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`},
+			"arn:aws:iam::111111111111:policy/team/Deploy": {Name: "Deploy", Arn: "arn:aws:iam::111111111111:policy/team/Deploy",
+				Document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:PutObject","Resource":"arn:aws:s3:::artifacts-111111111111/*"}]}`},
+			"arn:aws:iam::111111111111:policy/Shared": {Name: "Shared", Arn: "arn:aws:iam::111111111111:policy/Shared",
+				Document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"logs:*","Resource":"*"}]}`},
+		},
+	}
+}
+
+func TestRewriteAccounts(t *testing.T) {
+	rewritten, unmapped := policy.RewriteAccounts(
+		`arn:aws:iam::111111111111:root arn:aws:iam::333333333333:role/x arn:aws:s3:::bucket-1111111111112`,
+		map[string]string{copySourceAccount: copyTargetAccount})
+
+	if !strings.Contains(rewritten, "arn:aws:iam::222222222222:root") || !strings.Contains(rewritten, "bucket-1111111111112") {
+		t.Errorf("expected only whole account IDs to be rewritten, got %s", rewritten)
+	}
+	if len(unmapped) != 1 || unmapped[0] != copyToolsAccount {
+		t.Errorf("expected %s to be reported unmapped, got %v", copyToolsAccount, unmapped)
+	}
+}
+
+func TestPlanRoleCopy(t *testing.T) {
+	plan := audit.PlanRoleCopy(copyInventory(), copiedRole(), map[string]string{copySourceAccount: copyTargetAccount})
+
+	if plan.Role.Arn != "arn:aws:iam::222222222222:role/ci/deploy" {
+		t.Errorf("expected the role ARN to be in the destination account, got %s", plan.Role.Arn)
+	}
+	if !strings.Contains(plan.Role.TrustPolicy, "arn:aws:iam::222222222222:root") || strings.Contains(plan.Role.TrustPolicy, copySourceAccount) {
+		t.Errorf("expected the trust policy to be rewritten, got %s", plan.Role.TrustPolicy)
+	}
+	if !strings.Contains(plan.Role.InlinePolicies[0].Document, "222222222222:jobs") {
+		t.Errorf("expected the inline policy to be rewritten, got %s", plan.Role.InlinePolicies[0].Document)
+	}
+	if plan.Role.PermissionsBoundary != "arn:aws:iam::222222222222:policy/Boundary" {
+		t.Errorf("expected the boundary to be rewritten, got %s", plan.Role.PermissionsBoundary)
+	}
+	if _, ok := plan.Role.Tags["aws:cloudformation:stack-name"]; ok || plan.Role.Tags["team"] != "platform" {
+		t.Errorf("expected only user tags to be copied, got %v", plan.Role.Tags)
+	}
+
+	var attached []string
+	for _, p := range plan.Role.AttachedPolicies {
+		attached = append(attached, p.Arn)
+	}
+	expected := "arn:aws:iam::aws:policy/ReadOnlyAccess arn:aws:iam::222222222222:policy/team/Deploy arn:aws:iam::222222222222:policy/Shared"
+	if strings.Join(attached, " ") != expected {
+		t.Errorf("expected attached policies %s, got %v", expected, attached)
+	}
+
+	if len(plan.Policies) != 3 {
+		t.Fatalf("expected three customer managed policies to copy, got %+v", plan.Policies)
+	}
+	deploy := plan.Policies[0]
+	if deploy.Name != "Deploy" || deploy.Path != "/team/" || !strings.Contains(deploy.Document, "artifacts-222222222222") {
+		t.Errorf("expected the Deploy policy with its path and rewritten document, got %+v", deploy)
+	}
+
+	unmapped := strings.Join(plan.Unmapped, "\n")
+	for _, item := range []string{"account 333333333333 in the trust policy", "policy arn:aws:iam::444444444444:policy/Foreign", "tag aws:cloudformation:stack-name"} {
+		if !strings.Contains(unmapped, item) {
+			t.Errorf("expected unmapped items to mention %q, got:\n%s", item, unmapped)
+		}
+	}
+}
+
+// runCopyRole copies the deploy role of the copy inventory into client and
+// returns the output
+func runCopyRole(t *testing.T, client *MockIAMClient, options commands.CopyRoleOptions) (string, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	if err := aws.SaveInventory(path, copyInventory()); err != nil {
+		t.Fatal(err)
+	}
+	options.Inventory = path
+	options.ToProfile = "target"
+
+	aws.SetTestClient(client)
+	defer aws.ClearTestClient()
+
+	originalStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := commands.NewCopyRoleCommand("", "", "deploy", options).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+	out, _ := io.ReadAll(r)
+	return string(out), err
+}
+
+func TestCopyRoleCommand(t *testing.T) {
+	client := NewMockIAMClient()
+	client.AccountID = copyTargetAccount
+	// Shared already exists with the same permissions, Deploy differs
+	client.ManagedPolicies["arn:aws:iam::222222222222:policy/Shared"] = aws.Policy{Name: "Shared",
+		Document: `{"Statement":[{"Resource":"*","Action":["logs:*"],"Effect":"Allow"}],"Version":"2012-10-17"}`}
+	client.ManagedPolicies["arn:aws:iam::222222222222:policy/team/Deploy"] = aws.Policy{Name: "Deploy",
+		Document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`}
+
+	out, err := runCopyRole(t, client, commands.CopyRoleOptions{Force: true})
+	if err != nil {
+		t.Fatalf("Copy failed: %v\n%s", err, out)
+	}
+
+	created := findMockRole(client, "deploy")
+	if created == nil || created.Arn != "arn:aws:iam::222222222222:role/ci/deploy" {
+		t.Fatalf("expected the role to be created in the destination, got %+v", created)
+	}
+	if created.PermissionsBoundary != "arn:aws:iam::222222222222:policy/Boundary" || strings.Contains(created.TrustPolicy, copySourceAccount) {
+		t.Errorf("expected the rewritten boundary and trust policy, got %+v", created)
+	}
+	if _, ok := client.ManagedPolicies["arn:aws:iam::222222222222:policy/Boundary"]; !ok {
+		t.Error("expected the missing boundary policy to be created")
+	}
+	if !strings.Contains(client.ManagedPolicies["arn:aws:iam::222222222222:policy/team/Deploy"].Document, `"s3:*"`) {
+		t.Error("expected the differing Deploy policy to be left alone")
+	}
+
+	attached := strings.Join(client.AttachedPolicies["deploy"], " ")
+	if attached != "arn:aws:iam::aws:policy/ReadOnlyAccess arn:aws:iam::222222222222:policy/Shared" {
+		t.Errorf("expected the AWS managed and reused policies to be attached, got %s", attached)
+	}
+	if !strings.Contains(client.InlinePolicies["deploy"]["queue"], "222222222222:jobs") {
+		t.Errorf("expected the rewritten inline policy, got %v", client.InlinePolicies["deploy"])
+	}
+	if roles := client.InstanceProfiles["deploy"]; len(roles) != 1 || roles[0] != "deploy" {
+		t.Errorf("expected the instance profile to be recreated with the role, got %v", client.InstanceProfiles)
+	}
+
+	for _, expected := range []string{"Reuse policy arn:aws:iam::222222222222:policy/Shared", "Could not map 4 items",
+		"policy arn:aws:iam::222222222222:policy/team/Deploy exists in the destination with a different document"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+}
+
+func TestCopyRoleDryRun(t *testing.T) {
+	client := NewMockIAMClient()
+	client.AccountID = copyTargetAccount
+
+	out, err := runCopyRole(t, client, commands.CopyRoleOptions{
+		DryRun:     true,
+		AccountMap: []string{copyToolsAccount + "=555555555555"},
+	})
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if findMockRole(client, "deploy") != nil || len(client.ManagedPolicies) != 0 {
+		t.Error("expected a dry run to create nothing")
+	}
+	for _, expected := range []string{"arn:aws:iam::555555555555:role/runner", "Create policy arn:aws:iam::222222222222:policy/team/Deploy", "DRY RUN"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "account 333333333333") {
+		t.Errorf("expected the mapped account not to be reported, got:\n%s", out)
+	}
+}
+
+func TestCopyRoleRejectsInvalidInput(t *testing.T) {
+	client := NewMockIAMClient()
+	client.AccountID = copyTargetAccount
+
+	if _, err := runCopyRole(t, client, commands.CopyRoleOptions{DryRun: true, AccountMap: []string{"111111111111"}}); err == nil {
+		t.Error("expected an invalid account mapping to be rejected")
+	}
+
+	// A boundary that differs in the destination must not be dropped
+	client.ManagedPolicies["arn:aws:iam::222222222222:policy/Boundary"] = aws.Policy{Name: "Boundary",
+		Document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`}
+	if _, err := runCopyRole(t, client, commands.CopyRoleOptions{Force: true}); err == nil || findMockRole(client, "deploy") != nil {
+		t.Errorf("expected a differing boundary to stop the copy, got %v", err)
+	}
+}
+
+// findMockRole returns the role of the mock with the given name, or nil
+func findMockRole(client *MockIAMClient, name string) *aws.Role {
+	for i := range client.Roles {
+		if client.Roles[i].Name == name {
+			return &client.Roles[i]
+		}
+	}
+	return nil
+}
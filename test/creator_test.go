@@ -0,0 +1,219 @@
+package test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/aws"
+)
+
+// createRoleRecord builds a CloudTrail record of a CreateRole call
+func createRoleRecord(roleName, principal, userAgent, invokedBy string, eventTime time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"eventTime":   eventTime.UTC().Format(time.RFC3339),
+		"eventID":     "event-" + roleName,
+		"eventSource": "iam.amazonaws.com",
+		"eventName":   "CreateRole",
+		"userAgent":   userAgent,
+		"userIdentity": map[string]interface{}{
+			"type":      "AssumedRole",
+			"arn":       principal,
+			"invokedBy": invokedBy,
+		},
+		"requestParameters": map[string]interface{}{"roleName": roleName},
+		"responseElements": map[string]interface{}{
+			"role": map[string]interface{}{"arn": "arn:aws:iam::123456789012:role/" + roleName},
+		},
+	}
+}
+
+func TestParseCreateRoleEvent(t *testing.T) {
+	record, _ := json.Marshal(createRoleRecord("app", "arn:aws:sts::123456789012:assumed-role/Admin/alice", "aws-cli/2.15.0", "", time.Now()))
+	event, ok, err := aws.ParseCreateRoleEvent(record)
+	if err != nil || !ok {
+		t.Fatalf("expected a CreateRole event, got %v, %v", ok, err)
+	}
+	if event.RoleArn != "arn:aws:iam::123456789012:role/app" || event.Principal != "arn:aws:sts::123456789012:assumed-role/Admin/alice" {
+		t.Errorf("expected the role and principal of the event, got %+v", event)
+	}
+
+	for _, skipped := range []string{
+		`{"eventSource":"iam.amazonaws.com","eventName":"CreateRole","errorCode":"EntityAlreadyExists"}`,
+		`{"eventSource":"s3.amazonaws.com","eventName":"PutObject","requestParameters":{"roleName":7}}`,
+	} {
+		if _, ok, err := aws.ParseCreateRoleEvent([]byte(skipped)); ok || err != nil {
+			t.Errorf("expected %s to be skipped, got %v, %v", skipped, ok, err)
+		}
+	}
+}
+
+func TestCreateRoleEventSource(t *testing.T) {
+	tests := []struct {
+		event    aws.CreateRoleEvent
+		expected string
+	}{
+		{aws.CreateRoleEvent{UserAgent: "AWS Internal", FromConsole: true}, aws.CreatorSourceConsole},
+		{aws.CreateRoleEvent{UserAgent: "console.amazonaws.com"}, aws.CreatorSourceConsole},
+		{aws.CreateRoleEvent{UserAgent: "cloudformation.amazonaws.com", InvokedBy: "cloudformation.amazonaws.com"}, aws.CreatorSourceCloudFormation},
+		{aws.CreateRoleEvent{UserAgent: "APN/1.0 HashiCorp/1.0 Terraform/1.9.5 (+https://www.terraform.io) terraform-provider-aws/5.70.0 aws-sdk-go-v2/1.32.2"}, aws.CreatorSourceTerraform},
+		{aws.CreateRoleEvent{UserAgent: "aws-cli/2.15.0 Python/3.11.6 Linux/6.1 botocore/2.4.5"}, aws.CreatorSourceCLI},
+		{aws.CreateRoleEvent{UserAgent: "Boto3/1.34.0 md/Botocore#1.34.0 Python/3.12"}, aws.CreatorSourceSDK},
+		{aws.CreateRoleEvent{UserAgent: "lambda.amazonaws.com", InvokedBy: "lambda.amazonaws.com"}, aws.CreatorSourceService},
+		{aws.CreateRoleEvent{UserAgent: "custom-tool/1.0"}, aws.CreatorSourceOther},
+	}
+	for _, tt := range tests {
+		if source := tt.event.Source(); source != tt.expected {
+			t.Errorf("expected %q to be classified as %s, got %s", tt.event.UserAgent, tt.expected, source)
+		}
+	}
+}
+
+func TestAttachCreators(t *testing.T) {
+	now := time.Now()
+	since := now.AddDate(0, 0, -aws.EventHistoryDays)
+	roles := []aws.Role{
+		{Name: "recreated", Arn: "arn:aws:iam::123456789012:role/recreated", CreateDate: now.AddDate(0, 0, -10)},
+		{Name: "old", Arn: "arn:aws:iam::123456789012:role/old", CreateDate: now.AddDate(-1, 0, 0)},
+		{Name: "recent", Arn: "arn:aws:iam::123456789012:role/recent", CreateDate: now.AddDate(0, 0, -5)},
+		{Name: "missed", Arn: "arn:aws:iam::123456789012:role/missed", CreateDate: now.AddDate(0, 0, -3)},
+	}
+	events := []aws.CreateRoleEvent{
+		{RoleName: "recreated", RoleArn: "arn:aws:iam::123456789012:role/recreated", Principal: "first", EventTime: now.AddDate(0, 0, -30)},
+		{RoleName: "recreated", RoleArn: "arn:aws:iam::123456789012:role/recreated", Principal: "second", EventTime: now.AddDate(0, 0, -10),
+			UserAgent: "console.amazonaws.com"},
+		// A role of the same name in another account is not a match
+		{RoleName: "recent", RoleArn: "arn:aws:iam::999999999999:role/recent", Principal: "other", EventTime: now.AddDate(0, 0, -5)},
+		// The event of the current role was not read, only that of an earlier one
+		{RoleName: "missed", RoleArn: "arn:aws:iam::123456789012:role/missed", Principal: "earlier", EventTime: now.AddDate(0, 0, -40)},
+	}
+
+	aws.AttachCreators(roles, events, since)
+
+	if creator := roles[0].Creator; creator == nil || creator.Principal != "second" || creator.Source != aws.CreatorSourceConsole {
+		t.Errorf("expected the event that created the current role, got %+v", creator)
+	}
+	if creator := roles[1].Creator; creator == nil || !strings.Contains(creator.NotFound, "created before the CloudTrail event history") {
+		t.Errorf("expected a role older than the event history to say so, got %+v", creator)
+	}
+	if creator := roles[2].Creator; creator == nil || creator.NotFound != "no CreateRole event found" {
+		t.Errorf("expected a role without an event to say so, got %+v", creator)
+	}
+	if creator := roles[3].Creator; creator == nil || !strings.Contains(creator.NotFound, "no CreateRole event within 5m0s of its creation date") {
+		t.Errorf("expected the event of an earlier role of the same name not to match, got %+v", creator)
+	}
+}
+
+func TestLoadCreateRoleEvents(t *testing.T) {
+	dir := t.TempDir()
+	day := filepath.Join(dir, "AWSLogs", "123456789012", "CloudTrail", "us-east-1", "2026", "10", "01")
+	if err := os.MkdirAll(day, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	eventTime := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	compressed, _ := json.Marshal(map[string]interface{}{"Records": []interface{}{
+		createRoleRecord("deploy", "arn:aws:sts::123456789012:assumed-role/ci/run", "Terraform/1.9.5 terraform-provider-aws/5.70.0", "", eventTime),
+		map[string]interface{}{"eventSource": "s3.amazonaws.com", "eventName": "GetObject"},
+	}})
+	file, err := os.Create(filepath.Join(day, "trail.json.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(file)
+	gz.Write(compressed)
+	gz.Close()
+	file.Close()
+
+	plain, _ := json.Marshal(map[string]interface{}{"Records": []interface{}{
+		createRoleRecord("stack-role", "arn:aws:sts::123456789012:assumed-role/Admin/bob", "cloudformation.amazonaws.com", "cloudformation.amazonaws.com", eventTime),
+	}})
+	if err := os.WriteFile(filepath.Join(day, "trail.json"), plain, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := aws.LoadCreateRoleEvents(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected the CreateRole events of both files, got %+v", events)
+	}
+	sources := map[string]string{}
+	for _, event := range events {
+		sources[event.RoleName] = event.Source()
+	}
+	if sources["deploy"] != aws.CreatorSourceTerraform || sources["stack-role"] != aws.CreatorSourceCloudFormation {
+		t.Errorf("expected Terraform and CloudFormation sources, got %v", sources)
+	}
+}
+
+func TestListCommandWithCreator(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	now := time.Now()
+	mockClient.Roles = append(mockClient.Roles,
+		aws.Role{Name: "NewRole", Arn: "arn:aws:iam::123456789012:role/NewRole", CreateDate: now.AddDate(0, 0, -10)},
+		aws.Role{Name: "UntrackedRole", Arn: "arn:aws:iam::123456789012:role/UntrackedRole", CreateDate: now.AddDate(0, 0, -20)},
+	)
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	trail := &MockTrailClient{Events: []aws.CreateRoleEvent{{
+		RoleName:  "NewRole",
+		RoleArn:   "arn:aws:iam::123456789012:role/NewRole",
+		Principal: "arn:aws:sts::123456789012:assumed-role/Admin/alice@example.com",
+		UserAgent: "console.amazonaws.com",
+		EventTime: now.AddDate(0, 0, -10),
+	}}}
+	aws.SetTestTrailClient(trail)
+	defer aws.ClearTestTrailClient()
+
+	originalStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := commands.NewListCommand("", "", commands.ListOptions{
+		Output:  "json",
+		Creator: commands.CreatorOptions{WithCreator: true},
+	}).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+	out, _ := io.ReadAll(r)
+
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if days := int(now.Sub(trail.Since).Hours()/24 + 0.5); days != aws.EventHistoryDays {
+		t.Errorf("expected the last %d days to be looked up, got %d", aws.EventHistoryDays, days)
+	}
+
+	var roles []aws.Role
+	if err := json.Unmarshal(out, &roles); err != nil {
+		t.Fatalf("expected JSON output, got %v:\n%s", err, out)
+	}
+	for _, role := range roles {
+		switch {
+		case role.Creator == nil:
+			t.Errorf("expected every role to have a creator entry, %s has none", role.Name)
+		case role.Name == "NewRole" && (role.Creator.Source != aws.CreatorSourceConsole || !strings.Contains(role.Creator.Principal, "alice")):
+			t.Errorf("expected NewRole to be created by alice in the console, got %+v", role.Creator)
+		case role.Name != "NewRole" && role.Creator.NotFound == "":
+			t.Errorf("expected %s to say no creator was found, got %+v", role.Name, role.Creator)
+		}
+	}
+
+	trail.ErrorMode = true
+	err = commands.NewListCommand("", "", commands.ListOptions{
+		Output:  "json",
+		Creator: commands.CreatorOptions{WithCreator: true},
+	}).Execute(context.Background())
+	if err == nil {
+		t.Error("expected a failed lookup to be reported")
+	}
+}
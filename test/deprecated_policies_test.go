@@ -0,0 +1,128 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/catalog"
+)
+
+const (
+	oldLambdaPolicy = "arn:aws:iam::aws:policy/AWSLambdaFullAccess"
+	newLambdaPolicy = "arn:aws:iam::aws:policy/AWSLambda_FullAccess"
+	emrEC2Policy    = "arn:aws:iam::aws:policy/service-role/AmazonElasticMapReduceforEC2Role"
+)
+
+func TestCatalogLoad(t *testing.T) {
+	builtin, err := catalog.Builtin()
+	if err != nil {
+		t.Fatalf("Builtin() error = %v", err)
+	}
+	if entry, ok := builtin.Lookup(oldLambdaPolicy); !ok || entry.Replacement != newLambdaPolicy {
+		t.Errorf("expected the built-in catalog to replace %s with %s, got %+v", oldLambdaPolicy, newLambdaPolicy, entry)
+	}
+
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	local := `{"Updated": "2099-01-01", "Policies": [
+		{"Arn": "arn:aws:iam::aws:policy/CustomOld", "Replacement": "arn:aws:iam::aws:policy/CustomNew"},
+		{"Arn": "` + oldLambdaPolicy + `", "Replacement": "arn:aws:iam::aws:policy/Other"}]}`
+	if err := os.WriteFile(path, []byte(local), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := catalog.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, ok := merged.Lookup("arn:aws:iam::aws:policy/CustomOld"); !ok {
+		t.Errorf("expected local entries to be added")
+	}
+	if entry, _ := merged.Lookup(oldLambdaPolicy); entry.Replacement != "arn:aws:iam::aws:policy/Other" {
+		t.Errorf("expected local entries to override built-in ones, got %+v", entry)
+	}
+	if len(merged.Policies) != len(builtin.Policies)+1 || merged.Updated != "2099-01-01" {
+		t.Errorf("unexpected merged catalog: %d entries, updated %s", len(merged.Policies), merged.Updated)
+	}
+}
+
+func deprecatedRoles() []aws.Role {
+	return []aws.Role{
+		{Name: "LambdaDeployer", AttachedPolicies: []aws.Policy{{Name: "AWSLambdaFullAccess", Arn: oldLambdaPolicy}}},
+		{Name: "EMRInstance", AttachedPolicies: []aws.Policy{{Name: "AmazonElasticMapReduceforEC2Role", Arn: emrEC2Policy}}},
+		{Name: "Modern", AttachedPolicies: []aws.Policy{{Name: "AWSLambda_FullAccess", Arn: newLambdaPolicy}}},
+	}
+}
+
+func TestFindDeprecatedPolicies(t *testing.T) {
+	builtin, _ := catalog.Builtin()
+
+	attachments := audit.FindDeprecatedPolicies(deprecatedRoles(), builtin, 90)
+	if len(attachments) != 2 {
+		t.Fatalf("expected 2 deprecated attachments, got %+v", attachments)
+	}
+	if attachments[0].RoleName != "EMRInstance" || attachments[0].Replacement != "" || !attachments[0].Unused {
+		t.Errorf("expected an unused EMRInstance attachment without replacement, got %+v", attachments[0])
+	}
+	if attachments[1].RoleName != "LambdaDeployer" || attachments[1].Replacement != newLambdaPolicy {
+		t.Errorf("expected LambdaDeployer to be replaced with %s, got %+v", newLambdaPolicy, attachments[1])
+	}
+}
+
+func TestDeprecatedPoliciesReplace(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.Roles = deprecatedRoles()
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	originalStdout, originalStderr := os.Stdout, os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stdout, os.Stderr = w, w
+	backupDir := t.TempDir()
+	err := commands.NewDeprecatedPoliciesCommand("", "", commands.DeprecatedPoliciesOptions{
+		Replace:   true,
+		Force:     true,
+		BackupDir: backupDir,
+	}).Execute(context.Background())
+	w.Close()
+	os.Stdout, os.Stderr = originalStdout, originalStderr
+
+	if err != nil {
+		t.Fatalf("Command execution failed: %v", err)
+	}
+
+	if attached := mockClient.AttachedPolicies["LambdaDeployer"]; len(attached) != 1 || attached[0] != newLambdaPolicy {
+		t.Errorf("expected %s to be attached, got %v", newLambdaPolicy, attached)
+	}
+	if detached := mockClient.DetachedPolicies["LambdaDeployer"]; len(detached) != 1 || detached[0] != oldLambdaPolicy {
+		t.Errorf("expected %s to be detached, got %v", oldLambdaPolicy, detached)
+	}
+	if _, ok := mockClient.DetachedPolicies["EMRInstance"]; ok {
+		t.Errorf("policies without a replacement should not be detached")
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(backupDir, "deprecated-policy", "*.json"))
+	if len(backups) != 1 {
+		t.Fatalf("expected 1 backup, got %d", len(backups))
+	}
+
+	// Restoring attaches the deprecated policy again and detaches the successor
+	_, w, _ = os.Pipe()
+	os.Stdout = w
+	err = commands.NewDeprecatedRestoreCommand("", "", backups, commands.DeleteOptions{Force: true}).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if attached := mockClient.AttachedPolicies["LambdaDeployer"]; len(attached) != 2 || attached[1] != oldLambdaPolicy {
+		t.Errorf("expected %s to be attached again, got %v", oldLambdaPolicy, attached)
+	}
+	if detached := mockClient.DetachedPolicies["LambdaDeployer"]; len(detached) != 2 || detached[1] != newLambdaPolicy {
+		t.Errorf("expected %s to be detached, got %v", newLambdaPolicy, detached)
+	}
+}
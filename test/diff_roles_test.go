@@ -0,0 +1,146 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+)
+
+func diffRolePair() (aws.Role, aws.Role) {
+	staging := aws.Role{
+		Name:               "App",
+		Arn:                "arn:aws:iam::111111111111:role/App",
+		TrustPolicy:        lambdaTrust,
+		MaxSessionDuration: 3600,
+		AttachedPolicies:   []aws.Policy{{Arn: "arn:aws:iam::aws:policy/ReadOnlyAccess"}},
+		InlinePolicies:     []aws.Policy{{Name: "read", IsInline: true, Document: bucketRead}},
+		Tags:               map[string]string{"env": "staging", "team": "data"},
+	}
+	production := aws.Role{
+		Name:                "App",
+		Arn:                 "arn:aws:iam::222222222222:role/App",
+		TrustPolicy:         lambdaTrust,
+		MaxSessionDuration:  7200,
+		AttachedPolicies:    []aws.Policy{{Arn: "arn:aws:iam::aws:policy/ReadOnlyAccess"}},
+		InlinePolicies:      []aws.Policy{{Name: "s3", IsInline: true, Document: bucketReadReordered}, {Name: "other", IsInline: true, Document: bucketReadOther}},
+		PermissionsBoundary: "arn:aws:iam::222222222222:policy/Boundary",
+		Tags:                map[string]string{"env": "production", "team": "data"},
+	}
+	return staging, production
+}
+
+func TestDiffRoles(t *testing.T) {
+	staging, production := diffRolePair()
+
+	roleDiff, err := audit.DiffRoles("staging", "production", staging, production)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if roleDiff.Equal || roleDiff.TrustPolicyChanged || roleDiff.AttachedPolicies != nil {
+		t.Errorf("expected only inline statements, tags, boundary and session duration to differ, got %+v", roleDiff)
+	}
+	if change := roleDiff.InlineStatements; change == nil || len(change.Removed) != 0 || len(change.Added) != 1 {
+		t.Errorf("expected only the invoices statement to be added, got %+v", change)
+	}
+	if change := roleDiff.Tags; change == nil || change.Removed[0] != "env=staging" || change.Added[0] != "env=production" {
+		t.Errorf("expected the env tag to change, got %+v", change)
+	}
+	if roleDiff.PermissionsBoundary == nil || roleDiff.MaxSessionDuration == nil || roleDiff.MaxSessionDuration.To != "7200s" {
+		t.Errorf("expected the boundary and session duration to change, got %+v %+v", roleDiff.PermissionsBoundary, roleDiff.MaxSessionDuration)
+	}
+	if !strings.Contains(roleDiff.Unified, "+      \"Resource\": [") || !strings.Contains(roleDiff.Unified, "-  \"MaxSessionDuration\": 3600,") {
+		t.Errorf("expected a unified diff of the snapshots, got:\n%s", roleDiff.Unified)
+	}
+
+	staging.InlinePolicies = []aws.Policy{{Name: "renamed", IsInline: true, Document: bucketReadReordered}}
+	same, err := audit.DiffRoles("a", "b", staging, staging)
+	if err != nil || !same.Equal || same.Unified != "" {
+		t.Errorf("expected a role to equal itself, got %+v (%v)", same, err)
+	}
+}
+
+func TestDiffRolesAcrossAccounts(t *testing.T) {
+	ownAccountRole := func(account string) aws.Role {
+		return aws.Role{
+			Name:                "App",
+			Arn:                 "arn:aws:iam::" + account + ":role/App",
+			TrustPolicy:         `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::` + account + `:role/Deployer"},"Action":"sts:AssumeRole"}]}`,
+			AttachedPolicies:    []aws.Policy{{Arn: "arn:aws:iam::" + account + ":policy/AppPolicy"}},
+			InlinePolicies:      []aws.Policy{{Name: "queue", IsInline: true, Document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"sqs:SendMessage","Resource":"arn:aws:sqs:us-east-1:` + account + `:jobs"}]}`}},
+			PermissionsBoundary: "arn:aws:iam::" + account + ":policy/Boundary",
+		}
+	}
+
+	staging, production := ownAccountRole("111111111111"), ownAccountRole("222222222222")
+	roleDiff, err := audit.DiffRoles("staging", "production", staging, production)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !roleDiff.Equal {
+		t.Errorf("expected roles referring only to their own account to be equal, got:\n%s", roleDiff.Unified)
+	}
+
+	// A reference to the other account is still a difference
+	production.AttachedPolicies = []aws.Policy{{Arn: "arn:aws:iam::111111111111:policy/AppPolicy"}}
+	roleDiff, err = audit.DiffRoles("staging", "production", staging, production)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change := roleDiff.AttachedPolicies; change == nil || change.Added[0] != "arn:aws:iam::111111111111:policy/AppPolicy" {
+		t.Errorf("expected the policy of the other account to differ, got %+v", change)
+	}
+}
+
+func TestDiffRolesCommandAcrossInventories(t *testing.T) {
+	staging, production := diffRolePair()
+
+	mockClient := NewMockIAMClient()
+	mockClient.Roles = []aws.Role{staging}
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	otherInventory := filepath.Join(t.TempDir(), "production.json")
+	if err := aws.SaveInventory(otherInventory, &aws.Inventory{Roles: []aws.Role{production}}); err != nil {
+		t.Fatal(err)
+	}
+
+	originalStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cmd := commands.NewDiffRolesCommand("", "", "App", "App", commands.DiffRolesOptions{
+		OtherInventory: otherInventory,
+		Output:         "json",
+	})
+	err := cmd.Execute(context.Background())
+
+	w.Close()
+	os.Stdout = originalStdout
+	out, _ := io.ReadAll(r)
+
+	if err != nil {
+		t.Fatalf("Command execution failed: %v", err)
+	}
+
+	var roleDiff audit.RoleDiff
+	if err := json.Unmarshal(out, &roleDiff); err != nil {
+		t.Fatalf("expected JSON output, got %s: %v", out, err)
+	}
+	if roleDiff.From != staging.Arn || roleDiff.To != production.Arn || roleDiff.Equal {
+		t.Errorf("expected the roles to be labelled by ARN and differ, got %+v", roleDiff)
+	}
+
+	missing := commands.NewDiffRolesCommand("", "", "App", "Missing", commands.DiffRolesOptions{OtherInventory: otherInventory})
+	if err := missing.Execute(context.Background()); err == nil {
+		t.Error("expected an error for a missing role")
+	}
+}
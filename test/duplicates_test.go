@@ -0,0 +1,144 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+)
+
+const (
+	lambdaTrust = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"lambda.amazonaws.com"},"Action":"sts:AssumeRole"}]}`
+	bucketRead  = `{"Version":"2012-10-17","Statement":[
+		{"Sid":"Read","Effect":"Allow","Action":["s3:GetObject","s3:ListBucket"],"Resource":["arn:aws:s3:::reports","arn:aws:s3:::reports/*"]}]}`
+	// bucketReadReordered grants the same as bucketRead with another layout
+	bucketReadReordered = `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Action":["s3:ListBucket","s3:GetObject","s3:GetObject"],"Resource":["arn:aws:s3:::reports/*","arn:aws:s3:::reports"]}]}`
+	// bucketReadOther differs from bucketRead only by its resource ARNs
+	bucketReadOther = `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Action":["s3:GetObject","s3:ListBucket"],"Resource":["arn:aws:s3:::invoices","arn:aws:s3:::invoices/*"]}]}`
+)
+
+func duplicateRoles() []aws.Role {
+	recent := time.Now().AddDate(0, 0, -3)
+	older := time.Now().AddDate(0, 0, -30)
+	stale := time.Now().AddDate(0, 0, -200)
+	created := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	logs := []aws.Policy{{Name: "AWSLambdaBasicExecutionRole", Arn: "arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole"}}
+
+	return []aws.Role{
+		{Name: "ReportsA", CreateDate: created, LastUsed: &older, TrustPolicy: lambdaTrust, AttachedPolicies: logs,
+			InlinePolicies: []aws.Policy{{Name: "read", IsInline: true, Document: bucketRead}}},
+		{Name: "ReportsB", CreateDate: created.AddDate(0, 1, 0), LastUsed: &recent, TrustPolicy: lambdaTrust, AttachedPolicies: logs,
+			InlinePolicies: []aws.Policy{{Name: "s3", IsInline: true, Document: bucketReadReordered}}},
+		{Name: "Invoices", CreateDate: created, LastUsed: &stale, TrustPolicy: lambdaTrust, AttachedPolicies: logs,
+			InlinePolicies: []aws.Policy{{Name: "read", IsInline: true, Document: bucketReadOther}}},
+		{Name: "Unrelated", CreateDate: created, TrustPolicy: lambdaTrust,
+			InlinePolicies: []aws.Policy{{Name: "read", IsInline: true, Document: bucketRead}}},
+		{Name: "OldA", CreateDate: created.AddDate(1, 0, 0), TrustPolicy: lambdaTrust},
+		{Name: "OldB", CreateDate: created, TrustPolicy: lambdaTrust},
+		{Name: "Broken", TrustPolicy: `{"Statement":`},
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	groups, skipped := audit.FindDuplicates(duplicateRoles(), 90)
+
+	if len(skipped) != 1 || skipped[0].RoleName != "Broken" {
+		t.Errorf("expected Broken to be skipped, got %+v", skipped)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 duplicate groups, got %+v", groups)
+	}
+
+	reports := groups[0]
+	if reports.Kind != audit.DuplicateSimilar || len(reports.Roles) != 3 {
+		t.Fatalf("expected a similar group of 3 roles first, got %+v", reports)
+	}
+	if reports.Survivor != "ReportsB" {
+		t.Errorf("expected the most recently used role to survive, got %s", reports.Survivor)
+	}
+	if last := reports.Roles[2]; last.RoleName != "Invoices" || !last.Unused {
+		t.Errorf("expected Invoices last and unused, got %+v", last)
+	}
+
+	unused := groups[1]
+	if unused.Kind != audit.DuplicateIdentical || unused.Survivor != "OldB" {
+		t.Errorf("expected an identical group surviving the oldest role OldB, got %+v", unused)
+	}
+}
+
+func TestFindDuplicatesIdenticalSets(t *testing.T) {
+	roles := duplicateRoles()
+	invoicesCopy := roles[2]
+	invoicesCopy.Name = "InvoicesCopy"
+	roles = append(roles, invoicesCopy)
+
+	groups, _ := audit.FindDuplicates(roles, 90)
+	reports := groups[0]
+	if reports.Kind != audit.DuplicateSimilar || len(reports.Roles) != 4 {
+		t.Fatalf("expected a similar group of 4 roles first, got %+v", reports)
+	}
+
+	sets := make(map[string]int)
+	for _, role := range reports.Roles {
+		sets[role.RoleName] = role.Set
+	}
+	if sets["ReportsB"] != 1 || sets["ReportsA"] != 1 {
+		t.Errorf("expected the survivor's identical set to be set 1, got %v", sets)
+	}
+	if sets["Invoices"] != 2 || sets["InvoicesCopy"] != 2 {
+		t.Errorf("expected the invoice roles to form identical set 2, got %v", sets)
+	}
+	for _, role := range groups[1].Roles {
+		if role.Set != 0 {
+			t.Errorf("expected no sets in an identical group, got %+v", role)
+		}
+	}
+}
+
+func TestFindDuplicatesIdentical(t *testing.T) {
+	groups, _ := audit.FindDuplicates(duplicateRoles()[:2], 90)
+	if len(groups) != 1 || groups[0].Kind != audit.DuplicateIdentical {
+		t.Errorf("expected reordered inline policies to be identical, got %+v", groups)
+	}
+}
+
+func TestDuplicatesCommand(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.Roles = duplicateRoles()
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	originalStdout, originalStderr := os.Stdout, os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stdout, os.Stderr = w, w
+
+	cmd := commands.NewDuplicatesCommand("", "", commands.DuplicatesOptions{
+		FilterOptions: commands.FilterOptions{Days: 90, NamePattern: "Reports*"},
+		Output:        "json",
+	})
+	err := cmd.Execute(context.Background())
+
+	w.Close()
+	os.Stdout, os.Stderr = originalStdout, originalStderr
+	out, _ := io.ReadAll(r)
+
+	if err != nil {
+		t.Fatalf("Command execution failed: %v", err)
+	}
+
+	var groups []audit.DuplicateGroup
+	if err := json.Unmarshal(out, &groups); err != nil {
+		t.Fatalf("expected JSON output, got %s: %v", out, err)
+	}
+	if len(groups) != 1 || len(groups[0].Roles) != 2 || groups[0].Survivor != "ReportsB" {
+		t.Errorf("expected ReportsA and ReportsB grouped with ReportsB kept, got %+v", groups)
+	}
+}
@@ -0,0 +1,198 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/formatter"
+)
+
+const userScopedPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::home/${aws:username}/*","Condition":{"StringLike":{"s3:prefix":["a&b"]}}}]}`
+
+func exportedRole() aws.Role {
+	return aws.Role{
+		Name:                "app-api",
+		Arn:                 "arn:aws:iam::123456789012:role/service/app-api",
+		Path:                "/service/",
+		Description:         `Runs the "api" service`,
+		MaxSessionDuration:  7200,
+		PermissionsBoundary: "arn:aws:iam::123456789012:policy/Boundary",
+		TrustPolicy:         lambdaTrust,
+		InlinePolicies:      []aws.Policy{{Name: "home", IsInline: true, Document: userScopedPolicy}},
+		AttachedPolicies:    []aws.Policy{{Name: "ReadOnlyAccess", Arn: "arn:aws:iam::aws:policy/ReadOnlyAccess"}},
+		Tags:                map[string]string{"team": "payments", "aws:cloudformation:stack-name": "old"},
+	}
+}
+
+func TestRolesTerraform(t *testing.T) {
+	hcl := formatter.RolesTerraform([]aws.Role{exportedRole()})
+
+	for _, expected := range []string{
+		`resource "aws_iam_role" "app-api" {`,
+		`  name                 = "app-api"`,
+		`  description          = "Runs the \"api\" service"`,
+		`  max_session_duration = 7200`,
+		`resource "aws_iam_role_policy" "app-api_home" {`,
+		`  role = aws_iam_role.app-api.name`,
+		`"arn:aws:s3:::home/$${aws:username}/*"`,
+		`resource "aws_iam_role_policy_attachment" "app-api_ReadOnlyAccess" {`,
+		"  to = aws_iam_role.app-api\n  id = \"app-api\"",
+		"  to = aws_iam_role_policy.app-api_home\n  id = \"app-api:home\"",
+		"  id = \"app-api/arn:aws:iam::aws:policy/ReadOnlyAccess\"",
+		`    "team" = "payments"`,
+	} {
+		if !strings.Contains(hcl, expected) {
+			t.Errorf("expected Terraform output to contain %q, got:\n%s", expected, hcl)
+		}
+	}
+	if strings.Contains(hcl, "aws:cloudformation") {
+		t.Errorf("expected aws: tags to be left out, got:\n%s", hcl)
+	}
+}
+
+func TestRolesTerraformNameCollisions(t *testing.T) {
+	policy := func(name string) aws.Policy {
+		return aws.Policy{Name: name, IsInline: true, Document: userScopedPolicy}
+	}
+	hcl := formatter.RolesTerraform([]aws.Role{
+		{Name: "svc.a", TrustPolicy: lambdaTrust},
+		{Name: "svc_a", TrustPolicy: lambdaTrust},
+		{Name: "a", TrustPolicy: lambdaTrust, InlinePolicies: []aws.Policy{policy("b_c")}},
+		{Name: "a_b", TrustPolicy: lambdaTrust, InlinePolicies: []aws.Policy{policy("c")}},
+	})
+
+	for _, expected := range []string{
+		`resource "aws_iam_role" "svc_a" {`,
+		`resource "aws_iam_role" "svc_a_2" {`,
+		"  to = aws_iam_role.svc_a\n  id = \"svc.a\"",
+		"  to = aws_iam_role.svc_a_2\n  id = \"svc_a\"",
+		"  to = aws_iam_role_policy.a_b_c\n  id = \"a:b_c\"",
+		"  to = aws_iam_role_policy.a_b_c_2\n  id = \"a_b:c\"",
+		"  role = aws_iam_role.a_b.name",
+	} {
+		if !strings.Contains(hcl, expected) {
+			t.Errorf("expected Terraform output to contain %q, got:\n%s", expected, hcl)
+		}
+	}
+	if count := strings.Count(hcl, `resource "aws_iam_role_policy" "a_b_c" {`); count != 1 {
+		t.Errorf("expected one policy resource named a_b_c, got %d:\n%s", count, hcl)
+	}
+}
+
+func TestRolesCloudFormation(t *testing.T) {
+	role := exportedRole()
+	template := formatter.RolesCloudFormation([]aws.Role{role})
+
+	var parsed struct {
+		Resources map[string]struct {
+			Type           string
+			DeletionPolicy string
+			Properties     struct {
+				RoleName                 string
+				Description              string
+				MaxSessionDuration       int
+				AssumeRolePolicyDocument interface{}
+				ManagedPolicyArns        []string
+				Policies                 []struct {
+					PolicyName     string
+					PolicyDocument interface{}
+				}
+				Tags []map[string]string
+			}
+		}
+	}
+	var document interface{}
+	if err := yaml.Unmarshal([]byte(template), &document); err != nil {
+		t.Fatalf("expected a valid YAML template, got %v:\n%s", err, template)
+	}
+	// Go through JSON, whose field matching ignores case
+	content, err := json.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(content, &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	resource, ok := parsed.Resources["Roleappapi"]
+	if !ok || resource.Type != "AWS::IAM::Role" || resource.DeletionPolicy != "Retain" {
+		t.Fatalf("expected a retained AWS::IAM::Role named Roleappapi, got %+v", parsed.Resources)
+	}
+	properties := resource.Properties
+	if properties.RoleName != "app-api" || properties.Description != role.Description || properties.MaxSessionDuration != 7200 {
+		t.Errorf("expected the role attributes to be kept, got %+v", properties)
+	}
+	if len(properties.ManagedPolicyArns) != 1 || len(properties.Tags) != 1 || properties.Tags[0]["Value"] != "payments" {
+		t.Errorf("expected one managed policy and the team tag, got %+v", properties)
+	}
+	if len(properties.Policies) != 1 || !sameDocument(t, properties.Policies[0].PolicyDocument, userScopedPolicy) {
+		t.Errorf("expected the inline policy document to be rendered faithfully, got %+v", properties.Policies)
+	}
+	if !sameDocument(t, properties.AssumeRolePolicyDocument, lambdaTrust) {
+		t.Errorf("expected the trust policy to be rendered faithfully, got %+v", properties.AssumeRolePolicyDocument)
+	}
+}
+
+// sameDocument reports whether a parsed YAML value equals a JSON document
+func sameDocument(t *testing.T, parsed interface{}, document string) bool {
+	t.Helper()
+	var expected interface{}
+	if err := json.Unmarshal([]byte(document), &expected); err != nil {
+		t.Fatal(err)
+	}
+	roundTrip, err := json.Marshal(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual interface{}
+	if err := json.Unmarshal(roundTrip, &actual); err != nil {
+		t.Fatal(err)
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+func TestExportCommandSelectsUnmanagedRoles(t *testing.T) {
+	managed := exportedRole()
+	managed.Name = "stack-role"
+	plain := exportedRole()
+	plain.Tags = map[string]string{"team": "payments"}
+
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	if err := aws.SaveInventory(path, &aws.Inventory{Roles: []aws.Role{managed, plain}}); err != nil {
+		t.Fatal(err)
+	}
+
+	originalStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := commands.NewExportCommand("", "", commands.ExportOptions{
+		Inventory: path,
+		Format:    "terraform",
+		Unmanaged: true,
+	}).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+	out, _ := io.ReadAll(r)
+
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if strings.Contains(string(out), "stack-role") || !strings.Contains(string(out), `resource "aws_iam_role" "app-api"`) {
+		t.Errorf("expected only the role without a stack tag to be exported, got:\n%s", out)
+	}
+
+	err = commands.NewExportCommand("", "", commands.ExportOptions{Inventory: path, Format: "pulumi"}).Execute(context.Background())
+	if err == nil {
+		t.Error("expected an unsupported format to be rejected")
+	}
+}
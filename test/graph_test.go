@@ -0,0 +1,134 @@
+package test
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/formatter"
+)
+
+func graphRoles() []aws.Role {
+	recent := time.Now().AddDate(0, 0, -1)
+	assume := func(resource string) []aws.Policy {
+		return []aws.Policy{{Name: "assume", IsInline: true, Document: `{"Version":"2012-10-17","Statement":[
+			{"Effect":"Allow","Action":"sts:AssumeRole","Resource":"` + resource + `"}]}`}}
+	}
+
+	return []aws.Role{
+		{
+			Name: "Ci", Arn: "arn:aws:iam::123456789012:role/Ci", LastUsed: &recent,
+			TrustPolicy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow",
+				"Principal":{"Federated":"arn:aws:iam::123456789012:oidc-provider/token.actions.githubusercontent.com"},
+				"Action":"sts:AssumeRoleWithWebIdentity",
+				"Condition":{"StringLike":{"token.actions.githubusercontent.com:sub":"repo:org/app:*"}}}]}`,
+			InlinePolicies: assume("arn:aws:iam::123456789012:role/Deploy"),
+		},
+		{
+			Name: "Deploy", Arn: "arn:aws:iam::123456789012:role/Deploy", LastUsed: &recent,
+			TrustPolicy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow",
+				"Principal":{"AWS":"arn:aws:iam::123456789012:role/Ci"},"Action":"sts:AssumeRole"}]}`,
+			InlinePolicies: assume("arn:aws:iam::123456789012:role/Admin*"),
+		},
+		{
+			Name: "Admin", Arn: "arn:aws:iam::123456789012:role/Admin",
+			TrustPolicy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow",
+				"Principal":{"AWS":"arn:aws:iam::123456789012:root"},"Action":"sts:AssumeRole"}]}`,
+		},
+		{
+			Name: "Worker", Arn: "arn:aws:iam::123456789012:role/Worker", LastUsed: &recent,
+			TrustPolicy:    lambdaTrust,
+			InlinePolicies: assume("*"),
+		},
+	}
+}
+
+func findEdge(graph *audit.RoleGraph, from, to string) *audit.GraphEdge {
+	for i, edge := range graph.Edges {
+		if edge.From == from && edge.To == to {
+			return &graph.Edges[i]
+		}
+	}
+	return nil
+}
+
+func TestBuildRoleGraph(t *testing.T) {
+	roles := graphRoles()
+	graph, skipped := audit.BuildRoleGraph(&aws.Inventory{Roles: roles}, 90)
+	if len(skipped) != 0 {
+		t.Errorf("expected no skipped roles, got %+v", skipped)
+	}
+
+	if len(graph.Edges) != 5 {
+		t.Errorf("expected 5 edges, got %+v", graph.Edges)
+	}
+	if edge := findEdge(graph, "federated:arn:aws:iam::123456789012:oidc-provider/token.actions.githubusercontent.com", roles[0].Arn); edge == nil || !edge.Conditional {
+		t.Errorf("expected a conditional edge from the GitHub provider to Ci, got %+v", edge)
+	}
+	if edge := findEdge(graph, roles[0].Arn, roles[1].Arn); edge == nil || !edge.Trust || !edge.Identity {
+		t.Errorf("expected Ci to assume Deploy through trust and identity policies, got %+v", edge)
+	}
+	if edge := findEdge(graph, roles[1].Arn, roles[2].Arn); edge == nil || !edge.Trust || !edge.Identity {
+		t.Errorf("expected Deploy to assume Admin through its account trust, got %+v", edge)
+	}
+	if edge := findEdge(graph, "account:123456789012", roles[2].Arn); edge == nil || edge.Identity {
+		t.Errorf("expected a trust edge from the account to Admin, got %+v", edge)
+	}
+	if edge := findEdge(graph, roles[3].Arn, roles[2].Arn); edge != nil {
+		t.Errorf("expected no edges from a grant on * only, got %+v", edge)
+	}
+
+	for _, node := range graph.Nodes {
+		if node.ID == roles[2].Arn && !node.Unused {
+			t.Errorf("expected Admin to be marked unused")
+		}
+	}
+
+	chains := graph.Chains(roles)
+	if len(chains) != 1 || chains[0].RoleName != "Deploy" || chains[0].From[0] != "Ci" || chains[0].To[0] != "Admin" {
+		t.Errorf("expected only Deploy to chain Ci to Admin, got %+v", chains)
+	}
+
+	dot := formatter.RoleGraphDOT(graph)
+	if !strings.Contains(dot, `"arn:aws:iam::123456789012:role/Admin" [label="Admin", shape=box, style=dashed`) {
+		t.Errorf("expected the unused Admin role to be dashed, got:\n%s", dot)
+	}
+	mermaid := formatter.RoleGraphMermaid(graph)
+	if !strings.HasPrefix(mermaid, "flowchart LR\n") || !strings.Contains(mermaid, "-.->|conditional|") || !strings.Contains(mermaid, " unused\n") {
+		t.Errorf("expected a Mermaid flowchart with conditional edges and unused roles, got:\n%s", mermaid)
+	}
+}
+
+func TestDeleteWarnsAboutChainedRole(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.Roles = graphRoles()
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+	aws.SetTestReferenceScanners()
+	defer aws.ClearTestReferenceScanners()
+	aws.SetTestResourcePolicyScanners()
+	defer aws.ClearTestResourcePolicyScanners()
+
+	originalStdout, originalStderr := os.Stdout, os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stdout, os.Stderr = w, w
+
+	err := commands.NewDeleteCommand("", "", "Deploy", commands.DeleteOptions{DryRun: true}).Execute(context.Background())
+
+	w.Close()
+	os.Stdout, os.Stderr = originalStdout, originalStderr
+	out, _ := io.ReadAll(r)
+
+	if err != nil {
+		t.Fatalf("Command execution failed: %v", err)
+	}
+	if !strings.Contains(string(out), "Warning: Role Deploy is part of assume-role chains: Ci -> Deploy -> Admin") {
+		t.Errorf("expected a chain warning, got:\n%s", out)
+	}
+}
@@ -0,0 +1,197 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/policy"
+)
+
+const (
+	appInlinePolicy = `{"Version":"2012-10-17","Statement":[
+		{"Sid":"App","Effect":"Allow","Action":["s3:*","dynamodb:GetItem","sqs:SendMessage"],"Resource":"*"},
+		{"Effect":"Deny","Action":"s3:DeleteBucket","Resource":"*"}]}`
+	appManagedArn    = "arn:aws:iam::123456789012:policy/AppOps"
+	appManagedPolicy = `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Action":["ec2:DescribeInstances","ec2:TerminateInstances","logs:GetLogEvents"],"Resource":"*"}]}`
+)
+
+func leastPrivilegeClient(now time.Time) *MockIAMClient {
+	recent := now.AddDate(0, 0, -2)
+	stale := now.AddDate(0, 0, -300)
+
+	mockClient := NewMockIAMClient()
+	mockClient.Roles = []aws.Role{{
+		Name:             "App",
+		Arn:              "arn:aws:iam::123456789012:role/App",
+		LastUsed:         &recent,
+		InlinePolicies:   []aws.Policy{{Name: "app", IsInline: true, Document: appInlinePolicy}},
+		AttachedPolicies: []aws.Policy{{Name: "AppOps", Arn: appManagedArn}},
+	}}
+	mockClient.ManagedPolicies[appManagedArn] = aws.Policy{Name: "AppOps", Arn: appManagedArn, Document: appManagedPolicy}
+	mockClient.ServicesLastAccessed["arn:aws:iam::123456789012:role/App"] = []aws.ServiceLastAccessed{
+		{ServiceNamespace: "s3", LastAccessed: &recent},
+		{ServiceNamespace: "dynamodb", LastAccessed: &stale},
+		{ServiceNamespace: "sqs"},
+		{ServiceNamespace: "logs"},
+		{ServiceNamespace: "ec2", LastAccessed: &recent, Actions: []aws.ActionLastAccessed{
+			{ActionName: "DescribeInstances", LastAccessed: &recent},
+			{ActionName: "TerminateInstances"},
+		}},
+	}
+	return mockClient
+}
+
+func TestGenerateLeastPrivilege(t *testing.T) {
+	now := time.Now()
+	mockClient := leastPrivilegeClient(now)
+	inventory, _ := mockClient.GetInventory(context.Background())
+	role := inventory.Roles[0]
+	policySet, err := inventory.PolicySet(role)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generated := audit.GenerateLeastPrivilege(role, policySet, mockClient.ServicesLastAccessed[role.Arn], 90, now)
+
+	if len(generated.Current.Statement) != 3 {
+		t.Errorf("expected the current permissions to merge 3 statements, got %d", len(generated.Current.Statement))
+	}
+	proposed := generated.Proposed.Statement
+	if len(proposed) != 3 {
+		t.Fatalf("expected 3 proposed statements, got %+v", proposed)
+	}
+	if len(proposed[0].Action) != 1 || proposed[0].Action[0] != "s3:*" || proposed[0].Sid != "" {
+		t.Errorf("expected only s3:* to remain of the inline policy, got %+v", proposed[0])
+	}
+	if proposed[1].Effect != "Deny" {
+		t.Errorf("expected the Deny statement to be kept, got %+v", proposed[1])
+	}
+	if len(proposed[2].Action) != 1 || proposed[2].Action[0] != "ec2:DescribeInstances" {
+		t.Errorf("expected only the used tracked ec2 action to remain, got %+v", proposed[2])
+	}
+	if len(generated.Notes) != 1 || !strings.Contains(generated.Notes[0], "s3:*") {
+		t.Errorf("expected a note about the kept s3 wildcard, got %v", generated.Notes)
+	}
+}
+
+func TestLeastPrivilegeNeverExceedsCurrent(t *testing.T) {
+	now := time.Now()
+	recent := now.AddDate(0, 0, -2)
+	services := []aws.ServiceLastAccessed{
+		{ServiceNamespace: "iam", LastAccessed: &recent},
+		{ServiceNamespace: "s3", LastAccessed: &recent},
+		{ServiceNamespace: "ec2", LastAccessed: &recent},
+	}
+
+	tests := []struct {
+		name     string
+		document string
+		allowed  []string
+	}{
+		{
+			name: "NotAction excluding some actions of an accessed service",
+			document: `{"Version":"2012-10-17","Statement":[
+				{"Effect":"Allow","NotAction":["iam:CreateUser","iam:PassRole"],"Resource":"*"},
+				{"Effect":"Deny","Action":"s3:DeleteBucket","Resource":"*"}]}`,
+			allowed: []string{"iam:GetRole", "s3:GetObject", "ec2:DescribeInstances"},
+		},
+		{
+			name: "NotAction excluding whole services",
+			document: `{"Version":"2012-10-17","Statement":[
+				{"Effect":"Allow","NotAction":["iam:*","e*:*"],"Resource":"*"},
+				{"Effect":"Deny","Action":"s3:DeleteBucket","Resource":"*"}]}`,
+			allowed: []string{"s3:GetObject"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			current := policy.PolicySet{Identity: []policy.Source{{Name: "app", Document: mustParse(t, test.document)}}}
+			generated := audit.GenerateLeastPrivilege(aws.Role{Name: "App"}, current, services, 90, now)
+			proposed := policy.PolicySet{Identity: []policy.Source{{Name: "proposed", Document: generated.Proposed}}}
+
+			for _, action := range []string{"iam:CreateUser", "iam:PassRole", "iam:GetRole", "s3:GetObject", "s3:DeleteBucket", "ec2:DescribeInstances", "ec2:TerminateInstances"} {
+				request := policy.Request{Action: action, Resource: "*"}
+				if proposed.Evaluate(request).Allowed() && !current.Evaluate(request).Allowed() {
+					t.Errorf("expected the proposal not to allow %s, which the current policy does not allow", action)
+				}
+			}
+			for _, action := range test.allowed {
+				if !proposed.Evaluate(policy.Request{Action: action, Resource: "*"}).Allowed() {
+					t.Errorf("expected the proposal to keep allowing %s", action)
+				}
+			}
+		})
+	}
+}
+
+func TestLeastPrivilegeApplyAndRestore(t *testing.T) {
+	mockClient := leastPrivilegeClient(time.Now())
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	dir := t.TempDir()
+	policyFile := filepath.Join(dir, "app.tf")
+	backupDir := filepath.Join(dir, "backups")
+
+	run := func(cmd interface{ Execute(context.Context) error }) error {
+		originalStdout, originalStderr := os.Stdout, os.Stderr
+		_, w, _ := os.Pipe()
+		os.Stdout, os.Stderr = w, w
+		err := cmd.Execute(context.Background())
+		w.Close()
+		os.Stdout, os.Stderr = originalStdout, originalStderr
+		return err
+	}
+
+	err := run(commands.NewLeastPrivilegeCommand("", "", "App", commands.LeastPrivilegeOptions{
+		Days:         90,
+		File:         policyFile,
+		Apply:        true,
+		Force:        true,
+		BackupDir:    backupDir,
+		PollInterval: time.Millisecond,
+	}))
+	if err != nil {
+		t.Fatalf("Command execution failed: %v", err)
+	}
+
+	content, err := os.ReadFile(policyFile)
+	if err != nil || !strings.Contains(string(content), `resource "aws_iam_role_policy" "App_hawkling-least-privilege"`) {
+		t.Errorf("expected a Terraform resource to be written, got %s (%v)", content, err)
+	}
+
+	inline := mockClient.InlinePolicies["App"]
+	if document, ok := inline[commands.DefaultLeastPrivilegePolicyName]; !ok || strings.Contains(document, "dynamodb") {
+		t.Errorf("expected the proposed policy to be put without dynamodb, got %v", inline)
+	}
+	if detached := mockClient.DetachedPolicies["App"]; len(detached) != 1 || detached[0] != appManagedArn {
+		t.Errorf("expected %s to be detached, got %v", appManagedArn, detached)
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(backupDir, "least-privilege", "App-*.json"))
+	if len(backups) != 1 {
+		t.Fatalf("expected one backup, got %v", backups)
+	}
+
+	if err := run(commands.NewLeastPrivilegeRestoreCommand("", "", backups, commands.DeleteOptions{Force: true})); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if _, ok := mockClient.InlinePolicies["App"][commands.DefaultLeastPrivilegePolicyName]; ok {
+		t.Errorf("expected the generated policy to be deleted on restore")
+	}
+	if mockClient.InlinePolicies["App"]["app"] != appInlinePolicy {
+		t.Errorf("expected the original inline policy to be restored, got %v", mockClient.InlinePolicies["App"])
+	}
+	if attached := mockClient.AttachedPolicies["App"]; len(attached) != 1 || attached[0] != appManagedArn {
+		t.Errorf("expected %s to be reattached, got %v", appManagedArn, attached)
+	}
+}
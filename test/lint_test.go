@@ -0,0 +1,172 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/lint"
+)
+
+func lintRoles() []aws.Role {
+	return []aws.Role{
+		{
+			Name:               "AdminRole",
+			Arn:                "arn:aws:iam::123456789012:role/admin/AdminRole",
+			Path:               "/admin/",
+			MaxSessionDuration: 43200,
+			TrustPolicy:        `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::123456789012:root"},"Action":"sts:AssumeRole"}]}`,
+			AttachedPolicies:   []aws.Policy{{Name: "AdministratorAccess", Arn: "arn:aws:iam::aws:policy/AdministratorAccess"}},
+			InlinePolicies: []aws.Policy{{Name: "Everything", IsInline: true,
+				Document: `{"Version":"2012-10-17","Statement":{"Effect":"Allow","Action":"*","Resource":"*"}}`}},
+		},
+		{
+			Name:               "GoodRole",
+			Arn:                "arn:aws:iam::123456789012:role/admin/GoodRole",
+			Path:               "/admin/",
+			Description:        "Break-glass access",
+			MaxSessionDuration: 3600,
+			TrustPolicy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::123456789012:root"},"Action":"sts:AssumeRole",
+				"Condition":{"Bool":{"aws:MultiFactorAuthPresent":"true"}}}]}`,
+			PermissionsBoundary: "arn:aws:iam::123456789012:policy/Boundary",
+			Tags:                map[string]string{"owner": "platform"},
+		},
+	}
+}
+
+func TestLinterBuiltinRules(t *testing.T) {
+	config := lint.DefaultConfig()
+	config.RequiredTags = []string{"owner"}
+	config.BoundaryPaths = []string{"/admin/"}
+
+	findings := lint.NewLinter(config).Lint(lintRoles())
+
+	rules := make(map[string]bool)
+	for _, finding := range findings {
+		if finding.RoleName == "GoodRole" {
+			t.Errorf("unexpected finding for GoodRole: %+v", finding)
+		}
+		rules[finding.RuleID] = true
+	}
+
+	for _, id := range []string{
+		lint.RuleMaxSessionDuration, lint.RuleMissingDescription, lint.RuleRequiredTags, lint.RuleBoundaryRequired,
+		lint.RuleAdministratorAccess, lint.RuleInlineWildcard, lint.RuleTrustWithoutMFA,
+	} {
+		if !rules[id] {
+			t.Errorf("expected a finding for rule %s", id)
+		}
+	}
+
+	if findings[0].Severity != lint.SeverityCritical {
+		t.Errorf("expected the most severe finding first, got %s", findings[0].Severity)
+	}
+}
+
+func TestTrustWithoutMFAChecksConditionValues(t *testing.T) {
+	trust := func(condition string) aws.Role {
+		return aws.Role{
+			Name: "Assumable",
+			Arn:  "arn:aws:iam::123456789012:role/Assumable",
+			TrustPolicy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::123456789012:root"},"Action":"sts:AssumeRole",
+				"Condition":` + condition + `}]}`,
+		}
+	}
+
+	tests := []struct {
+		condition string
+		flagged   bool
+	}{
+		{`{"Bool":{"aws:MultiFactorAuthPresent":"true"}}`, false},
+		{`{"Bool":{"aws:MultiFactorAuthPresent":"false"}}`, true},
+		{`{"Bool":{"aws:MultiFactorAuthPresent":["true","false"]}}`, true},
+		{`{"BoolIfExists":{"aws:MultiFactorAuthPresent":"true"}}`, true},
+		{`{"NumericLessThan":{"aws:MultiFactorAuthAge":"3600"}}`, false},
+		{`{"NumericLessThanIfExists":{"aws:MultiFactorAuthAge":"3600"}}`, true},
+		{`{"NumericGreaterThan":{"aws:MultiFactorAuthAge":"3600"}}`, true},
+	}
+	for _, test := range tests {
+		flagged := false
+		for _, finding := range lint.NewLinter(lint.DefaultConfig()).Lint([]aws.Role{trust(test.condition)}) {
+			flagged = flagged || finding.RuleID == lint.RuleTrustWithoutMFA
+		}
+		if flagged != test.flagged {
+			t.Errorf("expected %s to be flagged = %v", test.condition, test.flagged)
+		}
+	}
+}
+
+func TestLintConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lint.json")
+	content := `{"Rules": {"missing-description": {"Enabled": false}, "administrator-access": {"Severity": "CRITICAL"}}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := lint.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if config.MaxSessionDuration != 3600 {
+		t.Errorf("expected the default session limit to be kept, got %d", config.MaxSessionDuration)
+	}
+
+	for _, finding := range lint.NewLinter(config).Lint(lintRoles()) {
+		if finding.RuleID == lint.RuleMissingDescription {
+			t.Errorf("expected missing-description to be disabled")
+		}
+		if finding.RuleID == lint.RuleAdministratorAccess && finding.Severity != lint.SeverityCritical {
+			t.Errorf("expected administrator-access severity to be overridden, got %s", finding.Severity)
+		}
+	}
+
+	config.Rules["no-such-rule"] = lint.RuleConfig{}
+	if err := lint.NewLinter(config).Validate(); err == nil {
+		t.Errorf("expected an error for an unknown rule")
+	}
+}
+
+func TestLintCommandFailOn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	if err := aws.SaveInventory(path, &aws.Inventory{Roles: lintRoles()}); err != nil {
+		t.Fatalf("SaveInventory() error = %v", err)
+	}
+
+	originalStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	sarifErr := commands.NewLintCommand("", "", commands.LintOptions{
+		FilterOptions: commands.FilterOptions{NamePattern: "GoodRole"},
+		Inventory:     path,
+		Output:        "sarif",
+		FailOn:        "low",
+	}).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+	output, _ := io.ReadAll(r)
+
+	if sarifErr != nil {
+		t.Errorf("expected GoodRole to pass, got %v", sarifErr)
+	}
+	var log map[string]interface{}
+	if err := json.Unmarshal(output, &log); err != nil || log["version"] != "2.1.0" {
+		t.Errorf("expected a SARIF 2.1.0 log, got %s", output)
+	}
+
+	_, w, _ = os.Pipe()
+	os.Stdout = w
+	err := commands.NewLintCommand("", "", commands.LintOptions{Inventory: path, Output: "json", FailOn: "high"}).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+
+	if err == nil || !strings.Contains(err.Error(), "severity high") {
+		t.Errorf("expected the command to fail on high findings, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"context"
+	"sync"
+
+	"hawkling/pkg/aws"
+)
+
+// MockAnalyzerClient implements the AnalyzerClient interface for testing
+type MockAnalyzerClient struct {
+	// Findings maps policy documents to the findings returned for them
+	Findings map[string][]aws.ValidationFinding
+
+	// Validated records the kind of each validated document
+	Validated map[string]aws.PolicyKind
+
+	// AnalyzerArn is the unused-access analyzer, empty if there is none
+	AnalyzerArn    string
+	UnusedFindings []aws.UnusedAccessFinding
+
+	ErrorMode bool
+	mu        sync.Mutex
+}
+
+// NewMockAnalyzerClient creates a new mock Access Analyzer client
+func NewMockAnalyzerClient() *MockAnalyzerClient {
+	return &MockAnalyzerClient{
+		Findings:  make(map[string][]aws.ValidationFinding),
+		Validated: make(map[string]aws.PolicyKind),
+	}
+}
+
+// ValidatePolicy returns the findings configured for the document
+func (m *MockAnalyzerClient) ValidatePolicy(ctx context.Context, document string, kind aws.PolicyKind) ([]aws.ValidationFinding, error) {
+	if m.ErrorMode {
+		return nil, ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Validated[document] = kind
+	return m.Findings[document], nil
+}
+
+// FindUnusedAccessAnalyzer returns the configured analyzer ARN
+func (m *MockAnalyzerClient) FindUnusedAccessAnalyzer(ctx context.Context) (string, error) {
+	if m.ErrorMode {
+		return "", ErrSimulated
+	}
+	return m.AnalyzerArn, nil
+}
+
+// ListUnusedAccessFindings returns the configured unused-access findings
+func (m *MockAnalyzerClient) ListUnusedAccessFindings(ctx context.Context, analyzerArn string) ([]aws.UnusedAccessFinding, error) {
+	if m.ErrorMode {
+		return nil, ErrSimulated
+	}
+	return m.UnusedFindings, nil
+}
@@ -2,6 +2,8 @@ package test
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	"hawkling/pkg/aws"
@@ -18,7 +20,34 @@ type MockIAMClient struct {
 	DeletedRoles     []string
 	DetachedPolicies map[string][]string
 	DeletedPolicies  map[string][]string
+	TrustPolicies    map[string]string
+	InlinePolicies   map[string]map[string]string
+	OIDCProviders    []string
+	ManagedPolicies  map[string]aws.Policy
+	Boundaries       map[string]string
+	AttachedPolicies map[string][]string
 	ErrorMode        bool
+
+	// InventoryErrorMode fails only GetInventory, as without
+	// iam:GetAccountAuthorizationDetails
+	InventoryErrorMode bool
+	// TagLookups counts the roles whose tags were listed
+	TagLookups int
+
+	// AccountID is the account the mock works in
+	AccountID string
+
+	// InstanceProfiles maps instance profiles created to their roles
+	InstanceProfiles map[string][]string
+
+	// ServicesLastAccessed maps role ARNs to their service last accessed
+	// report. Each job reports IN_PROGRESS for PendingPolls polls first.
+	ServicesLastAccessed map[string][]aws.ServiceLastAccessed
+	PendingPolls         int
+	jobs                 map[string]string
+	polls                map[string]int
+
+	mu sync.Mutex
 }
 
 // NewMockIAMClient creates a new mock IAM client with predefined roles
@@ -55,7 +84,18 @@ func NewMockIAMClient() *MockIAMClient {
 		DeletedRoles:     []string{},
 		DetachedPolicies: make(map[string][]string),
 		DeletedPolicies:  make(map[string][]string),
+		TrustPolicies:    make(map[string]string),
+		InlinePolicies:   make(map[string]map[string]string),
+		ManagedPolicies:  make(map[string]aws.Policy),
+		Boundaries:       make(map[string]string),
+		AttachedPolicies: make(map[string][]string),
 		ErrorMode:        false,
+		AccountID:        "123456789012",
+		InstanceProfiles: make(map[string][]string),
+
+		ServicesLastAccessed: make(map[string][]aws.ServiceLastAccessed),
+		jobs:                 make(map[string]string),
+		polls:                make(map[string]int),
 	}
 }
 
@@ -111,6 +151,75 @@ func (m *MockIAMClient) DeleteRole(ctx context.Context, roleName string) error {
 	return nil
 }
 
+// CreateRole adds a role to the mock roles, failing if it exists
+func (m *MockIAMClient) CreateRole(ctx context.Context, role aws.Role) error {
+	if m.ErrorMode {
+		return ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.Roles {
+		if existing.Name == role.Name {
+			return fmt.Errorf("role %s already exists", role.Name)
+		}
+	}
+	role.Arn = fmt.Sprintf("arn:aws:iam::%s:role%s%s", m.AccountID, rolePath(role.Path), role.Name)
+	m.Roles = append(m.Roles, role)
+	return nil
+}
+
+// CreateInstanceProfile records a new instance profile
+func (m *MockIAMClient) CreateInstanceProfile(ctx context.Context, name, path string) error {
+	if m.ErrorMode {
+		return ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.InstanceProfiles[name]; ok {
+		return fmt.Errorf("instance profile %s already exists", name)
+	}
+	m.InstanceProfiles[name] = []string{}
+	return nil
+}
+
+// AddRoleToInstanceProfile records a role added to an instance profile
+func (m *MockIAMClient) AddRoleToInstanceProfile(ctx context.Context, profileName, roleName string) error {
+	if m.ErrorMode {
+		return ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.InstanceProfiles[profileName]; !ok {
+		return fmt.Errorf("instance profile %s does not exist", profileName)
+	}
+	m.InstanceProfiles[profileName] = append(m.InstanceProfiles[profileName], roleName)
+	return nil
+}
+
+// ListRoleTags returns the tags of a mock role
+func (m *MockIAMClient) ListRoleTags(ctx context.Context, roleName string) (map[string]string, error) {
+	if m.ErrorMode {
+		return nil, ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.TagLookups++
+	for _, role := range m.Roles {
+		if role.Name == roleName {
+			return role.Tags, nil
+		}
+	}
+	return nil, fmt.Errorf("role %s not found", roleName)
+}
+
 // DetachRolePolicies mocks detaching all managed policies from a role
 func (m *MockIAMClient) DetachRolePolicies(ctx context.Context, roleName string) error {
 	if m.ErrorMode {
@@ -133,6 +242,202 @@ func (m *MockIAMClient) DeleteInlinePolicies(ctx context.Context, roleName strin
 	return nil
 }
 
+// AttachRolePolicy records a managed policy attached to a role
+func (m *MockIAMClient) AttachRolePolicy(ctx context.Context, roleName, policyArn string) error {
+	if m.ErrorMode {
+		return ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.AttachedPolicies[roleName] = append(m.AttachedPolicies[roleName], policyArn)
+	return nil
+}
+
+// DetachRolePolicy records a single managed policy detached from a role
+func (m *MockIAMClient) DetachRolePolicy(ctx context.Context, roleName, policyArn string) error {
+	if m.ErrorMode {
+		return ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.DetachedPolicies[roleName] = append(m.DetachedPolicies[roleName], policyArn)
+	return nil
+}
+
+// UpdateAssumeRolePolicy records the new trust policy of a role
+func (m *MockIAMClient) UpdateAssumeRolePolicy(ctx context.Context, roleName, document string) error {
+	if m.ErrorMode {
+		return ErrSimulated
+	}
+
+	// Record the updated trust policy for verification in tests
+	m.TrustPolicies[roleName] = document
+	return nil
+}
+
+// GetRolePolicy returns a recorded inline policy of a role
+func (m *MockIAMClient) GetRolePolicy(ctx context.Context, roleName, policyName string) (string, error) {
+	if m.ErrorMode {
+		return "", ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.InlinePolicies[roleName][policyName], nil
+}
+
+// PutRolePolicy records an inline policy put on a role
+func (m *MockIAMClient) PutRolePolicy(ctx context.Context, roleName, policyName, document string) error {
+	if m.ErrorMode {
+		return ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.InlinePolicies[roleName] == nil {
+		m.InlinePolicies[roleName] = make(map[string]string)
+	}
+	m.InlinePolicies[roleName][policyName] = document
+	return nil
+}
+
+// DeleteRolePolicy removes a recorded inline policy from a role
+func (m *MockIAMClient) DeleteRolePolicy(ctx context.Context, roleName, policyName string) error {
+	if m.ErrorMode {
+		return ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.InlinePolicies[roleName], policyName)
+	return nil
+}
+
+// PutRolePermissionsBoundary records the permissions boundary set on a role
+func (m *MockIAMClient) PutRolePermissionsBoundary(ctx context.Context, roleName, boundaryArn string) error {
+	if m.ErrorMode {
+		return ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Boundaries[roleName] = boundaryArn
+	return nil
+}
+
+// DeleteRolePermissionsBoundary records the removal of a role's permissions boundary
+func (m *MockIAMClient) DeleteRolePermissionsBoundary(ctx context.Context, roleName string) error {
+	if m.ErrorMode {
+		return ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Boundaries[roleName] = ""
+	return nil
+}
+
+// GetPolicy returns a mock managed policy, or nil if it does not exist
+func (m *MockIAMClient) GetPolicy(ctx context.Context, policyArn string) (*aws.Policy, error) {
+	if m.ErrorMode {
+		return nil, ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	managed, ok := m.ManagedPolicies[policyArn]
+	if !ok {
+		return nil, nil
+	}
+	return &managed, nil
+}
+
+// CreatePolicy adds a customer managed policy to the mock policies
+func (m *MockIAMClient) CreatePolicy(ctx context.Context, name, path, document string) (string, error) {
+	if m.ErrorMode {
+		return "", ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	arn := fmt.Sprintf("arn:aws:iam::%s:policy%s%s", m.AccountID, rolePath(path), name)
+	if _, ok := m.ManagedPolicies[arn]; ok {
+		return "", fmt.Errorf("policy %s already exists", arn)
+	}
+	m.ManagedPolicies[arn] = aws.Policy{Name: name, Arn: arn, Document: document}
+	return arn, nil
+}
+
+// GetAccountID returns the mock account ID
+func (m *MockIAMClient) GetAccountID(ctx context.Context) (string, error) {
+	if m.ErrorMode {
+		return "", ErrSimulated
+	}
+	return m.AccountID, nil
+}
+
+// ListOpenIDConnectProviders returns the mock OIDC provider ARNs
+func (m *MockIAMClient) ListOpenIDConnectProviders(ctx context.Context) ([]string, error) {
+	if m.ErrorMode {
+		return nil, ErrSimulated
+	}
+	return m.OIDCProviders, nil
+}
+
+// GetInventory returns the mock roles and managed policies as an inventory
+func (m *MockIAMClient) GetInventory(ctx context.Context) (*aws.Inventory, error) {
+	if m.ErrorMode || m.InventoryErrorMode {
+		return nil, ErrSimulated
+	}
+	return &aws.Inventory{Roles: m.Roles, Policies: m.ManagedPolicies}, nil
+}
+
+// GenerateServiceLastAccessedDetails starts a mock report job for an ARN
+func (m *MockIAMClient) GenerateServiceLastAccessedDetails(ctx context.Context, arn string) (string, error) {
+	if m.ErrorMode {
+		return "", ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobID := "job-" + arn
+	m.jobs[jobID] = arn
+	return jobID, nil
+}
+
+// GetServiceLastAccessedDetails returns the mock report of a job once it has
+// been polled PendingPolls times
+func (m *MockIAMClient) GetServiceLastAccessedDetails(ctx context.Context, jobID string) (*aws.ServiceLastAccessedJob, error) {
+	if m.ErrorMode {
+		return nil, ErrSimulated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	arn, ok := m.jobs[jobID]
+	if !ok {
+		return &aws.ServiceLastAccessedJob{Status: aws.JobStatusFailed, Error: "unknown job"}, nil
+	}
+	if m.polls[jobID] < m.PendingPolls {
+		m.polls[jobID]++
+		return &aws.ServiceLastAccessedJob{Status: aws.JobStatusInProgress}, nil
+	}
+	return &aws.ServiceLastAccessedJob{Status: aws.JobStatusCompleted, Services: m.ServicesLastAccessed[arn]}, nil
+}
+
 // ErrSimulated is a simulated error for testing
 var ErrSimulated = &simulatedError{}
 
@@ -141,3 +446,11 @@ type simulatedError struct{}
 func (e *simulatedError) Error() string {
 	return "simulated error"
 }
+
+// rolePath returns the path of an IAM entity, defaulting to /
+func rolePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
@@ -0,0 +1,293 @@
+package test
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/codebuild"
+	codebuildtypes "github.com/aws/aws-sdk-go-v2/service/codebuild/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	sfntypes "github.com/aws/aws-sdk-go-v2/service/sfn/types"
+
+	"hawkling/pkg/aws"
+)
+
+// MockReferenceScanner implements the ReferenceScanner interface for testing
+type MockReferenceScanner struct {
+	Name       string
+	References []aws.Reference
+	ErrorMode  bool
+}
+
+// Service returns the mock service name
+func (m *MockReferenceScanner) Service() string {
+	return m.Name
+}
+
+// ScanReferences returns the configured references
+func (m *MockReferenceScanner) ScanReferences(ctx context.Context) ([]aws.Reference, error) {
+	if m.ErrorMode {
+		return nil, ErrSimulated
+	}
+	return m.References, nil
+}
+
+// FakeLambdaAPI returns functions with their execution role ARNs, keyed
+// by function ARN, split over two pages
+type FakeLambdaAPI struct {
+	Functions map[string]string
+}
+
+// ListFunctions returns one function per page
+func (f *FakeLambdaAPI) ListFunctions(ctx context.Context, params *lambda.ListFunctionsInput, optFns ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
+	var functions []lambdatypes.FunctionConfiguration
+	for _, arn := range sortedKeys(f.Functions) {
+		functions = append(functions, lambdatypes.FunctionConfiguration{FunctionArn: sdkaws.String(arn), Role: sdkaws.String(f.Functions[arn])})
+	}
+	return pageOf(functions, params.Marker, func(page []lambdatypes.FunctionConfiguration, next *string) *lambda.ListFunctionsOutput {
+		return &lambda.ListFunctionsOutput{Functions: page, NextMarker: next}
+	}), nil
+}
+
+// FakeECSAPI returns task definitions, keyed by ARN
+type FakeECSAPI struct {
+	TaskDefinitions map[string]ecstypes.TaskDefinition
+}
+
+// ListTaskDefinitions returns every task definition ARN
+func (f *FakeECSAPI) ListTaskDefinitions(ctx context.Context, params *ecs.ListTaskDefinitionsInput, optFns ...func(*ecs.Options)) (*ecs.ListTaskDefinitionsOutput, error) {
+	var arns []string
+	for arn := range f.TaskDefinitions {
+		arns = append(arns, arn)
+	}
+	return &ecs.ListTaskDefinitionsOutput{TaskDefinitionArns: arns}, nil
+}
+
+// DescribeTaskDefinition returns a task definition by ARN
+func (f *FakeECSAPI) DescribeTaskDefinition(ctx context.Context, params *ecs.DescribeTaskDefinitionInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTaskDefinitionOutput, error) {
+	definition := f.TaskDefinitions[sdkaws.ToString(params.TaskDefinition)]
+	return &ecs.DescribeTaskDefinitionOutput{TaskDefinition: &definition}, nil
+}
+
+// FakeEC2API returns running instances with their instance profile ARNs,
+// keyed by instance ID, and the roles of each instance profile, keyed by
+// profile name
+type FakeEC2API struct {
+	Instances       map[string]string
+	ProfileRoles    map[string][]string
+	ProfileLookups  int
+	RequestedStates []string
+}
+
+// DescribeInstances returns every instance in one reservation
+func (f *FakeEC2API) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	for _, filter := range params.Filters {
+		if sdkaws.ToString(filter.Name) == "instance-state-name" {
+			f.RequestedStates = filter.Values
+		}
+	}
+
+	reservation := ec2types.Reservation{}
+	for id, profile := range f.Instances {
+		reservation.Instances = append(reservation.Instances, ec2types.Instance{
+			InstanceId:         sdkaws.String(id),
+			IamInstanceProfile: &ec2types.IamInstanceProfile{Arn: sdkaws.String(profile)},
+		})
+	}
+	return &ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{reservation}}, nil
+}
+
+// GetInstanceProfile returns an instance profile with its roles
+func (f *FakeEC2API) GetInstanceProfile(ctx context.Context, params *iam.GetInstanceProfileInput, optFns ...func(*iam.Options)) (*iam.GetInstanceProfileOutput, error) {
+	f.ProfileLookups++
+	profile := &iamtypes.InstanceProfile{InstanceProfileName: params.InstanceProfileName}
+	for _, arn := range f.ProfileRoles[sdkaws.ToString(params.InstanceProfileName)] {
+		profile.Roles = append(profile.Roles, iamtypes.Role{Arn: sdkaws.String(arn)})
+	}
+	return &iam.GetInstanceProfileOutput{InstanceProfile: profile}, nil
+}
+
+// FakeRegionsAPI returns the enabled regions
+type FakeRegionsAPI struct {
+	Regions []string
+}
+
+// DescribeRegions returns the regions, and fails if asked for disabled ones
+func (f *FakeRegionsAPI) DescribeRegions(ctx context.Context, params *ec2.DescribeRegionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error) {
+	if sdkaws.ToBool(params.AllRegions) {
+		return nil, ErrSimulated
+	}
+	output := &ec2.DescribeRegionsOutput{}
+	for _, region := range f.Regions {
+		output.Regions = append(output.Regions, ec2types.Region{RegionName: sdkaws.String(region)})
+	}
+	return output, nil
+}
+
+// FakeStepFunctionsAPI returns state machines with their role ARNs, keyed
+// by state machine ARN
+type FakeStepFunctionsAPI struct {
+	StateMachines map[string]string
+}
+
+// ListStateMachines returns every state machine
+func (f *FakeStepFunctionsAPI) ListStateMachines(ctx context.Context, params *sfn.ListStateMachinesInput, optFns ...func(*sfn.Options)) (*sfn.ListStateMachinesOutput, error) {
+	var machines []sfntypes.StateMachineListItem
+	for arn := range f.StateMachines {
+		machines = append(machines, sfntypes.StateMachineListItem{StateMachineArn: sdkaws.String(arn), Name: sdkaws.String(arn)})
+	}
+	return &sfn.ListStateMachinesOutput{StateMachines: machines}, nil
+}
+
+// DescribeStateMachine returns the role of a state machine
+func (f *FakeStepFunctionsAPI) DescribeStateMachine(ctx context.Context, params *sfn.DescribeStateMachineInput, optFns ...func(*sfn.Options)) (*sfn.DescribeStateMachineOutput, error) {
+	return &sfn.DescribeStateMachineOutput{
+		StateMachineArn: params.StateMachineArn,
+		RoleArn:         sdkaws.String(f.StateMachines[sdkaws.ToString(params.StateMachineArn)]),
+	}, nil
+}
+
+// FakeCodeBuildAPI returns projects with their service role ARNs, keyed by
+// project name
+type FakeCodeBuildAPI struct {
+	Projects   map[string]string
+	BatchSizes []int
+}
+
+// ListProjects returns every project name
+func (f *FakeCodeBuildAPI) ListProjects(ctx context.Context, params *codebuild.ListProjectsInput, optFns ...func(*codebuild.Options)) (*codebuild.ListProjectsOutput, error) {
+	var names []string
+	for name := range f.Projects {
+		names = append(names, name)
+	}
+	return &codebuild.ListProjectsOutput{Projects: names}, nil
+}
+
+// BatchGetProjects returns the named projects
+func (f *FakeCodeBuildAPI) BatchGetProjects(ctx context.Context, params *codebuild.BatchGetProjectsInput, optFns ...func(*codebuild.Options)) (*codebuild.BatchGetProjectsOutput, error) {
+	f.BatchSizes = append(f.BatchSizes, len(params.Names))
+	var projects []codebuildtypes.Project
+	for _, name := range params.Names {
+		projects = append(projects, codebuildtypes.Project{
+			Name:        sdkaws.String(name),
+			Arn:         sdkaws.String("arn:aws:codebuild:us-east-1:123456789012:project/" + name),
+			ServiceRole: sdkaws.String(f.Projects[name]),
+		})
+	}
+	return &codebuild.BatchGetProjectsOutput{Projects: projects}, nil
+}
+
+// FakeEventBridgeAPI returns the rules of each event bus, keyed by bus
+// name, with the role ARNs of their targets, keyed by rule name
+type FakeEventBridgeAPI struct {
+	Rules       map[string][]eventbridgetypes.Rule
+	TargetRoles map[string][]string
+}
+
+// ListEventBuses returns every event bus, one per page
+func (f *FakeEventBridgeAPI) ListEventBuses(ctx context.Context, params *eventbridge.ListEventBusesInput, optFns ...func(*eventbridge.Options)) (*eventbridge.ListEventBusesOutput, error) {
+	var buses []eventbridgetypes.EventBus
+	for _, name := range sortedKeys(f.Rules) {
+		buses = append(buses, eventbridgetypes.EventBus{Name: sdkaws.String(name)})
+	}
+	return pageOf(buses, params.NextToken, func(page []eventbridgetypes.EventBus, next *string) *eventbridge.ListEventBusesOutput {
+		return &eventbridge.ListEventBusesOutput{EventBuses: page, NextToken: next}
+	}), nil
+}
+
+// ListRules returns the rules of an event bus
+func (f *FakeEventBridgeAPI) ListRules(ctx context.Context, params *eventbridge.ListRulesInput, optFns ...func(*eventbridge.Options)) (*eventbridge.ListRulesOutput, error) {
+	return &eventbridge.ListRulesOutput{Rules: f.Rules[sdkaws.ToString(params.EventBusName)]}, nil
+}
+
+// ListTargetsByRule returns the targets of a rule
+func (f *FakeEventBridgeAPI) ListTargetsByRule(ctx context.Context, params *eventbridge.ListTargetsByRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.ListTargetsByRuleOutput, error) {
+	var targets []eventbridgetypes.Target
+	for _, role := range f.TargetRoles[sdkaws.ToString(params.Rule)] {
+		targets = append(targets, eventbridgetypes.Target{Id: sdkaws.String("target"), RoleArn: sdkaws.String(role)})
+	}
+	return &eventbridge.ListTargetsByRuleOutput{Targets: targets}, nil
+}
+
+// FakeGlueAPI returns jobs and crawlers with their roles, keyed by name.
+// Roles given by name are resolved through RoleArns.
+type FakeGlueAPI struct {
+	Jobs        map[string]string
+	Crawlers    map[string]string
+	RoleArns    map[string]string
+	RoleLookups int
+}
+
+// GetJobs returns one job per page
+func (f *FakeGlueAPI) GetJobs(ctx context.Context, params *glue.GetJobsInput, optFns ...func(*glue.Options)) (*glue.GetJobsOutput, error) {
+	var jobs []gluetypes.Job
+	for _, name := range sortedKeys(f.Jobs) {
+		jobs = append(jobs, gluetypes.Job{Name: sdkaws.String(name), Role: sdkaws.String(f.Jobs[name])})
+	}
+	return pageOf(jobs, params.NextToken, func(page []gluetypes.Job, next *string) *glue.GetJobsOutput {
+		return &glue.GetJobsOutput{Jobs: page, NextToken: next}
+	}), nil
+}
+
+// GetCrawlers returns one crawler per page
+func (f *FakeGlueAPI) GetCrawlers(ctx context.Context, params *glue.GetCrawlersInput, optFns ...func(*glue.Options)) (*glue.GetCrawlersOutput, error) {
+	var crawlers []gluetypes.Crawler
+	for _, name := range sortedKeys(f.Crawlers) {
+		crawlers = append(crawlers, gluetypes.Crawler{Name: sdkaws.String(name), Role: sdkaws.String(f.Crawlers[name])})
+	}
+	return pageOf(crawlers, params.NextToken, func(page []gluetypes.Crawler, next *string) *glue.GetCrawlersOutput {
+		return &glue.GetCrawlersOutput{Crawlers: page, NextToken: next}
+	}), nil
+}
+
+// GetRole resolves a role name to its ARN
+func (f *FakeGlueAPI) GetRole(ctx context.Context, params *iam.GetRoleInput, optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error) {
+	f.RoleLookups++
+	arn, ok := f.RoleArns[sdkaws.ToString(params.RoleName)]
+	if !ok {
+		return nil, &iamtypes.NoSuchEntityException{Message: sdkaws.String("role not found")}
+	}
+	return &iam.GetRoleOutput{Role: &iamtypes.Role{RoleName: params.RoleName, Arn: sdkaws.String(arn)}}, nil
+}
+
+// pageOf returns one item per page, so fakes exercise pagination. The
+// token is the index of the page to return.
+func pageOf[T any, O any](items []T, token *string, output func(page []T, next *string) O) O {
+	index := 0
+	if token != nil {
+		index, _ = strconv.Atoi(*token)
+	}
+	if index >= len(items) {
+		return output(nil, nil)
+	}
+
+	var next *string
+	if index+1 < len(items) {
+		next = sdkaws.String(strconv.Itoa(index + 1))
+	}
+	return output(items[index:index+1], next)
+}
+
+// sortedKeys returns the keys of a map in order, so pages are stable
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
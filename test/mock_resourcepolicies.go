@@ -0,0 +1,107 @@
+package test
+
+import (
+	"context"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/smithy-go"
+)
+
+// FakeS3PolicyAPI returns bucket policies keyed by bucket name, one bucket
+// per page. Buckets with an empty policy have none.
+type FakeS3PolicyAPI struct {
+	Policies map[string]string
+	Regions  map[string]string
+
+	// RequestedRegions records the region each policy was read in
+	RequestedRegions map[string]string
+}
+
+// ListBuckets returns one bucket per page
+func (f *FakeS3PolicyAPI) ListBuckets(ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	var buckets []s3types.Bucket
+	for _, name := range sortedKeys(f.Policies) {
+		bucket := s3types.Bucket{Name: sdkaws.String(name)}
+		if region, ok := f.Regions[name]; ok {
+			bucket.BucketRegion = sdkaws.String(region)
+		}
+		buckets = append(buckets, bucket)
+	}
+	return pageOf(buckets, params.ContinuationToken, func(page []s3types.Bucket, next *string) *s3.ListBucketsOutput {
+		return &s3.ListBucketsOutput{Buckets: page, ContinuationToken: next}
+	}), nil
+}
+
+// GetBucketPolicy returns the policy of a bucket, or NoSuchBucketPolicy
+func (f *FakeS3PolicyAPI) GetBucketPolicy(ctx context.Context, params *s3.GetBucketPolicyInput, optFns ...func(*s3.Options)) (*s3.GetBucketPolicyOutput, error) {
+	options := s3.Options{Region: "us-east-1"}
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	if f.RequestedRegions == nil {
+		f.RequestedRegions = make(map[string]string)
+	}
+	f.RequestedRegions[*params.Bucket] = options.Region
+
+	policy := f.Policies[*params.Bucket]
+	if policy == "" {
+		return nil, &smithy.GenericAPIError{Code: "NoSuchBucketPolicy", Message: "The bucket policy does not exist"}
+	}
+	return &s3.GetBucketPolicyOutput{Policy: sdkaws.String(policy)}, nil
+}
+
+// FakeSQSPolicyAPI returns queue policies keyed by queue URL
+type FakeSQSPolicyAPI struct {
+	Policies map[string]string
+}
+
+// ListQueues returns one queue per page
+func (f *FakeSQSPolicyAPI) ListQueues(ctx context.Context, params *sqs.ListQueuesInput, optFns ...func(*sqs.Options)) (*sqs.ListQueuesOutput, error) {
+	return pageOf(sortedKeys(f.Policies), params.NextToken, func(page []string, next *string) *sqs.ListQueuesOutput {
+		return &sqs.ListQueuesOutput{QueueUrls: page, NextToken: next}
+	}), nil
+}
+
+// GetQueueAttributes returns the policy of a queue, omitting empty ones
+func (f *FakeSQSPolicyAPI) GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	attributes := map[string]string{}
+	if policy := f.Policies[*params.QueueUrl]; policy != "" {
+		attributes["Policy"] = policy
+	}
+	return &sqs.GetQueueAttributesOutput{Attributes: attributes}, nil
+}
+
+// FakeECRPolicyAPI returns repository policies keyed by repository name.
+// Repositories with an empty policy have none.
+type FakeECRPolicyAPI struct {
+	Policies map[string]string
+}
+
+// DescribeRepositories returns one repository per page
+func (f *FakeECRPolicyAPI) DescribeRepositories(ctx context.Context, params *ecr.DescribeRepositoriesInput, optFns ...func(*ecr.Options)) (*ecr.DescribeRepositoriesOutput, error) {
+	var repositories []ecrtypes.Repository
+	for _, name := range sortedKeys(f.Policies) {
+		repositories = append(repositories, ecrtypes.Repository{
+			RepositoryName: sdkaws.String(name),
+			RepositoryArn:  sdkaws.String("arn:aws:ecr:us-east-1:123456789012:repository/" + name),
+		})
+	}
+	return pageOf(repositories, params.NextToken, func(page []ecrtypes.Repository, next *string) *ecr.DescribeRepositoriesOutput {
+		return &ecr.DescribeRepositoriesOutput{Repositories: page, NextToken: next}
+	}), nil
+}
+
+// GetRepositoryPolicy returns the policy of a repository, or
+// RepositoryPolicyNotFoundException
+func (f *FakeECRPolicyAPI) GetRepositoryPolicy(ctx context.Context, params *ecr.GetRepositoryPolicyInput, optFns ...func(*ecr.Options)) (*ecr.GetRepositoryPolicyOutput, error) {
+	policy := f.Policies[*params.RepositoryName]
+	if policy == "" {
+		return nil, &ecrtypes.RepositoryPolicyNotFoundException{Message: sdkaws.String("Repository policy does not exist")}
+	}
+	return &ecr.GetRepositoryPolicyOutput{PolicyText: sdkaws.String(policy)}, nil
+}
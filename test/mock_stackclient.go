@@ -0,0 +1,32 @@
+package test
+
+import (
+	"context"
+	"sync"
+)
+
+// MockStackClient implements the StackClient interface for testing
+type MockStackClient struct {
+	// Statuses holds the status of each existing stack, keyed by name or ID
+	Statuses map[string]string
+
+	// Failing holds the stacks whose lookup fails
+	Failing map[string]bool
+
+	// Lookups records the stacks looked up, in order
+	Lookups []string
+
+	mu sync.Mutex
+}
+
+// GetStackStatus returns the configured status, or "" for unknown stacks
+func (m *MockStackClient) GetStackStatus(ctx context.Context, stack string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Lookups = append(m.Lookups, stack)
+	if m.Failing[stack] {
+		return "", ErrSimulated
+	}
+	return m.Statuses[stack], nil
+}
@@ -0,0 +1,33 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	"hawkling/pkg/aws"
+)
+
+// MockTrailClient implements the TrailClient interface for testing
+type MockTrailClient struct {
+	Events    []aws.CreateRoleEvent
+	ErrorMode bool
+
+	// Since records the start time of the last lookup
+	Since time.Time
+}
+
+// LookupCreateRoleEvents returns the configured events since the given time
+func (m *MockTrailClient) LookupCreateRoleEvents(ctx context.Context, since time.Time) ([]aws.CreateRoleEvent, error) {
+	if m.ErrorMode {
+		return nil, ErrSimulated
+	}
+
+	m.Since = since
+	var events []aws.CreateRoleEvent
+	for _, event := range m.Events {
+		if !event.EventTime.Before(since) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,132 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+)
+
+const (
+	eksProviderArn    = "arn:aws:iam::123456789012:oidc-provider/oidc.eks.us-east-1.amazonaws.com/id/ABCDEF"
+	gitHubProviderArn = "arn:aws:iam::123456789012:oidc-provider/token.actions.githubusercontent.com"
+)
+
+func webIdentityTrustPolicy(providerArn, condition string) string {
+	return `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Federated":"` + providerArn +
+		`"},"Action":"sts:AssumeRoleWithWebIdentity"` + condition + `}]}`
+}
+
+func TestAuditOIDCTrust(t *testing.T) {
+	roles := []aws.Role{
+		{
+			Name: "IRSARole",
+			TrustPolicy: webIdentityTrustPolicy(eksProviderArn,
+				`,"Condition":{"StringEquals":{"oidc.eks.us-east-1.amazonaws.com/id/ABCDEF:sub":"system:serviceaccount:payments:api"}}`),
+		},
+		{
+			Name:        "DeployAnyRepo",
+			TrustPolicy: webIdentityTrustPolicy(gitHubProviderArn, `,"Condition":{"StringLike":{"token.actions.githubusercontent.com:sub":"repo:example/*"}}`),
+		},
+		{
+			Name:        "DeployAnyBranch",
+			TrustPolicy: webIdentityTrustPolicy(gitHubProviderArn, `,"Condition":{"StringLike":{"token.actions.githubusercontent.com:sub":"repo:example/app:*"}}`),
+		},
+		{
+			Name:        "DeployNoSub",
+			TrustPolicy: webIdentityTrustPolicy(gitHubProviderArn, `,"Condition":{"StringEquals":{"token.actions.githubusercontent.com:aud":"sts.amazonaws.com"}}`),
+		},
+		{
+			Name:        "DeployMain",
+			TrustPolicy: webIdentityTrustPolicy(gitHubProviderArn, `,"Condition":{"StringEquals":{"token.actions.githubusercontent.com:sub":"repo:example/app:ref:refs/heads/main"}}`),
+		},
+		{
+			Name:        "ServiceRole",
+			TrustPolicy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}]}`,
+		},
+	}
+
+	// Only the GitHub provider exists; the EKS cluster was deleted
+	groups := audit.AuditOIDCTrust(roles, []string{gitHubProviderArn})
+
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 provider groups, got %d", len(groups))
+	}
+
+	eks := groups[0]
+	if eks.Kind != audit.ProviderKindEKS || eks.Exists {
+		t.Errorf("expected missing EKS provider first, got %+v", eks)
+	}
+	if len(eks.Roles) != 1 || len(eks.Roles[0].ServiceAccounts) != 1 || eks.Roles[0].ServiceAccounts[0].String() != "payments/api" {
+		t.Errorf("expected service account payments/api, got %+v", eks.Roles)
+	}
+	if !strings.Contains(strings.Join(eks.Roles[0].Issues, ";"), "does not exist") {
+		t.Errorf("expected missing provider issue, got %v", eks.Roles[0].Issues)
+	}
+
+	github := groups[1]
+	if github.Kind != audit.ProviderKindGitHub || !github.Exists {
+		t.Errorf("expected existing GitHub provider, got %+v", github)
+	}
+
+	expectedIssues := map[string]string{
+		"DeployAnyRepo":   "wildcard repository",
+		"DeployAnyBranch": "wildcard branch",
+		"DeployNoSub":     "no sub condition",
+		"DeployMain":      "",
+	}
+	for _, trust := range github.Roles {
+		expected, ok := expectedIssues[trust.RoleName]
+		if !ok {
+			t.Errorf("unexpected role %s", trust.RoleName)
+			continue
+		}
+		issues := strings.Join(trust.Issues, ";")
+		if expected == "" && issues != "" {
+			t.Errorf("expected no issues for %s, got %s", trust.RoleName, issues)
+		}
+		if expected != "" && !strings.Contains(issues, expected) {
+			t.Errorf("expected %q issue for %s, got %s", expected, trust.RoleName, issues)
+		}
+	}
+}
+
+func TestAuditOIDCTrustNegatedAndMergedStatements(t *testing.T) {
+	twoStatements := `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Principal":{"Federated":"` + gitHubProviderArn + `"},"Action":"sts:AssumeRoleWithWebIdentity",
+		 "Condition":{"StringEquals":{"token.actions.githubusercontent.com:sub":"repo:example/app:ref:refs/heads/main"}}},
+		{"Effect":"Allow","Principal":{"Federated":"` + gitHubProviderArn + `"},"Action":"sts:AssumeRoleWithWebIdentity",
+		 "Condition":{"StringEquals":{"token.actions.githubusercontent.com:sub":"repo:example/app:environment:prod"}}}]}`
+	roles := []aws.Role{
+		{
+			Name:        "DeployExceptFork",
+			Arn:         "arn:aws:iam::123456789012:role/DeployExceptFork",
+			TrustPolicy: webIdentityTrustPolicy(gitHubProviderArn, `,"Condition":{"StringNotLike":{"token.actions.githubusercontent.com:sub":"repo:fork/*"}}`),
+		},
+		{
+			Name:        "DeployTwoStatements",
+			Arn:         "arn:aws:iam::123456789012:role/DeployTwoStatements",
+			TrustPolicy: twoStatements,
+		},
+	}
+
+	groups := audit.AuditOIDCTrust(roles, []string{gitHubProviderArn})
+	if len(groups) != 1 || len(groups[0].Roles) != 2 {
+		t.Fatalf("expected one entry per role, got %+v", groups)
+	}
+
+	for _, trust := range groups[0].Roles {
+		issues := strings.Join(trust.Issues, ";")
+		switch trust.RoleName {
+		case "DeployExceptFork":
+			if !strings.Contains(issues, "no sub condition") || strings.Contains(issues, "wildcard") {
+				t.Errorf("expected a negated condition to leave the role unrestricted, got %s", issues)
+			}
+		case "DeployTwoStatements":
+			if len(trust.Subjects) != 2 || issues != "" {
+				t.Errorf("expected the subjects of both statements and no issues, got %+v", trust)
+			}
+		}
+	}
+}
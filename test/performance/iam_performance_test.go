@@ -108,6 +108,137 @@ func (m *DelayedMockIAMClient) DeleteInlinePolicies(ctx context.Context, roleNam
 	return nil
 }
 
+// AttachRolePolicy mocks attaching a managed policy to a role
+func (m *DelayedMockIAMClient) AttachRolePolicy(ctx context.Context, roleName, policyArn string) error {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return nil
+}
+
+// DetachRolePolicy mocks detaching a single managed policy from a role
+func (m *DelayedMockIAMClient) DetachRolePolicy(ctx context.Context, roleName, policyArn string) error {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return nil
+}
+
+// UpdateAssumeRolePolicy mocks replacing the trust policy of a role
+func (m *DelayedMockIAMClient) UpdateAssumeRolePolicy(ctx context.Context, roleName, document string) error {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return nil
+}
+
+// GetRolePolicy mocks getting an inline policy of a role
+func (m *DelayedMockIAMClient) GetRolePolicy(ctx context.Context, roleName, policyName string) (string, error) {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return "", nil
+}
+
+// PutRolePolicy mocks putting an inline policy on a role
+func (m *DelayedMockIAMClient) PutRolePolicy(ctx context.Context, roleName, policyName, document string) error {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return nil
+}
+
+// DeleteRolePolicy mocks deleting an inline policy from a role
+func (m *DelayedMockIAMClient) DeleteRolePolicy(ctx context.Context, roleName, policyName string) error {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return nil
+}
+
+// PutRolePermissionsBoundary mocks setting the permissions boundary of a role
+func (m *DelayedMockIAMClient) PutRolePermissionsBoundary(ctx context.Context, roleName, boundaryArn string) error {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return nil
+}
+
+// DeleteRolePermissionsBoundary mocks removing the permissions boundary of a role
+func (m *DelayedMockIAMClient) DeleteRolePermissionsBoundary(ctx context.Context, roleName string) error {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return nil
+}
+
+// CreateRole mocks creating a role
+func (m *DelayedMockIAMClient) CreateRole(ctx context.Context, role aws.Role) error {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return nil
+}
+
+// CreateInstanceProfile mocks creating an instance profile
+func (m *DelayedMockIAMClient) CreateInstanceProfile(ctx context.Context, name, path string) error {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return nil
+}
+
+// AddRoleToInstanceProfile mocks adding a role to an instance profile
+func (m *DelayedMockIAMClient) AddRoleToInstanceProfile(ctx context.Context, profileName, roleName string) error {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return nil
+}
+
+// ListRoleTags mocks listing the tags of an untagged role
+func (m *DelayedMockIAMClient) ListRoleTags(ctx context.Context, roleName string) (map[string]string, error) {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return nil, nil
+}
+
+// GetPolicy mocks getting a managed policy that does not exist
+func (m *DelayedMockIAMClient) GetPolicy(ctx context.Context, policyArn string) (*aws.Policy, error) {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return nil, nil
+}
+
+// CreatePolicy mocks creating a managed policy
+func (m *DelayedMockIAMClient) CreatePolicy(ctx context.Context, name, path, document string) (string, error) {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return "arn:aws:iam::123456789012:policy/" + name, nil
+}
+
+// GetAccountID returns the mock account ID
+func (m *DelayedMockIAMClient) GetAccountID(ctx context.Context) (string, error) {
+	return "123456789012", nil
+}
+
+// ListOpenIDConnectProviders mocks listing OIDC providers
+func (m *DelayedMockIAMClient) ListOpenIDConnectProviders(ctx context.Context) ([]string, error) {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return nil, nil
+}
+
+// GetInventory returns the mock roles as an inventory with simulated API delay
+func (m *DelayedMockIAMClient) GetInventory(ctx context.Context) (*aws.Inventory, error) {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return &aws.Inventory{Roles: m.Roles}, nil
+}
+
+// GenerateServiceLastAccessedDetails mocks starting a report with simulated API delay
+func (m *DelayedMockIAMClient) GenerateServiceLastAccessedDetails(ctx context.Context, arn string) (string, error) {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return "job-" + arn, nil
+}
+
+// GetServiceLastAccessedDetails mocks a completed report with simulated API delay
+func (m *DelayedMockIAMClient) GetServiceLastAccessedDetails(ctx context.Context, jobID string) (*aws.ServiceLastAccessedJob, error) {
+	// Simulate API delay
+	time.Sleep(m.APIDelay)
+	return &aws.ServiceLastAccessedJob{Status: aws.JobStatusCompleted}, nil
+}
+
 // ListAllRolesSequential gets a list of all roles and their last used times sequentially
 func ListAllRolesSequential(ctx context.Context, client aws.IAMClient) ([]aws.Role, error) {
 	// Get all roles
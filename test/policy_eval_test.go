@@ -0,0 +1,202 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/policy"
+)
+
+func mustParse(t *testing.T, document string) *policy.Document {
+	t.Helper()
+	doc, err := policy.Parse(document)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return doc
+}
+
+func TestPolicyEvaluation(t *testing.T) {
+	identity := `{
+	  "Version": "2012-10-17",
+	  "Statement": [
+	    {"Sid": "S3Read", "Effect": "Allow", "Action": "s3:Get*", "Resource": "arn:aws:s3:::data/*"},
+	    {"Sid": "NoSecrets", "Effect": "Deny", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::data/secret/*"},
+	    {"Sid": "AllButIAM", "Effect": "Allow", "NotAction": ["iam:*", "kms:*", "ssm:*"], "NotResource": "arn:aws:ec2:*:*:instance/prod-*"},
+	    {"Sid": "OfficeOnly", "Effect": "Allow", "Action": "kms:Decrypt", "Resource": "*",
+	     "Condition": {"IpAddress": {"aws:SourceIp": "10.0.0.0/8"}, "Bool": {"aws:MultiFactorAuthPresent": true}}},
+	    {"Sid": "TaggedOnly", "Effect": "Allow", "Action": "ssm:GetParameter", "Resource": "*",
+	     "Condition": {"ForAnyValue:StringLike": {"aws:TagKeys": ["team-*"]}}}
+	  ]
+	}`
+	boundary := `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": ["s3:*", "kms:*", "ssm:*", "ec2:*"], "Resource": "*"}]}`
+
+	set := policy.PolicySet{
+		Identity: []policy.Source{{Name: "AppPolicy", Document: mustParse(t, identity)}},
+		Boundary: &policy.Source{Name: "Boundary", Document: mustParse(t, boundary)},
+	}
+
+	tests := []struct {
+		name     string
+		request  policy.Request
+		expected policy.Decision
+	}{
+		{
+			name:     "wildcard action is allowed",
+			request:  policy.Request{Action: "s3:GetObject", Resource: "arn:aws:s3:::data/report.csv"},
+			expected: policy.DecisionAllowed,
+		},
+		{
+			name:     "actions are matched case-insensitively",
+			request:  policy.Request{Action: "S3:getobject", Resource: "arn:aws:s3:::data/report.csv"},
+			expected: policy.DecisionAllowed,
+		},
+		{
+			name:     "explicit deny wins",
+			request:  policy.Request{Action: "s3:GetObject", Resource: "arn:aws:s3:::data/secret/key"},
+			expected: policy.DecisionExplicitDeny,
+		},
+		{
+			name:     "NotAction excludes IAM",
+			request:  policy.Request{Action: "iam:CreateUser", Resource: "*"},
+			expected: policy.DecisionImplicitDeny,
+		},
+		{
+			name:     "NotResource excludes production instances",
+			request:  policy.Request{Action: "ec2:TerminateInstances", Resource: "arn:aws:ec2:us-east-1:123456789012:instance/prod-web"},
+			expected: policy.DecisionImplicitDeny,
+		},
+		{
+			name:     "permissions boundary limits NotAction",
+			request:  policy.Request{Action: "lambda:InvokeFunction", Resource: "*"},
+			expected: policy.DecisionImplicitDeny,
+		},
+		{
+			name: "conditions satisfied",
+			request: policy.Request{Action: "kms:Decrypt", Resource: "arn:aws:kms:us-east-1:123456789012:key/abc", Context: map[string][]string{
+				"aws:SourceIp":               {"10.1.2.3"},
+				"aws:MultiFactorAuthPresent": {"true"},
+			}},
+			expected: policy.DecisionAllowed,
+		},
+		{
+			name:     "missing condition keys do not match",
+			request:  policy.Request{Action: "kms:Decrypt", Resource: "arn:aws:kms:us-east-1:123456789012:key/abc"},
+			expected: policy.DecisionImplicitDeny,
+		},
+		{
+			name: "ForAnyValue matches one of several values",
+			request: policy.Request{Action: "ssm:GetParameter", Resource: "*", Context: map[string][]string{
+				"aws:TagKeys": {"owner", "team-payments"},
+			}},
+			expected: policy.DecisionAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := set.Evaluate(test.request)
+			if result.Decision != test.expected {
+				t.Errorf("expected %s, got %s (matches: %+v)", test.expected, result.Decision, result.Matches)
+			}
+		})
+	}
+
+	result := set.Evaluate(policy.Request{Action: "lambda:InvokeFunction", Resource: "*"})
+	if !result.DeniedByBoundary {
+		t.Errorf("expected the boundary to be reported as the reason for the deny")
+	}
+
+	result = set.Evaluate(policy.Request{Action: "kms:Decrypt", Resource: "*"})
+	if len(result.Unresolved) != 2 {
+		t.Errorf("expected 2 unresolved condition keys, got %v", result.Unresolved)
+	}
+}
+
+func TestScopedDenyWithoutResource(t *testing.T) {
+	identity := `{
+	  "Version": "2012-10-17",
+	  "Statement": [
+	    {"Effect": "Allow", "Action": ["iam:AttachRolePolicy", "iam:PutRolePolicy"], "Resource": "*"},
+	    {"Effect": "Deny", "Action": "iam:AttachRolePolicy", "Resource": "arn:aws:iam::123456789012:role/admin"},
+	    {"Effect": "Deny", "Action": "iam:AttachRolePolicy", "NotResource": "arn:aws:iam::123456789012:role/app-*"},
+	    {"Effect": "Deny", "Action": "iam:PutRolePolicy", "Resource": "*"}
+	  ]
+	}`
+	set := policy.PolicySet{Identity: []policy.Source{{Name: "AppPolicy", Document: mustParse(t, identity)}}}
+
+	// The action is still allowed on the roles the Deny does not name
+	if result := set.Evaluate(policy.Request{Action: "iam:AttachRolePolicy"}); result.Decision != policy.DecisionAllowed {
+		t.Errorf("expected a scoped Deny not to deny a request without a resource, got %s (matches: %+v)", result.Decision, result.Matches)
+	}
+	if result := set.Evaluate(policy.Request{Action: "iam:AttachRolePolicy", Resource: "arn:aws:iam::123456789012:role/admin"}); result.Decision != policy.DecisionExplicitDeny {
+		t.Errorf("expected the scoped Deny to deny its resource, got %s", result.Decision)
+	}
+	if result := set.Evaluate(policy.Request{Action: "iam:PutRolePolicy"}); result.Decision != policy.DecisionExplicitDeny {
+		t.Errorf("expected a Deny on every resource to deny a request without a resource, got %s", result.Decision)
+	}
+}
+
+func TestMatchWildcard(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		value    string
+		expected bool
+	}{
+		{"*", "anything", true},
+		{"s3:Get*", "s3:GetObject", true},
+		{"s3:Get*", "s3:PutObject", false},
+		{"arn:aws:s3:::bucket/*/logs", "arn:aws:s3:::bucket/a/b/logs", true},
+		{"ec2:Describe?nstances", "ec2:DescribeInstances", true},
+		{"abc", "abcd", false},
+	}
+
+	for _, test := range tests {
+		if result := policy.MatchWildcard(test.pattern, test.value, false); result != test.expected {
+			t.Errorf("MatchWildcard(%q, %q) = %v, expected %v", test.pattern, test.value, result, test.expected)
+		}
+	}
+}
+
+func TestCanCommandWithSavedInventory(t *testing.T) {
+	inventory := &aws.Inventory{
+		Roles: []aws.Role{
+			{
+				Name:             "AppRole",
+				Arn:              "arn:aws:iam::123456789012:role/AppRole",
+				AttachedPolicies: []aws.Policy{{Name: "ReadOnly", Arn: "arn:aws:iam::123456789012:policy/ReadOnly"}},
+			},
+		},
+		Policies: map[string]aws.Policy{
+			"arn:aws:iam::123456789012:policy/ReadOnly": {
+				Name:     "ReadOnly",
+				Arn:      "arn:aws:iam::123456789012:policy/ReadOnly",
+				Document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:Get*","Resource":"*"}]}`,
+			},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	if err := aws.SaveInventory(path, inventory); err != nil {
+		t.Fatalf("SaveInventory() error = %v", err)
+	}
+
+	originalStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	err := commands.NewCanCommand("", "", "AppRole", "s3:GetObject", "", commands.CanOptions{Inventory: path, Output: "json"}).Execute(context.Background())
+	missingErr := commands.NewCanCommand("", "", "MissingRole", "s3:GetObject", "", commands.CanOptions{Inventory: path, Output: "json"}).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+
+	if err != nil {
+		t.Fatalf("Command execution failed: %v", err)
+	}
+	if missingErr == nil {
+		t.Errorf("expected an error for a role missing from the inventory")
+	}
+}
@@ -0,0 +1,92 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+)
+
+func inlineRole(name string, lastUsed *time.Time, trustPolicy, document string) aws.Role {
+	return aws.Role{
+		Name:           name,
+		Arn:            "arn:aws:iam::123456789012:role/" + name,
+		LastUsed:       lastUsed,
+		TrustPolicy:    trustPolicy,
+		InlinePolicies: []aws.Policy{{Name: name + "Policy", IsInline: true, Document: document}},
+	}
+}
+
+func allowDocument(actions, resource string) string {
+	return `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":` + actions + `,"Resource":"` + resource + `"}]}`
+}
+
+func TestAuditPrivilegeEscalation(t *testing.T) {
+	recent := time.Now().AddDate(0, 0, -1)
+	accountTrust := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::123456789012:root"},"Action":"sts:AssumeRole"}]}`
+	serviceTrust := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"lambda.amazonaws.com"},"Action":"sts:AssumeRole"}]}`
+
+	inventory := &aws.Inventory{
+		Roles: []aws.Role{
+			inlineRole("LambdaDeployer", &recent, serviceTrust, allowDocument(`["iam:PassRole","lambda:CreateFunction"]`, "*")),
+			inlineRole("ScopedPassRole", nil, serviceTrust, `{"Version":"2012-10-17","Statement":[
+				{"Effect":"Allow","Action":"iam:PassRole","Resource":"arn:aws:iam::123456789012:role/app-*"},
+				{"Effect":"Allow","Action":"ec2:RunInstances","Resource":"*"}]}`),
+			inlineRole("PolicyWriter", nil, serviceTrust, allowDocument(`"iam:PutRolePolicy"`, "arn:aws:iam::123456789012:role/*")),
+			inlineRole("Hopper", nil, serviceTrust, allowDocument(`"sts:AssumeRole"`, "arn:aws:iam::123456789012:role/Middle")),
+			inlineRole("Middle", &recent, accountTrust, allowDocument(`"sts:AssumeRole"`, "arn:aws:iam::123456789012:role/Admin")),
+			inlineRole("Admin", &recent, accountTrust, allowDocument(`"*"`, "*")),
+			inlineRole("ReadOnly", nil, serviceTrust, allowDocument(`["s3:Get*","s3:List*"]`, "*")),
+		},
+	}
+
+	findings, skipped := audit.AuditPrivilegeEscalation(inventory, 90)
+	if len(skipped) != 0 {
+		t.Fatalf("expected no skipped roles, got %+v", skipped)
+	}
+
+	found := make(map[string]audit.EscalationFinding)
+	for _, finding := range findings {
+		found[finding.RoleName+"/"+finding.Pattern] = finding
+	}
+
+	if f, ok := found["LambdaDeployer/"+audit.PatternPassRoleLambda]; !ok || f.Unused {
+		t.Errorf("expected a used LambdaDeployer passrole-lambda finding, got %+v", findings)
+	}
+	if _, ok := found["ScopedPassRole/"+audit.PatternPassRoleEC2]; ok {
+		t.Errorf("PassRole scoped to app-* roles should not be reported")
+	}
+	if f, ok := found["PolicyWriter/"+audit.PatternPutRolePolicy]; !ok || !f.Unused {
+		t.Errorf("expected an unused PolicyWriter put-role-policy finding, got %+v", findings)
+	}
+
+	chain, ok := found["Hopper/"+audit.PatternAssumeRoleChain]
+	if !ok {
+		t.Fatalf("expected an assume-role chain for Hopper, got %+v", findings)
+	}
+	if strings.Join(chain.Path, ">") != "Hopper>Middle>Admin" {
+		t.Errorf("expected chain Hopper>Middle>Admin, got %v", chain.Path)
+	}
+	if _, ok := found["Middle/"+audit.PatternAssumeRoleChain]; !ok {
+		t.Errorf("expected an assume-role chain for Middle")
+	}
+
+	for _, finding := range findings {
+		if finding.RoleName == "Admin" || finding.RoleName == "ReadOnly" {
+			t.Errorf("unexpected finding %+v", finding)
+		}
+	}
+
+	// Unused roles are ranked first
+	seenUsed := false
+	for _, finding := range findings {
+		if !finding.Unused {
+			seenUsed = true
+		} else if seenUsed {
+			t.Errorf("expected unused findings before used ones, got %+v", findings)
+			break
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/aws"
+)
+
+const (
+	appRoleArn    = "arn:aws:iam::123456789012:role/App"
+	workerRoleArn = "arn:aws:iam::123456789012:role/Worker"
+)
+
+func scanAll(t *testing.T, scanner aws.ReferenceScanner) []aws.Reference {
+	t.Helper()
+	references, err := scanner.ScanReferences(context.Background())
+	if err != nil {
+		t.Fatalf("%s scan failed: %v", scanner.Service(), err)
+	}
+	return references
+}
+
+func TestLambdaScanner(t *testing.T) {
+	references := scanAll(t, aws.NewLambdaScanner(&FakeLambdaAPI{Functions: map[string]string{
+		"arn:aws:lambda:us-east-1:123456789012:function:a": appRoleArn,
+		"arn:aws:lambda:us-east-1:123456789012:function:b": workerRoleArn,
+	}}))
+
+	if len(references) != 2 || references[0].RoleArn != appRoleArn || references[1].Usage != "execution role" {
+		t.Errorf("expected both pages of functions, got %+v", references)
+	}
+}
+
+func TestECSScanner(t *testing.T) {
+	references := scanAll(t, aws.NewECSScanner(&FakeECSAPI{TaskDefinitions: map[string]ecstypes.TaskDefinition{
+		"arn:aws:ecs:us-east-1:123456789012:task-definition/app:3": {
+			TaskRoleArn:      sdkaws.String(appRoleArn),
+			ExecutionRoleArn: sdkaws.String(workerRoleArn),
+		},
+	}}))
+
+	if len(references) != 2 || references[0].Usage != "task role" || references[1].RoleArn != workerRoleArn {
+		t.Errorf("expected the task and execution roles, got %+v", references)
+	}
+}
+
+func TestEC2Scanner(t *testing.T) {
+	fake := &FakeEC2API{
+		Instances: map[string]string{
+			"i-1": "arn:aws:iam::123456789012:instance-profile/web/App",
+			"i-2": "arn:aws:iam::123456789012:instance-profile/web/App",
+		},
+		ProfileRoles: map[string][]string{"App": {appRoleArn}},
+	}
+	references := scanAll(t, aws.NewEC2Scanner(fake, fake))
+
+	if len(references) != 2 || references[0].RoleArn != appRoleArn || references[0].Usage != "instance profile App" {
+		t.Errorf("expected both instances to reference App, got %+v", references)
+	}
+	if fake.ProfileLookups != 1 {
+		t.Errorf("expected the instance profile to be resolved once, got %d lookups", fake.ProfileLookups)
+	}
+	if len(fake.RequestedStates) != 1 || fake.RequestedStates[0] != "running" {
+		t.Errorf("expected only running instances to be described, got %v", fake.RequestedStates)
+	}
+}
+
+func TestStepFunctionsScanner(t *testing.T) {
+	references := scanAll(t, aws.NewStepFunctionsScanner(&FakeStepFunctionsAPI{StateMachines: map[string]string{
+		"arn:aws:states:us-east-1:123456789012:stateMachine:flow": appRoleArn,
+	}}))
+
+	if len(references) != 1 || references[0].RoleArn != appRoleArn || references[0].Service != "states" {
+		t.Errorf("expected the state machine role, got %+v", references)
+	}
+}
+
+func TestCodeBuildScanner(t *testing.T) {
+	fake := &FakeCodeBuildAPI{Projects: make(map[string]string)}
+	for i := 0; i < 150; i++ {
+		fake.Projects[fmt.Sprintf("project-%03d", i)] = workerRoleArn
+	}
+	fake.Projects["build"] = appRoleArn
+
+	references := scanAll(t, aws.NewCodeBuildScanner(fake))
+
+	if len(references) != 151 {
+		t.Errorf("expected 151 project references, got %d", len(references))
+	}
+	if len(fake.BatchSizes) != 2 || fake.BatchSizes[0] != 100 || fake.BatchSizes[1] != 51 {
+		t.Errorf("expected projects to be fetched in batches of 100, got %v", fake.BatchSizes)
+	}
+}
+
+func TestEventBridgeScanner(t *testing.T) {
+	references := scanAll(t, aws.NewEventBridgeScanner(&FakeEventBridgeAPI{
+		Rules: map[string][]eventbridgetypes.Rule{
+			"default": {{Name: sdkaws.String("nightly"), Arn: sdkaws.String("arn:aws:events:us-east-1:123456789012:rule/nightly")}},
+			"orders": {{
+				Name:    sdkaws.String("forward"),
+				Arn:     sdkaws.String("arn:aws:events:us-east-1:123456789012:rule/orders/forward"),
+				RoleArn: sdkaws.String(workerRoleArn),
+			}},
+		},
+		TargetRoles: map[string][]string{"nightly": {appRoleArn}},
+	}))
+
+	if len(references) != 2 {
+		t.Fatalf("expected a target role and a rule role across both buses, got %+v", references)
+	}
+	if references[0].RoleArn != appRoleArn || references[0].Usage != "role of target target" {
+		t.Errorf("expected the target role of the nightly rule, got %+v", references[0])
+	}
+	if references[1].RoleArn != workerRoleArn || references[1].Usage != "rule role" {
+		t.Errorf("expected the rule role of the forward rule, got %+v", references[1])
+	}
+}
+
+func TestGlueScanner(t *testing.T) {
+	fake := &FakeGlueAPI{
+		Jobs:     map[string]string{"etl": "Worker", "load": "Worker", "gone": "Deleted"},
+		Crawlers: map[string]string{"raw": appRoleArn},
+		RoleArns: map[string]string{"Worker": workerRoleArn},
+	}
+	references := scanAll(t, aws.NewGlueScanner(fake, fake))
+
+	if len(references) != 3 || references[0].RoleArn != workerRoleArn || references[0].Resource != "job/etl" {
+		t.Fatalf("expected role names to be resolved and unknown roles skipped, got %+v", references)
+	}
+	if references[2].RoleArn != appRoleArn || references[2].Usage != "crawler role" {
+		t.Errorf("expected the crawler role, got %+v", references[2])
+	}
+	if fake.RoleLookups != 2 {
+		t.Errorf("expected each role name to be resolved once, got %d lookups", fake.RoleLookups)
+	}
+}
+
+func TestScanReferences(t *testing.T) {
+	scanners := []aws.ReferenceScanner{
+		&MockReferenceScanner{Name: "lambda", References: []aws.Reference{
+			{RoleArn: appRoleArn, Service: "lambda", Resource: "fn", Usage: "execution role"},
+			{RoleArn: "arn:aws:iam::123456789012:role/Other", Service: "lambda", Resource: "other", Usage: "execution role"},
+		}},
+		&MockReferenceScanner{Name: "ecs", ErrorMode: true},
+	}
+
+	references, failures := aws.ScanReferences(context.Background(), scanners, []string{appRoleArn, workerRoleArn})
+
+	if len(references) != 1 || len(references[appRoleArn]) != 1 {
+		t.Errorf("expected only references to the requested roles, got %+v", references)
+	}
+	if len(failures) != 1 || failures["ecs"] == nil {
+		t.Errorf("expected the ecs scan to fail, got %v", failures)
+	}
+}
+
+func TestDeleteAndPruneBlockReferencedRoles(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.Roles = []aws.Role{
+		{Name: "App", Arn: appRoleArn},
+		{Name: "Worker", Arn: workerRoleArn},
+	}
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	scanner := &MockReferenceScanner{Name: "lambda", References: []aws.Reference{
+		{RoleArn: appRoleArn, Service: "lambda", Resource: "arn:aws:lambda:us-east-1:123456789012:function:app", Usage: "execution role"},
+	}}
+	aws.SetTestReferenceScanners(scanner)
+	defer aws.ClearTestReferenceScanners()
+	aws.SetTestResourcePolicyScanners()
+	defer aws.ClearTestResourcePolicyScanners()
+
+	run := func(cmd interface{ Execute(context.Context) error }) (string, error) {
+		originalStdout, originalStderr := os.Stdout, os.Stderr
+		r, w, _ := os.Pipe()
+		os.Stdout, os.Stderr = w, w
+		err := cmd.Execute(context.Background())
+		w.Close()
+		os.Stdout, os.Stderr = originalStdout, originalStderr
+		out, _ := io.ReadAll(r)
+		return string(out), err
+	}
+
+	out, err := run(commands.NewDeleteCommand("", "", "App", commands.DeleteOptions{Force: true}))
+	if err == nil || !strings.Contains(out, "lambda arn:aws:lambda:us-east-1:123456789012:function:app (execution role)") {
+		t.Errorf("expected deleting App to be blocked by its function, got %v:\n%s", err, out)
+	}
+	if len(mockClient.DeletedRoles) != 0 {
+		t.Fatalf("expected no roles to be deleted, got %v", mockClient.DeletedRoles)
+	}
+
+	if _, err := run(commands.NewDeleteCommand("", "", "App", commands.DeleteOptions{Force: true, IgnoreReferences: true})); err != nil {
+		t.Errorf("expected --ignore-references to allow the deletion, got %v", err)
+	}
+
+	mockClient.Roles = []aws.Role{{Name: "App", Arn: appRoleArn}, {Name: "Worker", Arn: workerRoleArn}}
+	mockClient.DeletedRoles = nil
+	out, err = run(commands.NewPruneCommand("", "", commands.PruneOptions{Force: true}))
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(mockClient.DeletedRoles) != 1 || mockClient.DeletedRoles[0] != "Worker" {
+		t.Errorf("expected prune to delete only Worker, got %v:\n%s", mockClient.DeletedRoles, out)
+	}
+
+	scanner.ErrorMode = true
+	if _, err := run(commands.NewPruneCommand("", "", commands.PruneOptions{Force: true})); err == nil {
+		t.Error("expected prune to stop when a reference scan fails")
+	}
+}
+
+func TestListEnabledRegions(t *testing.T) {
+	regions, err := aws.ListEnabledRegions(context.Background(), &FakeRegionsAPI{Regions: []string{"us-east-1", "eu-west-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(regions, ",") != "eu-west-1,us-east-1" {
+		t.Errorf("expected the enabled regions in order, got %v", regions)
+	}
+}
+
+func TestDeleteChecksEveryRegion(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.Roles = []aws.Role{{Name: "App", Arn: appRoleArn}}
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	failing := &MockReferenceScanner{Name: "ecs", ErrorMode: true}
+	aws.SetTestReferenceScanners(
+		aws.RegionScanner{ReferenceScanner: &MockReferenceScanner{Name: "lambda"}, Region: "us-east-1"},
+		aws.RegionScanner{ReferenceScanner: &MockReferenceScanner{Name: "lambda", References: []aws.Reference{
+			{RoleArn: appRoleArn, Service: "lambda", Resource: "arn:aws:lambda:eu-west-1:123456789012:function:app", Usage: "execution role"},
+		}}, Region: "eu-west-1"},
+		aws.RegionScanner{ReferenceScanner: failing, Region: "ap-south-1"},
+		aws.RegionScanner{ReferenceScanner: failing, Region: "sa-east-1"},
+	)
+	defer aws.ClearTestReferenceScanners()
+	aws.SetTestResourcePolicyScanners()
+	defer aws.ClearTestResourcePolicyScanners()
+
+	run := func(options commands.DeleteOptions) (string, error) {
+		originalStdout, originalStderr := os.Stdout, os.Stderr
+		r, w, _ := os.Pipe()
+		os.Stdout, os.Stderr = w, w
+		err := commands.NewDeleteCommand("", "us-east-1", "App", options).Execute(context.Background())
+		w.Close()
+		os.Stdout, os.Stderr = originalStdout, originalStderr
+		out, _ := io.ReadAll(r)
+		return string(out), err
+	}
+
+	// The failures of every region are reported
+	out, err := run(commands.DeleteOptions{Force: true})
+	if err == nil || !strings.Contains(err.Error(), "ap-south-1: ") || !strings.Contains(err.Error(), "sa-east-1: ") {
+		t.Errorf("expected the failed regions to be named, got %v:\n%s", err, out)
+	}
+
+	// The function in the second region keeps the role
+	failing.ErrorMode = false
+	out, err = run(commands.DeleteOptions{Force: true})
+	if err == nil || !strings.Contains(out, "function:app in eu-west-1 (execution role)") {
+		t.Errorf("expected deleting App to be blocked by its function in eu-west-1, got %v:\n%s", err, out)
+	}
+	if len(mockClient.DeletedRoles) != 0 {
+		t.Fatalf("expected no roles to be deleted, got %v", mockClient.DeletedRoles)
+	}
+
+	// Limiting the scan to the first region warns that others were not checked
+	out, err = run(commands.DeleteOptions{Force: true, ScanRegions: []string{"us-east-1"}})
+	if err != nil {
+		t.Fatalf("expected the deletion to go ahead, got %v", err)
+	}
+	if !strings.Contains(out, "Only regions us-east-1 were checked") {
+		t.Errorf("expected a warning about the regions not checked, got:\n%s", out)
+	}
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/aws"
+)
+
+const grantToApp = `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {"Effect": "Allow", "Principal": {"AWS": ["arn:aws:iam::123456789012:role/App", "arn:aws:iam::123456789012:role/App"]}, "Action": "s3:GetObject", "Resource": "*"},
+    {"Effect": "Deny", "Principal": "*", "Action": "*", "Resource": "*",
+     "Condition": {"ArnNotEquals": {"aws:PrincipalArn": "arn:aws:iam::123456789012:role/Worker"}}}
+  ]
+}`
+
+func TestS3PolicyScanner(t *testing.T) {
+	fake := &FakeS3PolicyAPI{
+		Policies: map[string]string{"assets": grantToApp, "logs": ""},
+		Regions:  map[string]string{"assets": "eu-west-1"},
+	}
+	references := scanAll(t, aws.NewS3PolicyScanner(fake))
+
+	if len(references) != 2 {
+		t.Fatalf("expected App and Worker once each, skipping the bucket without a policy, got %+v", references)
+	}
+	if references[0].RoleArn != appRoleArn || references[0].Resource != "arn:aws:s3:::assets" || references[0].Usage != "bucket policy" {
+		t.Errorf("expected App to be granted by the assets bucket, got %+v", references[0])
+	}
+	if references[1].RoleArn != workerRoleArn {
+		t.Errorf("expected Worker to be found in the condition, got %+v", references[1])
+	}
+	if fake.RequestedRegions["assets"] != "eu-west-1" {
+		t.Errorf("expected the policy to be read in the bucket's region, got %v", fake.RequestedRegions)
+	}
+}
+
+func TestSQSPolicyScanner(t *testing.T) {
+	references := scanAll(t, aws.NewSQSPolicyScanner(&FakeSQSPolicyAPI{Policies: map[string]string{
+		"https://sqs.us-east-1.amazonaws.com/123456789012/orders": grantToApp,
+		"https://sqs.us-east-1.amazonaws.com/123456789012/empty":  "",
+	}}))
+
+	if len(references) != 2 || references[0].Resource != "https://sqs.us-east-1.amazonaws.com/123456789012/orders" {
+		t.Errorf("expected the orders queue to grant both roles, got %+v", references)
+	}
+}
+
+func TestECRPolicyScanner(t *testing.T) {
+	references := scanAll(t, aws.NewECRPolicyScanner(&FakeECRPolicyAPI{Policies: map[string]string{
+		"app":  grantToApp,
+		"base": "",
+	}}))
+
+	if len(references) != 2 || references[0].Resource != "arn:aws:ecr:us-east-1:123456789012:repository/app" || references[0].Usage != "repository policy" {
+		t.Errorf("expected the app repository to grant both roles, got %+v", references)
+	}
+}
+
+func TestPruneReportsResourcePolicies(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.Roles = []aws.Role{{Name: "App", Arn: appRoleArn}}
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	aws.SetTestReferenceScanners()
+	defer aws.ClearTestReferenceScanners()
+	aws.SetTestResourcePolicyScanners(
+		&MockReferenceScanner{Name: "s3", References: []aws.Reference{
+			{RoleArn: appRoleArn, Service: "s3", Resource: "arn:aws:s3:::assets", Usage: "bucket policy"},
+		}},
+		&MockReferenceScanner{Name: "kms", ErrorMode: true},
+	)
+	defer aws.ClearTestResourcePolicyScanners()
+
+	originalStdout, originalStderr := os.Stdout, os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stdout, os.Stderr = w, w
+	err := commands.NewPruneCommand("", "", commands.PruneOptions{DryRun: true}).Execute(context.Background())
+	w.Close()
+	os.Stdout, os.Stderr = originalStdout, originalStderr
+	out, _ := io.ReadAll(r)
+
+	if err != nil {
+		t.Fatalf("expected a failed resource policy scan to only warn, got %v", err)
+	}
+	if !strings.Contains(string(out), "Role App is granted access by 1 resource policies") ||
+		!strings.Contains(string(out), "s3 arn:aws:s3:::assets (bucket policy)") {
+		t.Errorf("expected the bucket policy grant to be reported, got:\n%s", out)
+	}
+	if !strings.Contains(string(out), "Warning: Could not check resource policies: kms:") {
+		t.Errorf("expected a warning about the failed kms scan, got:\n%s", out)
+	}
+}
+
+func TestPruneReportsResourcePoliciesInEveryRegion(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.Roles = []aws.Role{{Name: "App", Arn: appRoleArn}}
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	aws.SetTestReferenceScanners()
+	defer aws.ClearTestReferenceScanners()
+	queue := func(region string) aws.ReferenceScanner {
+		return aws.RegionScanner{ReferenceScanner: &MockReferenceScanner{Name: "sqs", References: []aws.Reference{
+			{RoleArn: appRoleArn, Service: "sqs", Resource: "arn:aws:sqs:" + region + ":123456789012:jobs", Usage: "queue policy"},
+		}}, Region: region}
+	}
+	aws.SetTestResourcePolicyScanners(queue("us-east-1"), queue("eu-west-1"))
+	defer aws.ClearTestResourcePolicyScanners()
+
+	run := func(options commands.PruneOptions) string {
+		originalStdout, originalStderr := os.Stdout, os.Stderr
+		r, w, _ := os.Pipe()
+		os.Stdout, os.Stderr = w, w
+		err := commands.NewPruneCommand("", "us-east-1", options).Execute(context.Background())
+		w.Close()
+		os.Stdout, os.Stderr = originalStdout, originalStderr
+		out, _ := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("Prune failed: %v", err)
+		}
+		return string(out)
+	}
+
+	out := run(commands.PruneOptions{DryRun: true})
+	if !strings.Contains(out, "Role App is granted access by 2 resource policies") ||
+		!strings.Contains(out, "arn:aws:sqs:eu-west-1:123456789012:jobs in eu-west-1 (queue policy)") {
+		t.Errorf("expected the queue policies of both regions to be reported, got:\n%s", out)
+	}
+
+	out = run(commands.PruneOptions{DryRun: true, ScanRegions: []string{"us-east-1"}})
+	if !strings.Contains(out, "Role App is granted access by 1 resource policies") || strings.Contains(out, "eu-west-1") {
+		t.Errorf("expected only the queue policy of the scanned region, got:\n%s", out)
+	}
+}
@@ -0,0 +1,162 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/backup"
+)
+
+func TestRevokeSessionsCommand(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.Roles[0].TrustPolicy = vendorTrustPolicy
+	mockClient.Roles[1].TrustPolicy = vendorOnlyTrustPolicy
+	mockClient.Roles[2].TrustPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}]}`
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	backupDir := t.TempDir()
+	options := commands.RevokeSessionsOptions{
+		Trusting:  "111122223333",
+		Force:     true,
+		BackupDir: backupDir,
+	}
+
+	originalStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	err := commands.NewRevokeSessionsCommand("test-profile", "us-west-2", nil, options).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+	if err != nil {
+		t.Fatalf("Command execution failed: %v", err)
+	}
+
+	for _, name := range []string{"ActiveRole", "InactiveRole"} {
+		document, ok := mockClient.InlinePolicies[name][commands.RevokeSessionsPolicyName]
+		if !ok {
+			t.Fatalf("expected revocation policy on role %s", name)
+		}
+		if !strings.Contains(document, "aws:TokenIssueTime") {
+			t.Errorf("expected aws:TokenIssueTime condition, got:\n%s", document)
+		}
+	}
+	if _, ok := mockClient.InlinePolicies["NeverUsedRole"]; ok {
+		t.Errorf("role not trusting the principal should not be revoked")
+	}
+
+	records, _ := filepath.Glob(filepath.Join(backupDir, "session-revocation", "*.json"))
+	if len(records) != 1 {
+		t.Fatalf("expected 1 revocation record, got %d", len(records))
+	}
+
+	// Clean up the deny policies using the record
+	_, w, _ = os.Pipe()
+	os.Stdout = w
+	err = commands.NewRevokeSessionsCleanupCommand("test-profile", "us-west-2", records[0], commands.DeleteOptions{Force: true}).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+	if err != nil {
+		t.Fatalf("Cleanup execution failed: %v", err)
+	}
+
+	for _, name := range []string{"ActiveRole", "InactiveRole"} {
+		if _, ok := mockClient.InlinePolicies[name][commands.RevokeSessionsPolicyName]; ok {
+			t.Errorf("expected revocation policy to be removed from role %s", name)
+		}
+	}
+}
+
+func TestRevokeSessionsRequiresSelection(t *testing.T) {
+	aws.SetTestClient(NewMockIAMClient())
+	defer aws.ClearTestClient()
+
+	err := commands.NewRevokeSessionsCommand("test-profile", "us-west-2", nil, commands.RevokeSessionsOptions{}).Execute(context.Background())
+	if err == nil {
+		t.Fatal("expected an error when no roles are selected")
+	}
+}
+
+func TestRevokeSessionsCleanupKeepsNewerRevocation(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	earlier := `{"Version":"2012-10-17","Statement":[{"Effect":"Deny","Action":"*","Resource":"*","Condition":{"DateLessThan":{"aws:TokenIssueTime":"2025-01-01T00:00:00Z"}}}]}`
+	mockClient.InlinePolicies["ActiveRole"] = map[string]string{commands.RevokeSessionsPolicyName: earlier}
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	backupDir := t.TempDir()
+	originalStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	err := commands.NewRevokeSessionsCommand("test-profile", "us-west-2", []string{"ActiveRole", "InactiveRole"}, commands.RevokeSessionsOptions{
+		Force:     true,
+		BackupDir: backupDir,
+	}).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+	if err != nil {
+		t.Fatalf("Command execution failed: %v", err)
+	}
+
+	records, _ := filepath.Glob(filepath.Join(backupDir, "session-revocation", "*.json"))
+	if len(records) != 1 {
+		t.Fatalf("expected 1 revocation record, got %d", len(records))
+	}
+	var record commands.SessionRevocation
+	if err := backup.LoadJSON(records[0], &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Previous["ActiveRole"] != earlier || record.Document == "" {
+		t.Fatalf("expected the record to keep the replaced policy and the new one, got %+v", record)
+	}
+	latest := mockClient.InlinePolicies["ActiveRole"][commands.RevokeSessionsPolicyName]
+
+	// Cleaning up the earlier revocation must not lift the newer one
+	olderRecord, err := backup.SaveJSON(t.TempDir(), "session-revocation", "revocation", commands.SessionRevocation{
+		PolicyName: commands.RevokeSessionsPolicyName,
+		RevokedAt:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Roles:      []string{"ActiveRole", "InactiveRole"},
+		Document:   earlier,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, w, _ = os.Pipe()
+	os.Stdout = w
+	err = commands.NewRevokeSessionsCleanupCommand("test-profile", "us-west-2", olderRecord, commands.DeleteOptions{Force: true}).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+	if err != nil {
+		t.Fatalf("Cleanup execution failed: %v", err)
+	}
+	for _, name := range []string{"ActiveRole", "InactiveRole"} {
+		if _, ok := mockClient.InlinePolicies[name][commands.RevokeSessionsPolicyName]; !ok {
+			t.Errorf("expected the newer revocation of role %s to be kept", name)
+		}
+	}
+	if mockClient.InlinePolicies["ActiveRole"][commands.RevokeSessionsPolicyName] != latest {
+		t.Errorf("expected the newer revocation of ActiveRole to be unchanged")
+	}
+
+	// Cleaning up the newer revocation puts the earlier policy back
+	_, w, _ = os.Pipe()
+	os.Stdout = w
+	err = commands.NewRevokeSessionsCleanupCommand("test-profile", "us-west-2", records[0], commands.DeleteOptions{Force: true}).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+	if err != nil {
+		t.Fatalf("Cleanup execution failed: %v", err)
+	}
+	if document := mockClient.InlinePolicies["ActiveRole"][commands.RevokeSessionsPolicyName]; document != earlier {
+		t.Errorf("expected the earlier policy to be restored, got:\n%s", document)
+	}
+	if _, ok := mockClient.InlinePolicies["InactiveRole"][commands.RevokeSessionsPolicyName]; ok {
+		t.Errorf("expected the revocation policy to be removed from InactiveRole")
+	}
+}
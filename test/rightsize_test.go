@@ -0,0 +1,86 @@
+package test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+)
+
+func rightsizeServices(now time.Time) []aws.ServiceLastAccessed {
+	recent := now.AddDate(0, 0, -3)
+	stale := now.AddDate(0, 0, -200)
+	return []aws.ServiceLastAccessed{
+		{ServiceNamespace: "sqs", ServiceName: "Amazon SQS"},
+		{ServiceNamespace: "s3", ServiceName: "Amazon S3", LastAccessed: &recent, Actions: []aws.ActionLastAccessed{
+			{ActionName: "PutObject"},
+			{ActionName: "GetObject", LastAccessed: &recent},
+			{ActionName: "DeleteObject", LastAccessed: &stale},
+		}},
+		{ServiceNamespace: "dynamodb", ServiceName: "Amazon DynamoDB", LastAccessed: &stale},
+		{ServiceNamespace: "logs", ServiceName: "Amazon CloudWatch Logs", LastAccessed: &recent},
+	}
+}
+
+func TestRightsize(t *testing.T) {
+	now := time.Now()
+	report := audit.Rightsize(aws.Role{Name: "App"}, rightsizeServices(now), 90, now)
+
+	if report.ServicesGranted != 4 || len(report.UnusedServices) != 3 {
+		t.Fatalf("expected 3 of 4 services to be reported, got %+v", report)
+	}
+	if report.UnusedServices[0].ServiceNamespace != "dynamodb" || !report.UnusedServices[0].Unused {
+		t.Errorf("expected dynamodb to be unused, got %+v", report.UnusedServices[0])
+	}
+	s3 := report.UnusedServices[1]
+	if s3.ServiceNamespace != "s3" || s3.Unused || len(s3.UnusedActions) != 2 || s3.UnusedActions[0] != "DeleteObject" || s3.UnusedActions[1] != "PutObject" {
+		t.Errorf("expected s3 to be used with DeleteObject and PutObject unused, got %+v", s3)
+	}
+	if report.UnusedServices[2].ServiceNamespace != "sqs" || !report.UnusedServices[2].Unused {
+		t.Errorf("expected sqs to be unused, got %+v", report.UnusedServices[2])
+	}
+}
+
+func TestRightsizeCommandPollsJobs(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.PendingPolls = 2
+	mockClient.ServicesLastAccessed["arn:aws:iam::123456789012:role/ActiveRole"] = rightsizeServices(time.Now())
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	run := func(roleName string, timeout time.Duration) error {
+		originalStdout, originalStderr := os.Stdout, os.Stderr
+		_, w, _ := os.Pipe()
+		os.Stdout, os.Stderr = w, w
+		err := commands.NewRightsizeCommand("", "", roleName, commands.RightsizeOptions{
+			FilterOptions: commands.FilterOptions{Days: 90},
+			Output:        "json",
+			PollInterval:  time.Millisecond,
+			PollTimeout:   timeout,
+		}).Execute(context.Background())
+		w.Close()
+		os.Stdout, os.Stderr = originalStdout, originalStderr
+		return err
+	}
+
+	// Both used roles are polled until their jobs complete
+	if err := run("", time.Second); err != nil {
+		t.Fatalf("Command execution failed: %v", err)
+	}
+	if len(mockClient.jobs) != 2 {
+		t.Errorf("expected jobs for the 2 used roles, got %v", mockClient.jobs)
+	}
+
+	if err := run("MissingRole", time.Second); err == nil {
+		t.Errorf("expected an unknown role to be rejected")
+	}
+
+	mockClient.PendingPolls = 1 << 30
+	if err := run("InactiveRole", 20*time.Millisecond); err == nil {
+		t.Errorf("expected a job that never completes to time out")
+	}
+}
@@ -0,0 +1,101 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+)
+
+func TestAssessRisk(t *testing.T) {
+	now := time.Now()
+	created := now.AddDate(-2, 0, 0)
+	serviceTrust := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}]}`
+	externalTrust := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::999988887777:root"},"Action":"sts:AssumeRole"}]}`
+
+	readOnly := inlineRole("NeverUsedReadOnly", nil, serviceTrust, allowDocument(`["s3:GetObject"]`, "*"))
+	readOnly.CreateDate = created
+	readOnly.PermissionsBoundary = "arn:aws:iam::123456789012:policy/Boundary"
+
+	admin := inlineRole("NeverUsedAdmin", nil, externalTrust, allowDocument(`"*"`, "*"))
+	admin.CreateDate = created
+
+	recent := now.AddDate(0, 0, -1)
+	active := inlineRole("ActiveWriter", &recent, serviceTrust, allowDocument(`"s3:*"`, "*"))
+	active.CreateDate = created
+
+	inventory := &aws.Inventory{
+		Roles: []aws.Role{readOnly, admin, active},
+		Policies: map[string]aws.Policy{
+			"arn:aws:iam::123456789012:policy/Boundary": {
+				Name:     "Boundary",
+				Document: allowDocument(`"s3:*"`, "*"),
+			},
+		},
+	}
+
+	roles := []aws.Role{
+		{Name: readOnly.Name, Arn: readOnly.Arn, CreateDate: created},
+		{Name: admin.Name, Arn: admin.Arn, CreateDate: created},
+		{Name: active.Name, Arn: active.Arn, CreateDate: created, LastUsed: &recent},
+		{Name: "Unknown", Arn: "arn:aws:iam::123456789012:role/Unknown"},
+	}
+
+	skipped := audit.ScoreRoles(inventory, roles, now)
+	if len(skipped) != 1 || skipped[0].RoleName != "Unknown" {
+		t.Errorf("expected Unknown to be skipped, got %+v", skipped)
+	}
+
+	scores := make(map[string]aws.Role)
+	for _, role := range roles {
+		scores[role.Name] = role
+	}
+
+	if scores["NeverUsedAdmin"].RiskScore != 95 {
+		t.Errorf("expected a never used admin trusted by another account to score 95, got %d (%v)",
+			scores["NeverUsedAdmin"].RiskScore, scores["NeverUsedAdmin"].RiskFactors)
+	}
+	factors := strings.Join(scores["NeverUsedAdmin"].RiskFactors, ";")
+	for _, expected := range []string{"never used", "administrator access", "external account 999988887777", "no permissions boundary"} {
+		if !strings.Contains(factors, expected) {
+			t.Errorf("expected factor %q, got %s", expected, factors)
+		}
+	}
+
+	if scores["NeverUsedReadOnly"].RiskScore >= scores["NeverUsedAdmin"].RiskScore {
+		t.Errorf("expected the read-only role to score below the admin role, got %d", scores["NeverUsedReadOnly"].RiskScore)
+	}
+	if scores["ActiveWriter"].RiskScore >= scores["NeverUsedReadOnly"].RiskScore {
+		t.Errorf("expected the active role to score below the never used role, got %d", scores["ActiveWriter"].RiskScore)
+	}
+
+	if err := aws.SortRoles(roles, aws.SortByRisk); err != nil {
+		t.Fatalf("SortRoles() error = %v", err)
+	}
+	if roles[0].Name != "NeverUsedAdmin" || roles[1].Name != "NeverUsedReadOnly" {
+		t.Errorf("expected riskiest roles first, got %s, %s", roles[0].Name, roles[1].Name)
+	}
+
+	if err := aws.SortRoles(roles, "size"); err == nil {
+		t.Errorf("expected an error for an unsupported sort order")
+	}
+}
+
+func TestSortRolesByLastUsed(t *testing.T) {
+	now := time.Now()
+	roles := []aws.Role{
+		{Name: "Recent", LastUsed: timePtr(now.AddDate(0, 0, -1))},
+		{Name: "Never"},
+		{Name: "Old", LastUsed: timePtr(now.AddDate(-1, 0, 0))},
+	}
+
+	if err := aws.SortRoles(roles, aws.SortByLastUsed); err != nil {
+		t.Fatalf("SortRoles() error = %v", err)
+	}
+
+	if roles[0].Name != "Never" || roles[1].Name != "Old" || roles[2].Name != "Recent" {
+		t.Errorf("expected Never, Old, Recent, got %s, %s, %s", roles[0].Name, roles[1].Name, roles[2].Name)
+	}
+}
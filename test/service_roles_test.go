@@ -0,0 +1,186 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/catalog"
+)
+
+func serviceRole(name, path string) aws.Role {
+	return aws.Role{Name: name, Path: path, Arn: "arn:aws:iam::123456789012:role" + path + name}
+}
+
+func TestServiceRoleCatalogMatch(t *testing.T) {
+	patterns, err := catalog.BuiltinServiceRoles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, path   string
+		pattern      string
+		owner        string
+		pathMatches  bool
+		shouldNotHit bool
+	}{
+		{name: "checkout-role-ab12cd34", path: "/service-role/", pattern: "lambda-console", owner: "checkout", pathMatches: true},
+		{name: "StepFunctions-orders-role-0a1b2c3d", path: "/service-role/", pattern: "stepfunctions-console", owner: "orders", pathMatches: true},
+		{name: "codebuild-web-app-service-role", path: "/", pattern: "codebuild-console", owner: "web-app"},
+		{name: "AWSGlueServiceRole-raw", path: "/service-role/", pattern: "glue-console", pathMatches: true},
+		{name: "Amazon_EventBridge_Invoke_Lambda_123456", path: "/service-role/", pattern: "eventbridge-console", pathMatches: true},
+		{name: "checkout-role-prod", path: "/service-role/", shouldNotHit: true},
+		{name: "app-deployer", path: "/", shouldNotHit: true},
+	}
+	for _, tt := range tests {
+		match, ok := patterns.Match(tt.name, tt.path)
+		if ok == tt.shouldNotHit {
+			t.Errorf("%s: expected match %v, got %v", tt.name, !tt.shouldNotHit, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if match.Pattern.Name != tt.pattern || match.OwnerName != tt.owner || match.PathMatches != tt.pathMatches {
+			t.Errorf("%s: expected pattern %s, owner %q and path match %v, got %+v", tt.name, tt.pattern, tt.owner, tt.pathMatches, match)
+		}
+	}
+}
+
+func TestLoadServiceRolesMergesLocalCatalog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service-roles.json")
+	local := `{"Updated":"2099-01-01","Patterns":[
+		{"Name":"codebuild-console","Service":"codebuild","Owner":"project","Pattern":"cb-(?P<owner>.+)","Path":"/"},
+		{"Name":"team-lambda","Service":"lambda","Owner":"function","Pattern":"lambda-(?P<owner>.+)-exec","Path":"/"}]}`
+	if err := os.WriteFile(path, []byte(local), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	patterns, err := catalog.LoadServiceRoles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patterns.Updated != "2099-01-01" || patterns.Patterns[0].Name != "codebuild-console" || patterns.Patterns[1].Name != "team-lambda" {
+		t.Fatalf("expected local patterns first, got %+v", patterns.Patterns)
+	}
+	if match, ok := patterns.Match("codebuild-web-service-role", "/service-role/"); ok {
+		t.Errorf("expected the replaced built-in pattern to be gone, got %+v", match)
+	}
+	if match, ok := patterns.Match("lambda-resize-exec", "/"); !ok || match.OwnerName != "resize" {
+		t.Errorf("expected the local pattern to match, got %+v", match)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"Patterns":[{"Name":"bad","Service":"lambda","Pattern":"("}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := catalog.LoadServiceRoles(path); err == nil {
+		t.Error("expected an invalid pattern to be rejected")
+	}
+}
+
+func TestClassifyServiceRoles(t *testing.T) {
+	patterns, err := catalog.BuiltinServiceRoles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastUsed := time.Now().Add(-24 * time.Hour)
+	used := serviceRole("api-role-aaaa1111", "/service-role/")
+	orphaned := serviceRole("old-role-bbbb2222", "/service-role/")
+	orphaned.LastUsed = &lastUsed
+	moved := serviceRole("codebuild-web-service-role", "/")
+	unknown := serviceRole("AWSGlueServiceRole-raw", "/service-role/")
+
+	findings := audit.FindServiceRoles([]aws.Role{used, orphaned, moved, unknown, serviceRole("admin", "/")}, patterns)
+	if len(findings) != 4 {
+		t.Fatalf("expected four service roles, got %+v", findings)
+	}
+
+	references := map[string][]aws.Reference{
+		used.Arn: {{RoleArn: used.Arn, Service: "lambda", Resource: "arn:aws:lambda:us-east-1:123456789012:function:api", Usage: "execution role"}},
+	}
+	audit.ClassifyServiceRoles(findings, []string{"lambda", "codebuild"}, nil, references, nil)
+
+	byName := make(map[string]audit.ServiceRoleFinding)
+	for _, finding := range findings {
+		byName[finding.RoleName] = finding
+	}
+	if finding := byName[orphaned.Name]; finding.Status != audit.ServiceRoleOrphaned || finding.Confidence != audit.ConfidenceHigh || finding.Owner != "function old" {
+		t.Errorf("expected a recently used role with no function to be a high confidence orphan, got %+v", finding)
+	}
+	if finding := byName[moved.Name]; finding.Status != audit.ServiceRoleOrphaned || finding.Confidence != audit.ConfidenceMedium {
+		t.Errorf("expected a role outside the console path to be a medium confidence orphan, got %+v", finding)
+	}
+	if finding := byName[used.Name]; finding.Status != audit.ServiceRoleInUse || len(finding.UsedBy) != 1 {
+		t.Errorf("expected the role used by a function to be in use, got %+v", finding)
+	}
+	if finding := byName[unknown.Name]; finding.Status != audit.ServiceRoleUnknown {
+		t.Errorf("expected a role of an unscanned service to be unknown, got %+v", finding)
+	}
+	if findings[0].Status != audit.ServiceRoleOrphaned || findings[1].Status != audit.ServiceRoleOrphaned {
+		t.Errorf("expected orphans first, got %+v", findings)
+	}
+
+	audit.ClassifyServiceRoles(findings, []string{"lambda", "codebuild", "glue"}, nil, references, map[string]error{"glue": ErrSimulated})
+	for _, finding := range findings {
+		if finding.RoleName == unknown.Name && finding.Status != audit.ServiceRoleUnknown {
+			t.Errorf("expected a failed scan to leave the role unknown, got %+v", finding)
+		}
+		if finding.RoleName == orphaned.Name && finding.Confidence != audit.ConfidenceMedium {
+			t.Errorf("expected a failed scan of another service to lower confidence, got %+v", finding)
+		}
+	}
+
+	audit.ClassifyServiceRoles(findings, []string{"lambda", "codebuild"}, []string{"us-east-1"}, references, nil)
+	for _, finding := range findings {
+		if finding.RoleName == orphaned.Name && (finding.Confidence != audit.ConfidenceMedium || !strings.Contains(finding.Reason, "only region us-east-1 scanned")) {
+			t.Errorf("expected a scan of one region to lower confidence, got %+v", finding)
+		}
+	}
+}
+
+func TestAuditServiceRolesCommand(t *testing.T) {
+	used := serviceRole("api-role-aaaa1111", "/service-role/")
+	orphaned := serviceRole("old-role-bbbb2222", "/service-role/")
+
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	if err := aws.SaveInventory(path, &aws.Inventory{Roles: []aws.Role{used, orphaned, serviceRole("admin", "/")}}); err != nil {
+		t.Fatal(err)
+	}
+
+	aws.SetTestReferenceScanners(&MockReferenceScanner{Name: "lambda", References: []aws.Reference{
+		{RoleArn: used.Arn, Service: "lambda", Resource: "function:api", Usage: "execution role"},
+	}})
+	defer aws.ClearTestReferenceScanners()
+
+	originalStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := commands.NewAuditServiceRolesCommand("", "", commands.AuditOptions{
+		Inventory:    path,
+		Output:       "json",
+		OnlyOrphaned: true,
+	}).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+	out, _ := io.ReadAll(r)
+
+	if err != nil {
+		t.Fatalf("Audit failed: %v", err)
+	}
+	var findings []audit.ServiceRoleFinding
+	if err := json.Unmarshal(out, &findings); err != nil {
+		t.Fatalf("expected JSON output, got %v:\n%s", err, out)
+	}
+	if len(findings) != 1 || findings[0].RoleName != orphaned.Name || !strings.Contains(findings[0].Reason, "no lambda resource") {
+		t.Errorf("expected only the orphaned role, got %+v", findings)
+	}
+}
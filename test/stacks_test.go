@@ -0,0 +1,144 @@
+package test
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/aws"
+)
+
+const liveStackID = "arn:aws:cloudformation:eu-west-1:123456789012:stack/live/1a2b"
+
+func stackTagged(name, arn, stack, stackID string) aws.Role {
+	role := aws.Role{Name: name, Arn: arn, Tags: map[string]string{aws.StackNameTag: stack}}
+	if stackID != "" {
+		role.Tags[aws.StackIDTag] = stackID
+	}
+	return role
+}
+
+func TestAttachStackOwnership(t *testing.T) {
+	client := &MockStackClient{
+		Statuses: map[string]string{liveStackID: "UPDATE_COMPLETE"},
+		Failing:  map[string]bool{"broken": true},
+	}
+	roles := []aws.Role{
+		stackTagged("LiveA", "arn:aws:iam::123456789012:role/LiveA", "live", liveStackID),
+		stackTagged("LiveB", "arn:aws:iam::123456789012:role/LiveB", "live", liveStackID),
+		stackTagged("Gone", "arn:aws:iam::123456789012:role/Gone", "gone", ""),
+		stackTagged("Broken", "arn:aws:iam::123456789012:role/Broken", "broken", ""),
+		{Name: "Plain", Arn: "arn:aws:iam::123456789012:role/Plain"},
+	}
+
+	failures := aws.AttachStackOwnership(context.Background(), client, roles)
+
+	if len(client.Lookups) != 3 {
+		t.Errorf("expected each stack to be looked up once, by ID when tagged, got %v", client.Lookups)
+	}
+	if roles[0].Stack == nil || roles[0].Stack.String() != "managed by stack live" || roles[0].Stack.StackStatus != "UPDATE_COMPLETE" {
+		t.Errorf("expected LiveA to be managed by the live stack, got %+v", roles[0].Stack)
+	}
+	if roles[2].Stack == nil || roles[2].Stack.String() != "orphaned from stack gone" {
+		t.Errorf("expected Gone to be orphaned, got %+v", roles[2].Stack)
+	}
+	if roles[3].Stack == nil || roles[3].Stack.Orphaned || failures["broken"] == nil {
+		t.Errorf("expected a failed lookup to be treated as managed and reported, got %+v, %v", roles[3].Stack, failures)
+	}
+	if roles[4].Stack != nil {
+		t.Errorf("expected an untagged role to have no stack, got %+v", roles[4].Stack)
+	}
+}
+
+func TestPruneSkipsStackManagedRoles(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.Roles = []aws.Role{
+		{Name: "Plain", Arn: "arn:aws:iam::123456789012:role/Plain"},
+		stackTagged("Managed", "arn:aws:iam::123456789012:role/Managed", "live", liveStackID),
+		stackTagged("Orphan", "arn:aws:iam::123456789012:role/Orphan", "gone", ""),
+	}
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	aws.SetTestStackClient(&MockStackClient{Statuses: map[string]string{liveStackID: "CREATE_COMPLETE"}})
+	defer aws.ClearTestStackClient()
+	aws.SetTestReferenceScanners()
+	defer aws.ClearTestReferenceScanners()
+	aws.SetTestResourcePolicyScanners()
+	defer aws.ClearTestResourcePolicyScanners()
+
+	run := func(options commands.PruneOptions) string {
+		originalStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		err := commands.NewPruneCommand("", "", options).Execute(context.Background())
+		w.Close()
+		os.Stdout = originalStdout
+		out, _ := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("Prune failed: %v", err)
+		}
+		return string(out)
+	}
+
+	out := run(commands.PruneOptions{DryRun: true})
+	if !strings.Contains(out, "1. Orphan") || !strings.Contains(out, "[orphaned from stack gone]") {
+		t.Errorf("expected the orphaned role first in the plan, got:\n%s", out)
+	}
+	if !strings.Contains(out, "[managed by stack live]") || !strings.Contains(out, "Skipping 1 roles managed by CloudFormation stacks") {
+		t.Errorf("expected the managed role to be marked and skipped, got:\n%s", out)
+	}
+
+	run(commands.PruneOptions{Force: true})
+	if len(mockClient.DeletedRoles) != 2 {
+		t.Fatalf("expected Orphan and Plain to be deleted, got %v", mockClient.DeletedRoles)
+	}
+	for _, name := range mockClient.DeletedRoles {
+		if name == "Managed" {
+			t.Errorf("expected the stack-managed role to be kept, got %v", mockClient.DeletedRoles)
+		}
+	}
+}
+
+func TestPruneReadsStackTagsWithoutInventory(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.InventoryErrorMode = true
+	mockClient.Roles = []aws.Role{
+		{Name: "Plain", Arn: "arn:aws:iam::123456789012:role/Plain"},
+		stackTagged("Managed", "arn:aws:iam::123456789012:role/Managed", "live", liveStackID),
+		stackTagged("Orphan", "arn:aws:iam::123456789012:role/Orphan", "gone", ""),
+	}
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	aws.SetTestStackClient(&MockStackClient{Statuses: map[string]string{liveStackID: "CREATE_COMPLETE"}})
+	defer aws.ClearTestStackClient()
+	aws.SetTestReferenceScanners()
+	defer aws.ClearTestReferenceScanners()
+	aws.SetTestResourcePolicyScanners()
+	defer aws.ClearTestResourcePolicyScanners()
+
+	originalStdout, originalStderr := os.Stdout, os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stdout, os.Stderr = w, w
+	err := commands.NewPruneCommand("", "", commands.PruneOptions{DryRun: true}).Execute(context.Background())
+	w.Close()
+	os.Stdout, os.Stderr = originalStdout, originalStderr
+	out, _ := io.ReadAll(r)
+
+	if err != nil {
+		t.Fatalf("expected prune to work without the inventory, got %v", err)
+	}
+	if mockClient.TagLookups != 3 {
+		t.Errorf("expected the tags of each role to be listed, got %d lookups", mockClient.TagLookups)
+	}
+	if !strings.Contains(string(out), "[orphaned from stack gone]") || !strings.Contains(string(out), "Skipping 1 roles managed by CloudFormation stacks") {
+		t.Errorf("expected stack ownership to be read from the role tags, got:\n%s", out)
+	}
+	if !strings.Contains(string(out), "iam:GetAccountAuthorizationDetails") {
+		t.Errorf("expected the warning to name the missing inventory permission, got:\n%s", out)
+	}
+}
@@ -0,0 +1,169 @@
+package test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/terraform"
+)
+
+const ciState = `{
+  "version": 4,
+  "terraform_version": "1.7.5",
+  "resources": [
+    {
+      "mode": "managed", "type": "aws_iam_role", "name": "deploy", "module": "module.ci",
+      "instances": [{"attributes": {"name": "Deploy", "arn": "arn:aws:iam::123456789012:role/Deploy"}}]
+    },
+    {
+      "mode": "managed", "type": "aws_iam_role", "name": "worker",
+      "instances": [
+        {"index_key": 0, "attributes": {"name": "Worker0", "arn": "arn:aws:iam::123456789012:role/Worker0"}},
+        {"index_key": 1, "attributes": {"name": "Worker1", "arn": "arn:aws:iam::123456789012:role/Worker1"}}
+      ]
+    },
+    {
+      "mode": "data", "type": "aws_iam_role", "name": "existing",
+      "instances": [{"attributes": {"name": "Plain", "arn": "arn:aws:iam::123456789012:role/Plain"}}]
+    },
+    {
+      "mode": "managed", "type": "aws_iam_role_policy", "name": "deploy",
+      "instances": [{"attributes": {"name": "deploy"}}]
+    }
+  ]
+}`
+
+const appsState = `{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed", "type": "aws_iam_role", "name": "app",
+      "instances": [{"index_key": "api", "attributes": {"name": "Api", "arn": "arn:aws:iam::123456789012:role/Api"}}]
+    },
+    {
+      "mode": "managed", "type": "aws_iam_role", "name": "other_account",
+      "instances": [{"attributes": {"name": "Remote", "arn": "arn:aws:iam::999999999999:role/Remote"}}]
+    }
+  ]
+}`
+
+// writeStates writes a state file at the top of a directory and another
+// one in a nested directory, and returns the directory
+func writeStates(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "apps"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string]string{
+		"ci.tfstate":             ciState,
+		"apps/terraform.tfstate": appsState,
+		"apps/notes.txt":         "not a state",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadTerraformRoles(t *testing.T) {
+	managed, err := terraform.LoadRoles([]string{writeStates(t)})
+	if err != nil {
+		t.Fatalf("LoadRoles failed: %v", err)
+	}
+
+	var addresses []string
+	for _, role := range managed {
+		addresses = append(addresses, role.Address)
+	}
+	expected := []string{
+		`aws_iam_role.app["api"]`,
+		"aws_iam_role.other_account",
+		"module.ci.aws_iam_role.deploy",
+		"aws_iam_role.worker[0]",
+		"aws_iam_role.worker[1]",
+	}
+	if strings.Join(addresses, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected managed aws_iam_role instances %v, got %v", expected, addresses)
+	}
+}
+
+func TestLoadTerraformRolesRejectsOldState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.tfstate")
+	if err := os.WriteFile(path, []byte(`{"version": 3, "modules": []}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := terraform.LoadRoles([]string{path}); err == nil || !strings.Contains(err.Error(), "version 3") {
+		t.Errorf("expected an error for state version 3, got %v", err)
+	}
+}
+
+func TestAttachTerraformAddresses(t *testing.T) {
+	managed, err := terraform.LoadRoles([]string{writeStates(t)})
+	if err != nil {
+		t.Fatalf("LoadRoles failed: %v", err)
+	}
+	roles := []aws.Role{
+		{Name: "Deploy", Arn: "arn:aws:iam::123456789012:role/Deploy"},
+		{Name: "Worker0", Arn: "arn:aws:iam::123456789012:role/Worker0"},
+		{Name: "Plain", Arn: "arn:aws:iam::123456789012:role/Plain"},
+	}
+
+	drift := terraform.AttachAddresses(roles, managed)
+
+	if roles[0].TerraformAddress != "module.ci.aws_iam_role.deploy" || roles[1].TerraformAddress != "aws_iam_role.worker[0]" {
+		t.Errorf("expected managed roles to be annotated, got %q and %q", roles[0].TerraformAddress, roles[1].TerraformAddress)
+	}
+	if roles[2].TerraformAddress != "" {
+		t.Errorf("expected a role only read by a data source to be unmanaged, got %q", roles[2].TerraformAddress)
+	}
+	if len(drift) != 2 || drift[0].Name != "Api" || drift[1].Name != "Worker1" {
+		t.Errorf("expected Api and Worker1 to be reported as drift, ignoring the other account, got %+v", drift)
+	}
+}
+
+func TestPruneSkipsTerraformManagedRoles(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.Roles = []aws.Role{
+		{Name: "Deploy", Arn: "arn:aws:iam::123456789012:role/Deploy"},
+		{Name: "Plain", Arn: "arn:aws:iam::123456789012:role/Plain"},
+	}
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	aws.SetTestReferenceScanners()
+	defer aws.ClearTestReferenceScanners()
+	aws.SetTestResourcePolicyScanners()
+	defer aws.ClearTestResourcePolicyScanners()
+
+	originalStdout, originalStderr := os.Stdout, os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stdout, os.Stderr = w, w
+	err := commands.NewPruneCommand("", "", commands.PruneOptions{
+		Force:          true,
+		TerraformState: []string{writeStates(t)},
+	}).Execute(context.Background())
+	w.Close()
+	os.Stdout, os.Stderr = originalStdout, originalStderr
+	out, _ := io.ReadAll(r)
+
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(mockClient.DeletedRoles) != 1 || mockClient.DeletedRoles[0] != "Plain" {
+		t.Errorf("expected only the unmanaged role to be deleted, got %v", mockClient.DeletedRoles)
+	}
+	if !strings.Contains(string(out), "Deploy: module.ci.aws_iam_role.deploy") {
+		t.Errorf("expected the module address to remove, got:\n%s", out)
+	}
+	if !strings.Contains(string(out), "Role Worker1 is declared at aws_iam_role.worker[1]") {
+		t.Errorf("expected drift of Worker1 to be reported, got:\n%s", out)
+	}
+}
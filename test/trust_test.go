@@ -0,0 +1,209 @@
+package test
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/diff"
+	"hawkling/pkg/policy"
+)
+
+const vendorTrustPolicy = `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Principal": {"AWS": ["arn:aws:iam::111122223333:root", "arn:aws:iam::444455556666:role/Deployer"]},
+      "Action": "sts:AssumeRole",
+      "Condition": {"StringEquals": {"sts:ExternalId": "vendor"}}
+    },
+    {
+      "Effect": "Allow",
+      "Principal": {"Service": "lambda.amazonaws.com"},
+      "Action": "sts:AssumeRole"
+    }
+  ]
+}`
+
+const vendorOnlyTrustPolicy = `{
+  "Version": "2012-10-17",
+  "Statement": {
+    "Effect": "Allow",
+    "Principal": {"AWS": "111122223333"},
+    "Action": "sts:AssumeRole"
+  }
+}`
+
+func TestRemovePrincipal(t *testing.T) {
+	tests := []struct {
+		name            string
+		document        string
+		target          string
+		expectedRemoved []string
+		hasPrincipals   bool
+		statements      int
+	}{
+		{
+			name:            "account ID removes root and role ARNs in the account",
+			document:        vendorTrustPolicy,
+			target:          "444455556666",
+			expectedRemoved: []string{"arn:aws:iam::444455556666:role/Deployer"},
+			hasPrincipals:   true,
+			statements:      2,
+		},
+		{
+			name:            "root ARN matches the bare account ID",
+			document:        vendorOnlyTrustPolicy,
+			target:          "arn:aws:iam::111122223333:root",
+			expectedRemoved: []string{"111122223333"},
+			hasPrincipals:   false,
+			statements:      0,
+		},
+		{
+			name:            "exact ARN only removes that principal",
+			document:        vendorTrustPolicy,
+			target:          "arn:aws:iam::444455556666:role/Other",
+			expectedRemoved: nil,
+			hasPrincipals:   true,
+			statements:      2,
+		},
+		{
+			name:            "removing every AWS principal keeps service principals",
+			document:        strings.ReplaceAll(vendorTrustPolicy, "444455556666", "111122223333"),
+			target:          "111122223333",
+			expectedRemoved: []string{"arn:aws:iam::111122223333:root", "arn:aws:iam::111122223333:role/Deployer"},
+			hasPrincipals:   true,
+			statements:      1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := policy.Parse(test.document)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			removed := doc.RemovePrincipal(test.target)
+
+			if strings.Join(removed, ",") != strings.Join(test.expectedRemoved, ",") {
+				t.Errorf("expected removed %v, got %v", test.expectedRemoved, removed)
+			}
+			if doc.HasPrincipals() != test.hasPrincipals {
+				t.Errorf("expected HasPrincipals() = %v", test.hasPrincipals)
+			}
+			if len(doc.Statement) != test.statements {
+				t.Errorf("expected %d statements, got %d", test.statements, len(doc.Statement))
+			}
+		})
+	}
+}
+
+func TestParseKeepsConditions(t *testing.T) {
+	doc, err := policy.Parse(vendorTrustPolicy)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	output, err := doc.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+
+	if !strings.Contains(output, `"sts:ExternalId": "vendor"`) {
+		t.Errorf("expected condition to be kept, got:\n%s", output)
+	}
+}
+
+func TestParseKeepsPlusInEncodedDocument(t *testing.T) {
+	encoded := url.PathEscape(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::123456789012:user/ops+oncall"},"Action":"sts:AssumeRole"}]}`)
+	encoded = strings.ReplaceAll(encoded, "%2B", "+")
+
+	doc, err := policy.Parse(encoded)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if principals := doc.Statement[0].Principal.Entries["AWS"]; len(principals) != 1 || principals[0] != "arn:aws:iam::123456789012:user/ops+oncall" {
+		t.Errorf("expected the + in the principal to be kept, got %v", principals)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	if output := diff.Unified("a", "b", "same\n", "same\n"); output != "" {
+		t.Errorf("expected no diff for equal input, got %q", output)
+	}
+
+	output := diff.Unified("a", "b", "one\ntwo\nthree\n", "one\n2\nthree\n")
+	expected := "--- a\n+++ b\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n"
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestTrustRemovePrincipalCommand(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.Roles[0].TrustPolicy = vendorTrustPolicy
+	mockClient.Roles[0].Path = "/vendor/"
+	mockClient.Roles[1].TrustPolicy = vendorOnlyTrustPolicy
+	mockClient.Roles[1].Path = "/vendor/"
+	mockClient.Roles[2].TrustPolicy = vendorOnlyTrustPolicy
+	mockClient.Roles[2].Path = "/internal/"
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	backupDir := t.TempDir()
+	options := commands.TrustOptions{
+		FilterOptions: commands.FilterOptions{PathPrefix: "/vendor/"},
+		DryRun:        true,
+		BackupDir:     backupDir,
+	}
+
+	// Dry run must not touch any trust policy
+	originalStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	err := commands.NewTrustRemovePrincipalCommand("test-profile", "us-west-2", "111122223333", options).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+	if err != nil {
+		t.Fatalf("Command execution failed: %v", err)
+	}
+	if len(mockClient.TrustPolicies) != 0 {
+		t.Fatalf("expected no updates in dry run, got %d", len(mockClient.TrustPolicies))
+	}
+
+	options.DryRun = false
+	options.Force = true
+	_, w, _ = os.Pipe()
+	os.Stdout = w
+	err = commands.NewTrustRemovePrincipalCommand("test-profile", "us-west-2", "111122223333", options).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+	if err != nil {
+		t.Fatalf("Command execution failed: %v", err)
+	}
+
+	if len(mockClient.TrustPolicies) != 2 {
+		t.Fatalf("expected 2 updated trust policies, got %d", len(mockClient.TrustPolicies))
+	}
+	if _, ok := mockClient.TrustPolicies["NeverUsedRole"]; ok {
+		t.Errorf("role outside the path prefix should not be updated")
+	}
+	if strings.Contains(mockClient.TrustPolicies["ActiveRole"], "111122223333") {
+		t.Errorf("principal should be removed, got:\n%s", mockClient.TrustPolicies["ActiveRole"])
+	}
+	if !strings.Contains(mockClient.TrustPolicies["InactiveRole"], `"Effect": "Deny"`) {
+		t.Errorf("trust policy without principals should deny all, got:\n%s", mockClient.TrustPolicies["InactiveRole"])
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(backupDir, "trust-policy", "*.json"))
+	if len(backups) != 2 {
+		t.Errorf("expected 2 backups, got %d", len(backups))
+	}
+}
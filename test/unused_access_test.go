@@ -0,0 +1,120 @@
+package test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+)
+
+func unusedAccessRoles() []aws.Role {
+	recent := time.Now().AddDate(0, 0, -5)
+	stale := time.Now().AddDate(0, 0, -200)
+	return []aws.Role{
+		{Name: "BothUnused", Arn: "arn:aws:iam::123456789012:role/BothUnused", LastUsed: &stale},
+		{Name: "AnalyzerUsed", Arn: "arn:aws:iam::123456789012:role/AnalyzerUsed"},
+		{Name: "AnalyzerUnused", Arn: "arn:aws:iam::123456789012:role/AnalyzerUnused", LastUsed: &recent},
+		{Name: "BothUsed", Arn: "arn:aws:iam::123456789012:role/BothUsed", LastUsed: &recent},
+	}
+}
+
+func unusedAccessFindings() []aws.UnusedAccessFinding {
+	return []aws.UnusedAccessFinding{
+		{ID: "f1", RoleArn: "arn:aws:iam::123456789012:role/BothUnused", FindingType: aws.FindingTypeUnusedRole},
+		{ID: "f2", RoleArn: "arn:aws:iam::123456789012:role/AnalyzerUnused", FindingType: aws.FindingTypeUnusedRole},
+		{ID: "f3", RoleArn: "arn:aws:iam::123456789012:role/BothUsed", FindingType: aws.FindingTypeUnusedPermission},
+		{ID: "f4", RoleArn: "arn:aws:iam::210987654321:role/Other", FindingType: aws.FindingTypeUnusedRole},
+	}
+}
+
+func TestUnusedAccessAgreement(t *testing.T) {
+	roles := unusedAccessRoles()
+	aws.AttachUnusedAccess(roles, unusedAccessFindings())
+
+	if access := roles[3].UnusedAccess; access == nil || access.UnusedRole || access.UnusedPermissions != 1 {
+		t.Errorf("expected BothUsed to have one unused permission finding, got %+v", access)
+	}
+	if access := roles[1].UnusedAccess; access == nil || access.UnusedRole {
+		t.Errorf("expected AnalyzerUsed to be used according to Access Analyzer, got %+v", access)
+	}
+
+	unused := aws.FilterRoles(roles, aws.FilterOptions{Days: 90})
+	if len(unused) != 2 {
+		t.Errorf("expected 2 roles unused by last-used date, got %d", len(unused))
+	}
+	agreed := aws.FilterRoles(roles, aws.FilterOptions{Days: 90, RequireAnalyzerAgreement: true})
+	if len(agreed) != 1 || agreed[0].Name != "BothUnused" {
+		t.Errorf("expected only BothUnused when both signals must agree, got %+v", agreed)
+	}
+
+	disagreements := audit.FindUsageDisagreements(roles, 90)
+	if len(disagreements) != 2 {
+		t.Fatalf("expected 2 disagreements, got %+v", disagreements)
+	}
+	if disagreements[0].RoleName != "AnalyzerUsed" || !disagreements[0].LastUsedUnused || disagreements[0].AnalyzerUnused {
+		t.Errorf("unexpected disagreement %+v", disagreements[0])
+	}
+	if disagreements[1].RoleName != "AnalyzerUnused" || disagreements[1].LastUsedUnused || !disagreements[1].AnalyzerUnused {
+		t.Errorf("unexpected disagreement %+v", disagreements[1])
+	}
+	lastUsed := roles[2].LastUsed.Format("2006-01-02")
+	if reason := disagreements[1].Reason; !strings.Contains(reason, "last used "+lastUsed+", within 90 days") {
+		t.Errorf("expected the reason to give the last-used date and window, got %q", reason)
+	}
+
+	// Without a window only never-used roles count as unused by date
+	for _, disagreement := range audit.FindUsageDisagreements(roles, 0) {
+		if disagreement.RoleName == "AnalyzerUnused" && !strings.Contains(disagreement.Reason, "last used "+lastUsed+" with no unused window") {
+			t.Errorf("expected the reason to say no window was set, got %q", disagreement.Reason)
+		}
+	}
+}
+
+func TestPruneRequireAnalyzerAgreement(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.Roles = unusedAccessRoles()
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	analyzer := NewMockAnalyzerClient()
+	analyzer.UnusedFindings = unusedAccessFindings()
+	aws.SetTestAnalyzerClient(analyzer)
+	defer aws.ClearTestAnalyzerClient()
+	aws.SetTestReferenceScanners()
+	defer aws.ClearTestReferenceScanners()
+	aws.SetTestResourcePolicyScanners()
+	defer aws.ClearTestResourcePolicyScanners()
+
+	run := func() error {
+		originalStdout, originalStderr := os.Stdout, os.Stderr
+		_, w, _ := os.Pipe()
+		os.Stdout, os.Stderr = w, w
+		err := commands.NewPruneCommand("", "", commands.PruneOptions{
+			FilterOptions: commands.FilterOptions{Days: 90, RequireAnalyzerAgreement: true},
+			Force:         true,
+		}).Execute(context.Background())
+		w.Close()
+		os.Stdout, os.Stderr = originalStdout, originalStderr
+		return err
+	}
+
+	if err := run(); err == nil {
+		t.Errorf("expected requiring agreement without an analyzer to fail")
+	}
+	if len(mockClient.DeletedRoles) != 0 {
+		t.Errorf("expected no roles to be deleted without an analyzer, got %v", mockClient.DeletedRoles)
+	}
+
+	analyzer.AnalyzerArn = "arn:aws:access-analyzer:us-east-1:123456789012:analyzer/unused"
+	if err := run(); err != nil {
+		t.Fatalf("Command execution failed: %v", err)
+	}
+	if len(mockClient.DeletedRoles) != 1 || mockClient.DeletedRoles[0] != "BothUnused" {
+		t.Errorf("expected only BothUnused to be deleted, got %v", mockClient.DeletedRoles)
+	}
+}
@@ -0,0 +1,192 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"hawkling/cmd/hawkling/commands"
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+)
+
+const (
+	validateInline = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`
+	validateTrust  = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"*"},"Action":"sts:AssumeRole"}]}`
+)
+
+func TestAnalyzerClientEndpointOverride(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_CONFIG_FILE", os.DevNull)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", os.DevNull)
+
+	var request map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/policy/validation" {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &request)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"findings":[{
+			"findingType":"SECURITY_WARNING",
+			"issueCode":"TRUST_ANY_PRINCIPAL",
+			"findingDetails":"Trusting any principal is overly permissive.",
+			"learnMoreLink":"https://example.com",
+			"locations":[{"path":[{"value":"Statement"},{"index":0},{"value":"Principal"},{"key":"AWS"}],
+				"span":{"start":{"line":0,"column":60,"offset":60},"end":{"line":0,"column":71,"offset":71}}}]}]}`)
+	}))
+	defer server.Close()
+
+	client, err := aws.NewAnalyzerClient(context.Background(), "", "us-east-1", server.URL)
+	if err != nil {
+		t.Fatalf("NewAnalyzerClient() error = %v", err)
+	}
+
+	findings, err := client.ValidatePolicy(context.Background(), validateTrust, aws.PolicyKindTrust)
+	if err != nil {
+		t.Fatalf("ValidatePolicy() error = %v", err)
+	}
+
+	if request["policyType"] != "RESOURCE_POLICY" || request["validatePolicyResourceType"] != "AWS::IAM::AssumeRolePolicyDocument" {
+		t.Errorf("expected a trust policy to be validated as a role trust policy, got %v", request)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", findings)
+	}
+	if findings[0].FindingType != aws.FindingTypeSecurityWarning || len(findings[0].Locations) != 1 || findings[0].Locations[0] != "Statement[0].Principal.AWS" {
+		t.Errorf("unexpected finding %+v", findings[0])
+	}
+}
+
+func validateRoles() []aws.Role {
+	return []aws.Role{
+		{Name: "Clean", InlinePolicies: []aws.Policy{{Name: "read", IsInline: true, Document: `{"Statement":[]}`}}},
+		{Name: "Broad", TrustPolicy: validateTrust, InlinePolicies: []aws.Policy{{Name: "s3", IsInline: true, Document: validateInline}}},
+	}
+}
+
+func validateAnalyzer() *MockAnalyzerClient {
+	analyzer := NewMockAnalyzerClient()
+	analyzer.Findings[validateInline] = []aws.ValidationFinding{
+		{FindingType: aws.FindingTypeSuggestion, IssueCode: "EMPTY_ARRAY_ACTION", Locations: []string{"Statement[0].Action"}},
+		{FindingType: aws.FindingTypeError, IssueCode: "MISSING_VERSION", Locations: []string{"Statement[0].Resource", "Statement[0].Action"}},
+	}
+	analyzer.Findings[validateTrust] = []aws.ValidationFinding{
+		{FindingType: aws.FindingTypeSecurityWarning, IssueCode: "TRUST_ANY_PRINCIPAL", Locations: []string{"Statement[0].Principal.AWS"}},
+	}
+	return analyzer
+}
+
+func TestValidateRoles(t *testing.T) {
+	roles := validateRoles()
+	analyzer := validateAnalyzer()
+
+	findings := make(map[string][]audit.PolicyFinding)
+	for _, role := range roles {
+		roleFindings, err := audit.ValidateRole(context.Background(), analyzer, role)
+		if err != nil {
+			t.Fatalf("ValidateRole(%s) error = %v", role.Name, err)
+		}
+		findings[role.Name] = roleFindings
+	}
+	if analyzer.Validated[validateTrust] != aws.PolicyKindTrust || analyzer.Validated[validateInline] != aws.PolicyKindInline {
+		t.Errorf("expected inline and trust policies to be validated with their kinds, got %v", analyzer.Validated)
+	}
+
+	validations := audit.SummarizeValidation(roles, findings, nil)
+	if len(validations) != 1 || validations[0].RoleName != "Broad" {
+		t.Fatalf("expected only Broad to have findings, got %+v", validations)
+	}
+	broad := validations[0]
+	if broad.Errors != 2 || broad.SecurityWarnings != 1 || broad.Suggestions != 1 || len(broad.Findings) != 4 {
+		t.Errorf("unexpected finding counts %+v", broad)
+	}
+	if first := broad.Findings[0]; first.FindingType != aws.FindingTypeError || first.Location != "Statement[0].Action" {
+		t.Errorf("expected errors first, sorted by location, got %+v", first)
+	}
+
+	securityOnly := audit.SummarizeValidation(roles, findings, []string{aws.FindingTypeSecurityWarning})
+	if len(securityOnly) != 1 || len(securityOnly[0].Findings) != 1 || securityOnly[0].Findings[0].PolicyName != "trust policy" {
+		t.Errorf("expected only the trust policy security warning, got %+v", securityOnly)
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	mockClient := NewMockIAMClient()
+	mockClient.Roles = validateRoles()
+	aws.SetTestClient(mockClient)
+	defer aws.ClearTestClient()
+
+	analyzer := validateAnalyzer()
+	aws.SetTestAnalyzerClient(analyzer)
+	defer aws.ClearTestAnalyzerClient()
+
+	run := func(options commands.ValidateOptions) error {
+		originalStdout, originalStderr := os.Stdout, os.Stderr
+		_, w, _ := os.Pipe()
+		os.Stdout, os.Stderr = w, w
+		err := commands.NewValidateCommand("", "", options).Execute(context.Background())
+		w.Close()
+		os.Stdout, os.Stderr = originalStdout, originalStderr
+		return err
+	}
+
+	if err := run(commands.ValidateOptions{Output: "json", FindingTypes: []string{"security-warning", "Error"}}); err != nil {
+		t.Errorf("Command execution failed: %v", err)
+	}
+	if err := run(commands.ValidateOptions{Output: "table", FindingTypes: []string{"critical"}}); err == nil {
+		t.Errorf("expected an unknown finding type to be rejected")
+	}
+
+	// SARIF output has a rule per issue code and a result per finding
+	originalStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := commands.NewValidateCommand("", "", commands.ValidateOptions{Output: "sarif"}).Execute(context.Background())
+	w.Close()
+	os.Stdout = originalStdout
+	out, _ := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Command execution failed: %v", err)
+	}
+	var log struct {
+		Version string
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct{ ID string }
+				}
+			}
+			Results []struct {
+				RuleID string
+				Level  string
+			}
+		}
+	}
+	if err := json.Unmarshal(out, &log); err != nil || log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("expected a SARIF 2.1.0 log, got %v:\n%s", err, out)
+	}
+	if rules := log.Runs[0].Tool.Driver.Rules; len(rules) != 3 {
+		t.Errorf("expected a rule per issue code, got %+v", rules)
+	}
+	levels := make(map[string]string)
+	for _, result := range log.Runs[0].Results {
+		levels[result.RuleID] = result.Level
+	}
+	if len(log.Runs[0].Results) != 4 || levels["TRUST_ANY_PRINCIPAL"] != "error" || levels["EMPTY_ARRAY_ACTION"] != "note" {
+		t.Errorf("expected every finding with its level, got %+v", log.Runs[0].Results)
+	}
+
+	analyzer.ErrorMode = true
+	if err := run(commands.ValidateOptions{Output: "table"}); err == nil {
+		t.Errorf("expected validation failures to be reported")
+	}
+}
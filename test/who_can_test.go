@@ -0,0 +1,140 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"hawkling/pkg/audit"
+	"hawkling/pkg/aws"
+	"hawkling/pkg/policy"
+)
+
+func TestWhoCan(t *testing.T) {
+	recent := time.Now().AddDate(0, 0, -5)
+	stale := time.Now().AddDate(0, 0, -400)
+
+	inventory := &aws.Inventory{
+		Roles: []aws.Role{
+			{
+				Name:     "ActiveWriter",
+				LastUsed: &recent,
+				InlinePolicies: []aws.Policy{{Name: "Write", IsInline: true,
+					Document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"arn:aws:s3:::prod-bucket/*"}]}`}},
+			},
+			{
+				Name:             "StaleAdmin",
+				LastUsed:         &stale,
+				AttachedPolicies: []aws.Policy{{Name: "AdministratorAccess", Arn: "arn:aws:iam::aws:policy/AdministratorAccess"}},
+			},
+			{
+				Name:                "BoundedAdmin",
+				AttachedPolicies:    []aws.Policy{{Name: "AdministratorAccess", Arn: "arn:aws:iam::aws:policy/AdministratorAccess"}},
+				PermissionsBoundary: "arn:aws:iam::123456789012:policy/ReadOnlyBoundary",
+			},
+			{
+				Name: "NeverUsedWriter",
+				InlinePolicies: []aws.Policy{{Name: "Write", IsInline: true,
+					Document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:DeleteObject","Resource":"*"}]}`}},
+			},
+			{
+				Name:             "Broken",
+				AttachedPolicies: []aws.Policy{{Name: "Missing", Arn: "arn:aws:iam::123456789012:policy/Missing"}},
+			},
+		},
+		Policies: map[string]aws.Policy{
+			"arn:aws:iam::aws:policy/AdministratorAccess": {
+				Name:     "AdministratorAccess",
+				Document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`,
+			},
+			"arn:aws:iam::123456789012:policy/ReadOnlyBoundary": {
+				Name:     "ReadOnlyBoundary",
+				Document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:Get*","s3:List*"],"Resource":"*"}]}`,
+			},
+		},
+	}
+
+	roles, skipped := audit.WhoCan(inventory, policy.Request{Action: "s3:DeleteObject", Resource: "arn:aws:s3:::prod-bucket/data.csv"}, 90)
+
+	var names []string
+	for _, role := range roles {
+		names = append(names, role.RoleName)
+	}
+	expected := []string{"NeverUsedWriter", "StaleAdmin", "ActiveWriter"}
+	if len(names) != len(expected) {
+		t.Fatalf("expected roles %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("expected roles %v in that order, got %v", expected, names)
+			break
+		}
+	}
+
+	if !roles[0].Unused || !roles[1].Unused || roles[2].Unused {
+		t.Errorf("expected unused roles to be marked and ranked first, got %+v", roles)
+	}
+
+	if len(skipped) != 1 || skipped[0].RoleName != "Broken" {
+		t.Errorf("expected Broken to be skipped, got %+v", skipped)
+	}
+
+	// The boundary allows reads, so the bounded role shows up for s3:GetObject
+	roles, _ = audit.WhoCan(inventory, policy.Request{Action: "s3:GetObject", Resource: "arn:aws:s3:::prod-bucket/data.csv"}, 90)
+	found := false
+	for _, role := range roles {
+		if role.RoleName == "BoundedAdmin" {
+			found = true
+			if role.PermissionsBoundary == "" {
+				t.Errorf("expected BoundedAdmin to report its permissions boundary")
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected BoundedAdmin to be allowed s3:GetObject")
+	}
+}
+
+func TestWhoCanConditional(t *testing.T) {
+	allowIf := func(condition string) []aws.Policy {
+		return []aws.Policy{{Name: "Conditional", IsInline: true,
+			Document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:DeleteObject","Resource":"*","Condition":` + condition + `}]}`}}
+	}
+	inventory := &aws.Inventory{
+		Roles: []aws.Role{
+			{Name: "OfficeOnly", InlinePolicies: allowIf(`{"IpAddress":{"aws:SourceIp":"203.0.113.0/24"}}`)},
+			{Name: "TeamOnly", InlinePolicies: allowIf(`{"StringEquals":{"aws:PrincipalTag/team":"data"}}`)},
+			{Name: "OtherRegion", InlinePolicies: allowIf(`{"StringEquals":{"aws:RequestedRegion":"eu-west-1"}}`)},
+		},
+	}
+
+	request := policy.Request{
+		Action:   "s3:DeleteObject",
+		Resource: "arn:aws:s3:::prod-bucket/data.csv",
+		Context:  map[string][]string{"aws:RequestedRegion": {"us-east-1"}},
+	}
+	roles, _ := audit.WhoCan(inventory, request, 90)
+
+	if len(roles) != 2 {
+		t.Fatalf("expected the two roles gated on missing keys, got %+v", roles)
+	}
+	expected := map[string]string{"OfficeOnly": "aws:SourceIp", "TeamOnly": "aws:PrincipalTag/team"}
+	for _, role := range roles {
+		key, ok := expected[role.RoleName]
+		if !ok {
+			t.Errorf("expected a role denied by a known key to be left out, got %s", role.RoleName)
+			continue
+		}
+		if !role.Conditional || len(role.Unresolved) != 1 || role.Unresolved[0] != key || len(role.Matches) != 1 {
+			t.Errorf("expected %s to be conditional on %s, got %+v", role.RoleName, key, role)
+		}
+	}
+
+	// With the key in the context the role is either allowed or left out
+	request.Context["aws:SourceIp"] = []string{"203.0.113.10"}
+	roles, _ = audit.WhoCan(inventory, request, 90)
+	for _, role := range roles {
+		if role.RoleName == "OfficeOnly" && role.Conditional {
+			t.Errorf("expected OfficeOnly to be allowed once its key is resolved, got %+v", role)
+		}
+	}
+}